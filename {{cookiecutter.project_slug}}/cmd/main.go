@@ -2,45 +2,131 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"log/slog"
 	"os"
+	"os/signal"
+	"time"
 
 	"{{cookiecutter.module_name}}/internal/config"
+	"{{cookiecutter.module_name}}/internal/consumer"
 	"{{cookiecutter.module_name}}/internal/db"
+	"{{cookiecutter.module_name}}/internal/gcp"
 	"{{cookiecutter.module_name}}/internal/logger"
+	"{{cookiecutter.module_name}}/internal/metrics"
+	"{{cookiecutter.module_name}}/internal/outbox"
 	"{{cookiecutter.module_name}}/internal/server"
+	"{{cookiecutter.module_name}}/internal/tracing"
 	"{{cookiecutter.module_name}}/internal/version"
 )
 
 func main() {
+	if err := run(); err != nil {
+		slog.Error("application error", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+}
+
+// run starts the application and blocks until it shuts down. Errors are
+// returned rather than exiting, so the deferred cleanup of the tracer, the
+// database and the background workers runs on every path.
+func run() error {
 	// Get version information
 	version, err := version.Get()
 	if err != nil {
-		panic(err)
+		return fmt.Errorf("get version: %w", err)
 	}
 
-	ctx := context.Background()
+	// The server, the outbox relay and the consumer all stop on the same interrupt
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
 	// Initialize structured logging
 	logger.Init(version)
 	log := slog.Default()
 
 	bootstrap, err := config.NewBootStrap(ctx, log)
 	if err != nil {
-		log.Error("failed to initialize bootstrap", slog.String("error", err.Error()))
-		os.Exit(1)
+		return fmt.Errorf("initialize bootstrap: %w", err)
 	}
 	cfg, err := bootstrap.Load(ctx)
 	if err != nil {
-		log.Error("failed to load config", slog.String("error", err.Error()))
-		os.Exit(1)
+		return fmt.Errorf("load config: %w", err)
 	}
+	// Trace requests, queries and messages, trace IDs are logged for Cloud Logging
+	shutdownTracing, err := tracing.Setup(ctx, cfg.TraceExporter, version)
+	if err != nil {
+		return fmt.Errorf("set up tracing: %w", err)
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			log.Error("failed to flush traces", slog.String("error", err.Error()))
+		}
+	}()
+	logger.SetTraceProject(cfg.ProjectID)
+
 	// Initialize database connection
 	makeDb := db.MakeDbFactory(cfg.Env)
-	db, cleanupFn := makeDb(cfg.DB.DSN, log)
+	db, cleanupFn, err := makeDb(cfg.DB.DSN, log)
+	if err != nil {
+		return err
+	}
 	defer cleanupFn()
+	if err := db.Use(tracing.GormPlugin{}); err != nil {
+		return fmt.Errorf("trace database queries: %w", err)
+	}
 
 	deps := server.NewDeps(ctx, db, cfg, log)
 
+	// Publish request, connection pool and build metrics at /metrics
+	deps.Metrics = metrics.New(version)
+	if err := deps.Metrics.RegisterDB(db, "postgres"); err != nil {
+		log.Warn("connection pool metrics are disabled", slog.String("error", err.Error()))
+	}
+
+	// Relay outbox messages to Pub/Sub, or keep them in memory when running locally
+	var publisher outbox.Publisher = outbox.NewMemoryPublisher(log)
+	if cfg.Env != "local" {
+		publisher, err = gcp.NewMessageRepository(ctx, log, cfg.ProjectID)
+		if err != nil {
+			return fmt.Errorf("create message repository: %w", err)
+		}
+	}
+	relayed := make(chan struct{})
+	go func() {
+		defer close(relayed)
+		outbox.NewRelay(db, publisher, log, outbox.RelayConfig{}).Run(ctx)
+	}()
+	// stop the relay and wait for it before the database is closed
+	defer func() {
+		stop()
+		<-relayed
+	}()
+
+	// Consume events from Pub/Sub when a subscription is configured
+	if cfg.Subscription != "" {
+		sub, err := gcp.NewSubscription(ctx, cfg.ProjectID, cfg.Subscription, 100)
+		if err != nil {
+			return fmt.Errorf("create subscription: %w", err)
+		}
+		c := consumer.New(sub, log, consumer.Config{})
+		// Register handlers with c.Handle, messages of other events are nacked
+		// until they reach the dead letter topic
+		consumed := make(chan struct{})
+		go func() {
+			defer close(consumed)
+			if err := c.Run(ctx); err != nil {
+				log.Error("consumer error", slog.String("error", err.Error()))
+			}
+		}()
+		// stop the consumer and wait for it to drain
+		defer func() {
+			stop()
+			<-consumed
+		}()
+	}
+
 	params := server.StartServerParams{
 		ParentCtx:       ctx,
 		Version:         version,
@@ -49,9 +135,5 @@ func main() {
 	}
 
 	_, err = server.StartServer(params, deps)
-
-	if err != nil {
-		log.Error("application error", slog.String("error", err.Error()))
-		os.Exit(1)
-	}
+	return err
 }
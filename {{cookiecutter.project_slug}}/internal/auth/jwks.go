@@ -0,0 +1,131 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-jose/go-jose/v4"
+)
+
+var ErrUnknownKey = errors.New("unknown signing key")
+
+type JWKSConfig struct {
+	Client     *http.Client  // used to fetch the key set, a client with a 10s timeout when nil
+	TTL        time.Duration // time before the key set is fetched again, 1h when zero
+	MinRefresh time.Duration // minimum time between two fetches, 1m when zero
+}
+
+// JWKS caches the JSON Web Key Set published by an identity provider.
+//
+// Keys are fetched on first use and again after TTL. A token signed with a
+// key that is not in the cache triggers a fetch as well, so keys the
+// provider rotates in are picked up straight away. Fetches are at least
+// MinRefresh apart, so tokens with made up key IDs or an unavailable
+// provider do not cause a fetch per request. When a fetch fails the
+// keys fetched before stay in use.
+type JWKS struct {
+	url       string
+	cfg       JWKSConfig
+	mu        sync.Mutex
+	keys      []jose.JSONWebKey
+	fetched   time.Time
+	attempted time.Time
+	now       func() time.Time
+}
+
+func NewJWKS(url string, cfg JWKSConfig) *JWKS {
+	if cfg.Client == nil {
+		cfg.Client = &http.Client{Timeout: 10 * time.Second}
+	}
+	if cfg.TTL <= 0 {
+		cfg.TTL = time.Hour
+	}
+	if cfg.MinRefresh <= 0 {
+		cfg.MinRefresh = time.Minute
+	}
+	return &JWKS{url: url, cfg: cfg, now: time.Now}
+}
+
+// Key returns the signing key with the key ID kid. A token without a key ID
+// can only be verified when the set holds a single signing key.
+func (j *JWKS) Key(ctx context.Context, kid string) (*jose.JSONWebKey, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	now := j.now()
+	key, found := j.lookup(kid)
+	if found && now.Sub(j.fetched) < j.cfg.TTL {
+		return key, nil
+	}
+	if now.Sub(j.attempted) < j.cfg.MinRefresh {
+		if found {
+			return key, nil
+		}
+		return nil, ErrUnknownKey
+	}
+
+	j.attempted = now
+	if err := j.fetch(ctx); err != nil {
+		if found {
+			return key, nil
+		}
+		return nil, err
+	}
+	if key, found = j.lookup(kid); !found {
+		return nil, ErrUnknownKey
+	}
+	return key, nil
+}
+
+func (j *JWKS) lookup(kid string) (*jose.JSONWebKey, bool) {
+	var match *jose.JSONWebKey
+	for i, key := range j.keys {
+		if key.Use != "" && key.Use != "sig" {
+			continue
+		}
+		if kid == "" || key.KeyID == kid {
+			if match != nil {
+				// ambiguous without a key ID
+				return nil, false
+			}
+			match = &j.keys[i]
+		}
+	}
+	return match, match != nil
+}
+
+func (j *JWKS) fetch(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, j.url, nil)
+	if err != nil {
+		return fmt.Errorf("create jwks request: %w", err)
+	}
+	resp, err := j.cfg.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("fetch jwks: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("fetch jwks: unexpected status %d", resp.StatusCode)
+	}
+
+	var set jose.JSONWebKeySet
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return fmt.Errorf("decode jwks: %w", err)
+	}
+
+	keys := set.Keys[:0]
+	for _, key := range set.Keys {
+		// never trust private or symmetric keys for verification
+		if key.IsPublic() {
+			keys = append(keys, key)
+		}
+	}
+	j.keys = keys
+	j.fetched = j.now()
+	return nil
+}
@@ -0,0 +1,87 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestJWKS_Caching(t *testing.T) {
+	provider := newIdentityProvider(t)
+	provider.addKey(t, "rs-1")
+	keys := NewJWKS(provider.server.URL, JWKSConfig{Client: provider.server.Client()})
+	now := time.Now()
+	keys.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	key := func(kid string) error {
+		t.Helper()
+		_, err := keys.Key(ctx, kid)
+		return err
+	}
+	fetches := func(want int32) {
+		t.Helper()
+		if got := provider.fetches.Load(); got != want {
+			t.Errorf("fetches = %d, want %d", got, want)
+		}
+	}
+
+	// the first lookup fetches, later ones are served from the cache
+	if err := key("rs-1"); err != nil {
+		t.Fatalf("Key() error = %v", err)
+	}
+	if err := key("rs-1"); err != nil {
+		t.Fatalf("Key() error = %v", err)
+	}
+	fetches(1)
+
+	// a rotated in key is fetched once MinRefresh has passed
+	provider.addKey(t, "rs-2")
+	if err := key("rs-2"); !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("Key() error = %v, want ErrUnknownKey within MinRefresh", err)
+	}
+	fetches(1)
+	now = now.Add(time.Minute)
+	if err := key("rs-2"); err != nil {
+		t.Fatalf("Key() error = %v", err)
+	}
+	fetches(2)
+
+	// unknown keys do not cause a fetch per lookup
+	if err := key("missing"); !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("Key() error = %v, want ErrUnknownKey", err)
+	}
+	fetches(2)
+
+	// a rotated out key is gone after TTL
+	provider.removeKey("rs-1")
+	now = now.Add(time.Hour)
+	if err := key("rs-1"); !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("Key() error = %v, want ErrUnknownKey after TTL", err)
+	}
+	fetches(3)
+
+	// cached keys stay in use while the provider is down
+	provider.server.Close()
+	now = now.Add(time.Hour)
+	if err := key("rs-2"); err != nil {
+		t.Errorf("Key() error = %v, want the cached key", err)
+	}
+}
+
+func TestJWKS_KeyWithoutID(t *testing.T) {
+	provider := newIdentityProvider(t)
+	provider.addKey(t, "rs-1")
+	keys := NewJWKS(provider.server.URL, JWKSConfig{Client: provider.server.Client()})
+
+	if _, err := keys.Key(context.Background(), ""); err != nil {
+		t.Errorf("Key() error = %v, want the only key", err)
+	}
+
+	provider.addKey(t, "rs-2")
+	keys.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	if _, err := keys.Key(context.Background(), ""); !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("Key() error = %v, want ErrUnknownKey with two keys", err)
+	}
+}
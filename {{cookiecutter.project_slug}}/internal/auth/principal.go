@@ -0,0 +1,41 @@
+package auth
+
+import (
+	"context"
+	"slices"
+)
+
+// contextKey is a private type for context keys to avoid collisions.
+type contextKey string
+
+const principalKey contextKey = "principal"
+
+// Principal is the verified caller of a request.
+type Principal struct {
+	Subject string
+	Issuer  string
+	Scopes  []string // from the "scope" or "scp" claim
+	Roles   []string // from the "roles" claim
+}
+
+// HasScope reports whether the principal was granted scope.
+func (p *Principal) HasScope(scope string) bool {
+	return slices.Contains(p.Scopes, scope)
+}
+
+// HasRole reports whether the principal has role.
+func (p *Principal) HasRole(role string) bool {
+	return slices.Contains(p.Roles, role)
+}
+
+// ToContext adds a principal to the context.
+func ToContext(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, principalKey, principal)
+}
+
+// FromContext retrieves the principal from the context. It returns false
+// for unauthenticated requests and outside of a request.
+func FromContext(ctx context.Context) (*Principal, bool) {
+	principal, ok := ctx.Value(principalKey).(*Principal)
+	return principal, ok
+}
@@ -0,0 +1,100 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-jose/go-jose/v4"
+	"github.com/go-jose/go-jose/v4/jwt"
+)
+
+// ErrInvalidToken is returned for tokens that must be rejected. Other errors
+// from Verify mean the token could not be checked, for example because the
+// key set is unavailable.
+var ErrInvalidToken = errors.New("invalid token")
+
+// algorithms are the signature algorithms tokens may use. Symmetric and
+// unsigned tokens are rejected when they are parsed.
+var algorithms = []jose.SignatureAlgorithm{jose.RS256, jose.ES256}
+
+type Config struct {
+	Issuer   string        // required "iss" claim
+	Audience string        // value the "aud" claim must contain
+	Leeway   time.Duration // clock skew allowed when checking exp, nbf and iat, 1m when zero
+}
+
+// Verifier checks signed JWTs against the keys of a JWKS.
+type Verifier struct {
+	keys *JWKS
+	cfg  Config
+	now  func() time.Time
+}
+
+func NewVerifier(keys *JWKS, cfg Config) *Verifier {
+	if cfg.Leeway <= 0 {
+		cfg.Leeway = time.Minute
+	}
+	return &Verifier{keys: keys, cfg: cfg, now: time.Now}
+}
+
+// claims are the claims read beyond the registered ones.
+type claims struct {
+	Scope string   `json:"scope"`
+	Scp   []string `json:"scp"`
+	Roles []string `json:"roles"`
+}
+
+// Verify checks the signature, issuer, audience and expiry of token and
+// returns the principal it was issued to.
+func (v *Verifier) Verify(ctx context.Context, token string) (*Principal, error) {
+	parsed, err := jwt.ParseSigned(token, algorithms)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidToken, err)
+	}
+	header := parsed.Headers[0]
+
+	key, err := v.keys.Key(ctx, header.KeyID)
+	if errors.Is(err, ErrUnknownKey) {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidToken, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if key.Algorithm != "" && key.Algorithm != header.Algorithm {
+		return nil, fmt.Errorf("%w: key %s is not for %s", ErrInvalidToken, key.KeyID, header.Algorithm)
+	}
+
+	var registered jwt.Claims
+	var extra claims
+	if err := parsed.Claims(key.Key, &registered, &extra); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidToken, err)
+	}
+	if registered.Expiry == nil {
+		return nil, fmt.Errorf("%w: missing exp", ErrInvalidToken)
+	}
+	expected := jwt.Expected{
+		Issuer:      v.cfg.Issuer,
+		AnyAudience: jwt.Audience{v.cfg.Audience},
+		Time:        v.now(),
+	}
+	if err := registered.ValidateWithLeeway(expected, v.cfg.Leeway); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidToken, err)
+	}
+	if registered.Subject == "" {
+		return nil, fmt.Errorf("%w: missing sub", ErrInvalidToken)
+	}
+
+	scopes := extra.Scp
+	if extra.Scope != "" {
+		scopes = strings.Fields(extra.Scope)
+	}
+	return &Principal{
+		Subject: registered.Subject,
+		Issuer:  registered.Issuer,
+		Scopes:  scopes,
+		Roles:   extra.Roles,
+	}, nil
+}
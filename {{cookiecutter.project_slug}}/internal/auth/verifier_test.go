@@ -0,0 +1,237 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-jose/go-jose/v4"
+	"github.com/go-jose/go-jose/v4/jwt"
+)
+
+const (
+	testIssuer   = "https://issuer.test"
+	testAudience = "api"
+)
+
+// identityProvider serves a JWKS from httptest and signs tokens with its keys.
+type identityProvider struct {
+	server  *httptest.Server
+	mu      sync.Mutex
+	keys    map[string]crypto.Signer
+	fetches atomic.Int32
+}
+
+func newIdentityProvider(t *testing.T) *identityProvider {
+	t.Helper()
+	p := &identityProvider{keys: map[string]crypto.Signer{}}
+	p.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p.fetches.Add(1)
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		var set jose.JSONWebKeySet
+		for kid, key := range p.keys {
+			set.Keys = append(set.Keys, jose.JSONWebKey{Key: key.Public(), KeyID: kid, Use: "sig"})
+		}
+		json.NewEncoder(w).Encode(set)
+	}))
+	t.Cleanup(p.server.Close)
+	return p
+}
+
+// addKey publishes a new key, kid decides its type: "es" keys are ECDSA P-256, others RSA.
+func (p *identityProvider) addKey(t *testing.T, kid string) crypto.Signer {
+	t.Helper()
+	var key crypto.Signer
+	var err error
+	if kid[:2] == "es" {
+		key, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	} else {
+		key, err = rsa.GenerateKey(rand.Reader, 2048)
+	}
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.keys[kid] = key
+	return key
+}
+
+func (p *identityProvider) removeKey(kid string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.keys, kid)
+}
+
+func sign(t *testing.T, key crypto.Signer, kid string, claims any) string {
+	t.Helper()
+	alg := jose.RS256
+	if _, ok := key.(*ecdsa.PrivateKey); ok {
+		alg = jose.ES256
+	}
+	signer, err := jose.NewSigner(
+		jose.SigningKey{Algorithm: alg, Key: key},
+		(&jose.SignerOptions{}).WithType("JWT").WithHeader("kid", kid),
+	)
+	if err != nil {
+		t.Fatalf("create signer: %v", err)
+	}
+	token, err := jwt.Signed(signer).Claims(claims).Serialize()
+	if err != nil {
+		t.Fatalf("sign token: %v", err)
+	}
+	return token
+}
+
+func validClaims() map[string]any {
+	now := time.Now()
+	return map[string]any{
+		"iss":   testIssuer,
+		"aud":   []string{testAudience},
+		"sub":   "user-1",
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+		"scope": "project:read project:write",
+		"roles": []string{"admin"},
+	}
+}
+
+func newTestVerifier(p *identityProvider) *Verifier {
+	keys := NewJWKS(p.server.URL, JWKSConfig{Client: p.server.Client()})
+	return NewVerifier(keys, Config{Issuer: testIssuer, Audience: testAudience})
+}
+
+func TestVerifier_Verify(t *testing.T) {
+	provider := newIdentityProvider(t)
+	rsaKey := provider.addKey(t, "rs-1")
+	ecKey := provider.addKey(t, "es-1")
+	verifier := newTestVerifier(provider)
+
+	with := func(key string, value any) map[string]any {
+		claims := validClaims()
+		if value == nil {
+			delete(claims, key)
+		} else {
+			claims[key] = value
+		}
+		return claims
+	}
+
+	tests := []struct {
+		name    string
+		token   func(t *testing.T) string
+		wantErr bool
+	}{
+		{
+			name:  "RS256",
+			token: func(t *testing.T) string { return sign(t, rsaKey, "rs-1", validClaims()) },
+		},
+		{
+			name:  "ES256",
+			token: func(t *testing.T) string { return sign(t, ecKey, "es-1", validClaims()) },
+		},
+		{
+			name:    "Wrong Issuer",
+			token:   func(t *testing.T) string { return sign(t, rsaKey, "rs-1", with("iss", "https://other.test")) },
+			wantErr: true,
+		},
+		{
+			name:    "Wrong Audience",
+			token:   func(t *testing.T) string { return sign(t, rsaKey, "rs-1", with("aud", []string{"other"})) },
+			wantErr: true,
+		},
+		{
+			name: "Expired",
+			token: func(t *testing.T) string {
+				return sign(t, rsaKey, "rs-1", with("exp", time.Now().Add(-time.Hour).Unix()))
+			},
+			wantErr: true,
+		},
+		{
+			name:    "Missing Expiry",
+			token:   func(t *testing.T) string { return sign(t, rsaKey, "rs-1", with("exp", nil)) },
+			wantErr: true,
+		},
+		{
+			name:    "Missing Subject",
+			token:   func(t *testing.T) string { return sign(t, rsaKey, "rs-1", with("sub", nil)) },
+			wantErr: true,
+		},
+		{
+			name:    "Signed By Another Key",
+			token:   func(t *testing.T) string { return sign(t, ecKey, "rs-1", validClaims()) },
+			wantErr: true,
+		},
+		{
+			name:    "Unknown Key",
+			token:   func(t *testing.T) string { return sign(t, rsaKey, "missing", validClaims()) },
+			wantErr: true,
+		},
+		{
+			name: "HS256",
+			token: func(t *testing.T) string {
+				signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.HS256, Key: []byte("0123456789abcdef0123456789abcdef")}, nil)
+				if err != nil {
+					t.Fatalf("create signer: %v", err)
+				}
+				token, err := jwt.Signed(signer).Claims(validClaims()).Serialize()
+				if err != nil {
+					t.Fatalf("sign token: %v", err)
+				}
+				return token
+			},
+			wantErr: true,
+		},
+		{
+			name:    "Malformed",
+			token:   func(t *testing.T) string { return "not.a.token" },
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			principal, err := verifier.Verify(context.Background(), tt.token(t))
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidToken) {
+					t.Fatalf("Verify() error = %v, want ErrInvalidToken", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Verify() error = %v", err)
+			}
+			if principal.Subject != "user-1" || principal.Issuer != testIssuer {
+				t.Errorf("principal = %+v, want subject user-1 of %s", principal, testIssuer)
+			}
+			if !principal.HasScope("project:read") || !principal.HasScope("project:write") || !principal.HasRole("admin") {
+				t.Errorf("principal = %+v, want project:read, project:write and admin", principal)
+			}
+		})
+	}
+}
+
+func TestVerifier_KeySetUnavailable(t *testing.T) {
+	provider := newIdentityProvider(t)
+	key := provider.addKey(t, "rs-1")
+	verifier := newTestVerifier(provider)
+	token := sign(t, key, "rs-1", validClaims())
+	provider.server.Close()
+
+	_, err := verifier.Verify(context.Background(), token)
+	if err == nil || errors.Is(err, ErrInvalidToken) {
+		t.Errorf("Verify() error = %v, want a fetch error", err)
+	}
+}
@@ -0,0 +1,36 @@
+package codec
+
+import (
+	"io"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+// minCBORDepth is the lowest nesting limit the CBOR decoder accepts.
+const minCBORDepth = 4
+
+// CBOR is the codec of application/cbor, RFC 8949. Struct fields are named
+// by their json tags.
+type CBOR struct{}
+
+func (CBOR) MediaType() string {
+	return "application/cbor"
+}
+
+func (CBOR) Encode(w io.Writer, v any) error {
+	return cbor.NewEncoder(w).Encode(v)
+}
+
+// Decode limits nesting to at least minCBORDepth levels. Tags count as a
+// level, like arrays and maps.
+func (CBOR) Decode(data []byte, v any, maxDepth int) error {
+	dm, err := cbor.DecOptions{
+		MaxNestedLevels:   max(maxDepth, minCBORDepth),
+		ExtraReturnErrors: cbor.ExtraDecErrorUnknownField,
+	}.DecMode()
+	if err != nil {
+		return err
+	}
+	// trailing data is an error of Unmarshal
+	return dm.Unmarshal(data, v)
+}
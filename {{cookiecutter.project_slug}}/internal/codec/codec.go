@@ -0,0 +1,207 @@
+package codec
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ErrUnsupported is returned by codecs that cannot represent a value, such
+// as CSV for anything but a list, or cannot decode at all.
+var ErrUnsupported = errors.New("value not supported by codec")
+
+// Codec reads and writes one media type.
+type Codec interface {
+	// MediaType is matched against Accept and Content-Type, it is also the
+	// Content-Type of encoded responses.
+	MediaType() string
+	// Encode writes v, or returns ErrUnsupported before writing anything.
+	Encode(w io.Writer, v any) error
+	// Decode reads the single value in data into v. Fields v does not have
+	// and arrays or maps nested deeper than maxDepth are errors.
+	Decode(data []byte, v any, maxDepth int) error
+}
+
+// Table is implemented by list responses. Rows returns the slice of structs
+// that row oriented codecs such as CSV write, one row per element.
+type Table interface {
+	Rows() any
+}
+
+// RowEncoder writes the rows of a table one at a time, so tables too large
+// to hold in memory can be streamed. Rows may be buffered until Flush.
+type RowEncoder interface {
+	Encode(row any) error
+	Flush() error
+}
+
+// RowDecoder reads the rows of a table one at a time, so uploads can be
+// processed as they arrive.
+type RowDecoder interface {
+	// Decode reads the next row into v and returns io.EOF after the last one.
+	// A malformed row is a *RowError, the next call goes on with the row
+	// after it. Any other error ends the table.
+	Decode(v any) error
+}
+
+// RowError is the error of a row that could not be decoded. Row counts the
+// rows of the table from 1, a CSV header is not a row.
+type RowError struct {
+	Row int
+	Err error
+}
+
+func (e *RowError) Error() string {
+	return fmt.Sprintf("row %d: %v", e.Row, e.Err)
+}
+
+func (e *RowError) Unwrap() error {
+	return e.Err
+}
+
+// Streamer is implemented by codecs that can read and write a table row by
+// row.
+type Streamer interface {
+	Codec
+	// NewRowEncoder starts a table on w whose rows are of the type of row,
+	// or returns ErrUnsupported when the codec cannot write such rows.
+	NewRowEncoder(w io.Writer, row any) (RowEncoder, error)
+	// NewRowDecoder reads a table from r. Rows are held to the same rules
+	// as values given to Decode.
+	NewRowDecoder(r io.Reader, maxDepth int) RowDecoder
+}
+
+// encodeTable writes v, which must be a Table, with the row encoders of s.
+func encodeTable(s Streamer, w io.Writer, v any) error {
+	table, ok := v.(Table)
+	if !ok {
+		return ErrUnsupported
+	}
+	rows := reflect.ValueOf(table.Rows())
+	if rows.Kind() != reflect.Slice {
+		return ErrUnsupported
+	}
+	enc, err := s.NewRowEncoder(w, reflect.Zero(rows.Type().Elem()).Interface())
+	if err != nil {
+		return err
+	}
+	for i := range rows.Len() {
+		if err := enc.Encode(rows.Index(i).Interface()); err != nil {
+			return err
+		}
+	}
+	return enc.Flush()
+}
+
+// Registry holds the codecs a server speaks. The first codec is used when
+// a request does not say which it wants.
+type Registry struct {
+	codecs []Codec
+}
+
+func NewRegistry(codecs ...Codec) *Registry {
+	return &Registry{codecs: codecs}
+}
+
+// MediaTypes lists the media types of the codecs in the registry.
+func (reg *Registry) MediaTypes() []string {
+	types := make([]string, len(reg.codecs))
+	for i, c := range reg.codecs {
+		types[i] = c.MediaType()
+	}
+	return types
+}
+
+// ForContentType returns the codec of a request body, none when the body has
+// no Content-Type.
+func (reg *Registry) ForContentType(contentType string) (Codec, bool) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, false
+	}
+	for _, c := range reg.codecs {
+		if c.MediaType() == mediaType {
+			return c, true
+		}
+	}
+	return nil, false
+}
+
+// ForAccept returns the codecs an Accept header allows, most preferred
+// first. Without an Accept header every codec is acceptable.
+func (reg *Registry) ForAccept(accept string) []Codec {
+	if accept == "" {
+		return reg.codecs
+	}
+
+	var codecs []Codec
+	for _, r := range parseAccept(accept) {
+		for _, c := range reg.codecs {
+			if r.matches(c.MediaType()) && !slices.Contains(codecs, c) {
+				codecs = append(codecs, c)
+			}
+		}
+	}
+	return codecs
+}
+
+// mediaRange is an entry of an Accept header, such as "text/*;q=0.5".
+type mediaRange struct {
+	mediaType string
+	q         float64
+}
+
+func (m mediaRange) matches(mediaType string) bool {
+	if m.mediaType == "*/*" || m.mediaType == mediaType {
+		return true
+	}
+	prefix, ok := strings.CutSuffix(m.mediaType, "/*")
+	return ok && strings.HasPrefix(mediaType, prefix+"/")
+}
+
+// specificity orders ranges of the same quality, "text/csv" before
+// "text/*" before "*/*".
+func (m mediaRange) specificity() int {
+	switch {
+	case m.mediaType == "*/*":
+		return 0
+	case strings.HasSuffix(m.mediaType, "/*"):
+		return 1
+	default:
+		return 2
+	}
+}
+
+// parseAccept returns the ranges of an Accept header by preference. Ranges
+// with q=0 are left out, ones that do not parse are skipped.
+func parseAccept(accept string) []mediaRange {
+	var ranges []mediaRange
+	for _, entry := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(entry))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if value, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(value, 64); err != nil {
+				continue
+			}
+		}
+		if q > 0 {
+			ranges = append(ranges, mediaRange{mediaType: mediaType, q: q})
+		}
+	}
+	slices.SortStableFunc(ranges, func(a, b mediaRange) int {
+		if c := cmp.Compare(b.q, a.q); c != 0 {
+			return c
+		}
+		return cmp.Compare(b.specificity(), a.specificity())
+	})
+	return ranges
+}
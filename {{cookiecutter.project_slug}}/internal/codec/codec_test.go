@@ -0,0 +1,175 @@
+package codec
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func mediaTypes(codecs []Codec) []string {
+	types := make([]string, len(codecs))
+	for i, c := range codecs {
+		types[i] = c.MediaType()
+	}
+	return types
+}
+
+func TestRegistry_ForAccept(t *testing.T) {
+	registry := NewRegistry(JSON{}, CBOR{}, MessagePack{}, CSV{})
+
+	tests := []struct {
+		name   string
+		accept string
+		want   []string
+	}{
+		{
+			name:   "No Accept",
+			accept: "",
+			want:   []string{"application/json", "application/cbor", "application/msgpack", "text/csv"},
+		},
+		{
+			name:   "Exact",
+			accept: "application/cbor",
+			want:   []string{"application/cbor"},
+		},
+		{
+			name:   "Quality",
+			accept: "application/json;q=0.5, text/csv",
+			want:   []string{"text/csv", "application/json"},
+		},
+		{
+			name:   "Specific Before Wildcard",
+			accept: "*/*, application/msgpack",
+			want:   []string{"application/msgpack", "application/json", "application/cbor", "text/csv"},
+		},
+		{
+			name:   "Type Wildcard",
+			accept: "text/*",
+			want:   []string{"text/csv"},
+		},
+		{
+			name:   "Excluded",
+			accept: "application/*, application/json;q=0",
+			want:   []string{"application/json", "application/cbor", "application/msgpack"},
+		},
+		{
+			name:   "Nothing Acceptable",
+			accept: "application/xml",
+			want:   nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := mediaTypes(registry.ForAccept(tt.accept))
+			if len(got) == 0 && len(tt.want) == 0 {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ForAccept(%q) = %v, want %v", tt.accept, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRegistry_ForContentType(t *testing.T) {
+	registry := NewRegistry(JSON{}, CBOR{}, MessagePack{}, CSV{})
+
+	tests := []struct {
+		contentType string
+		want        string
+		wantOK      bool
+	}{
+		{contentType: "", wantOK: false},
+		{contentType: "application/json; charset=utf-8", want: "application/json", wantOK: true},
+		{contentType: "application/msgpack", want: "application/msgpack", wantOK: true},
+		{contentType: "application/x-www-form-urlencoded", wantOK: false},
+		{contentType: "not a media type", wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.contentType, func(t *testing.T) {
+			got, ok := registry.ForContentType(tt.contentType)
+			if ok != tt.wantOK {
+				t.Fatalf("ForContentType(%q) ok = %v, want %v", tt.contentType, ok, tt.wantOK)
+			}
+			if ok && got.MediaType() != tt.want {
+				t.Errorf("ForContentType(%q) = %s, want %s", tt.contentType, got.MediaType(), tt.want)
+			}
+		})
+	}
+}
+
+type item struct {
+	Name  string   `json:"name"`
+	Count int      `json:"count,omitempty"`
+	Tags  []string `json:"tags"`
+}
+
+func TestCodecs_RoundTrip(t *testing.T) {
+	for _, c := range []Codec{JSON{}, CBOR{}, MessagePack{}} {
+		t.Run(c.MediaType(), func(t *testing.T) {
+			want := item{Name: "a", Count: 2, Tags: []string{"x", "y"}}
+			var buf bytes.Buffer
+			if err := c.Encode(&buf, want); err != nil {
+				t.Fatalf("Encode() error = %v", err)
+			}
+			var got item
+			if err := c.Decode(buf.Bytes(), &got, 32); err != nil {
+				t.Fatalf("Decode() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("Decode() = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestCodecs_DecodeRejects(t *testing.T) {
+	type unknown struct {
+		Name  string `json:"name"`
+		Owner string `json:"owner"`
+	}
+	// an object holding five levels of arrays is six levels deep
+	type nested struct {
+		Name string           `json:"name"`
+		Tags [][][][][]string `json:"tags"`
+	}
+
+	for _, c := range []Codec{JSON{}, CBOR{}, MessagePack{}} {
+		t.Run(c.MediaType(), func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := c.Encode(&buf, unknown{Name: "a", Owner: "b"}); err != nil {
+				t.Fatalf("Encode() error = %v", err)
+			}
+			var got item
+			if err := c.Decode(buf.Bytes(), &got, 32); err == nil {
+				t.Error("expected an error for an unknown field")
+			}
+
+			buf.Reset()
+			if err := c.Encode(&buf, item{Name: "a"}); err != nil {
+				t.Fatalf("Encode() error = %v", err)
+			}
+			if err := c.Encode(&buf, item{Name: "b"}); err != nil {
+				t.Fatalf("Encode() error = %v", err)
+			}
+			if err := c.Decode(buf.Bytes(), &got, 32); err == nil {
+				t.Error("expected an error for a second value")
+			}
+
+			buf.Reset()
+			tags := [][][][][]string{[][][][]string{[][][]string{[][]string{[]string{"deep"}}}}}
+			if err := c.Encode(&buf, nested{Name: "a", Tags: tags}); err != nil {
+				t.Fatalf("Encode() error = %v", err)
+			}
+			var deep nested
+			if err := c.Decode(buf.Bytes(), &deep, 5); err == nil {
+				t.Error("expected an error for nesting deeper than the limit")
+			}
+			if err := c.Decode(buf.Bytes(), &deep, 6); err != nil {
+				t.Errorf("Decode() within the limit error = %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,275 @@
+package codec
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CSV is the codec of text/csv. It only encodes Tables: the header names
+// the fields of the row struct by their json tags, in the order the struct
+// declares them, so columns stay put as long as the struct does. Tables are
+// read back row by row with NewRowDecoder.
+type CSV struct{}
+
+func (CSV) MediaType() string {
+	return "text/csv"
+}
+
+func (c CSV) Encode(w io.Writer, v any) error {
+	return encodeTable(c, w, v)
+}
+
+// NewRowEncoder writes the header of rows of the struct type of row, which
+// may also be a pointer to the struct.
+func (CSV) NewRowEncoder(w io.Writer, row any) (RowEncoder, error) {
+	rowType := reflect.TypeOf(row)
+	if rowType != nil && rowType.Kind() == reflect.Pointer {
+		rowType = rowType.Elem()
+	}
+	if rowType == nil || rowType.Kind() != reflect.Struct {
+		return nil, ErrUnsupported
+	}
+
+	columns, header := csvColumns(rowType)
+	enc := &csvRowEncoder{
+		w:       csv.NewWriter(w),
+		columns: columns,
+		record:  make([]string, len(columns)),
+	}
+	if err := enc.w.Write(header); err != nil {
+		return nil, err
+	}
+	return enc, nil
+}
+
+type csvRowEncoder struct {
+	w       *csv.Writer
+	columns []int
+	record  []string
+}
+
+// Encode writes row, nil rows are written as empty records.
+func (e *csvRowEncoder) Encode(row any) error {
+	v := reflect.Indirect(reflect.ValueOf(row))
+	for i, field := range e.columns {
+		if v.IsValid() {
+			e.record[i] = csvValue(v.Field(field))
+		} else {
+			e.record[i] = ""
+		}
+	}
+	return e.w.Write(e.record)
+}
+
+func (e *csvRowEncoder) Flush() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+func (CSV) Decode(data []byte, v any, maxDepth int) error {
+	return ErrUnsupported
+}
+
+// NewRowDecoder reads a header and then one row per record. Columns are
+// matched to the fields of the row struct by the names NewRowEncoder writes,
+// columns the struct does not have are an error, missing ones leave their
+// field untouched. maxDepth does not apply, CSV does not nest.
+func (CSV) NewRowDecoder(r io.Reader, maxDepth int) RowDecoder {
+	cr := csv.NewReader(r)
+	cr.ReuseRecord = true
+	return &csvRowDecoder{r: cr}
+}
+
+type csvRowDecoder struct {
+	r *csv.Reader
+	// fields are the indexes of the struct fields of the columns, read
+	// from the header on the first call to Decode
+	fields  []int
+	rowType reflect.Type
+	header  []string
+	row     int
+}
+
+func (d *csvRowDecoder) Decode(v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.Elem().Kind() != reflect.Struct {
+		return ErrUnsupported
+	}
+	row := rv.Elem()
+	if d.rowType == nil {
+		if err := d.readHeader(row.Type()); err != nil {
+			return err
+		}
+	} else if row.Type() != d.rowType {
+		return ErrUnsupported
+	}
+
+	record, err := d.r.Read()
+	if errors.Is(err, io.EOF) {
+		return io.EOF
+	}
+	d.row++
+	if err != nil {
+		// the reader goes on with the next record after a parse error
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			return &RowError{Row: d.row, Err: parseErr.Err}
+		}
+		return err
+	}
+	for i, field := range d.fields {
+		if err := csvSet(row.Field(field), record[i]); err != nil {
+			return &RowError{Row: d.row, Err: fmt.Errorf("%s: %w", d.header[i], err)}
+		}
+	}
+	return nil
+}
+
+func (d *csvRowDecoder) readHeader(rowType reflect.Type) error {
+	header, err := d.r.Read()
+	if err != nil {
+		return err
+	}
+	columns, names := csvColumns(rowType)
+	d.header = make([]string, len(header))
+	d.fields = make([]int, len(header))
+	for i, name := range header {
+		j := slices.Index(names, name)
+		if j < 0 {
+			return fmt.Errorf("unknown column %q", name)
+		}
+		d.header[i] = name
+		d.fields[i] = columns[j]
+	}
+	d.rowType = rowType
+	return nil
+}
+
+// csvColumns returns the indexes and names of the exported fields of t that
+// are not tagged json:"-".
+func csvColumns(t reflect.Type) ([]int, []string) {
+	var columns []int
+	var names []string
+	for i := range t.NumField() {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		columns = append(columns, i)
+		names = append(names, name)
+	}
+	return columns, names
+}
+
+// csvValue formats a field. Strings that a spreadsheet would run as a
+// formula are prefixed with a quote.
+func csvValue(v reflect.Value) string {
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return ""
+		}
+		v = v.Elem()
+	}
+	if t, ok := v.Interface().(time.Time); ok {
+		return t.Format(time.RFC3339)
+	}
+	switch v.Kind() {
+	case reflect.String:
+		s := v.String()
+		if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+			return "'" + s
+		}
+		return s
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10)
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, 64)
+	default:
+		return fmt.Sprint(v.Interface())
+	}
+}
+
+// csvSet parses s into v, undoing what csvValue does. Empty strings leave
+// numbers and pointers at their zero value.
+func csvSet(v reflect.Value, s string) error {
+	if v.Kind() == reflect.Pointer {
+		if s == "" {
+			return nil
+		}
+		elem := reflect.New(v.Type().Elem())
+		if err := csvSet(elem.Elem(), s); err != nil {
+			return err
+		}
+		v.Set(elem)
+		return nil
+	}
+	if v.Type() == reflect.TypeFor[time.Time]() {
+		if s == "" {
+			return nil
+		}
+		t, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(t))
+		return nil
+	}
+	if v.Kind() == reflect.String {
+		if len(s) > 1 && s[0] == '\'' && strings.ContainsRune("=+-@\t\r", rune(s[1])) {
+			s = s[1:]
+		}
+		v.SetString(s)
+		return nil
+	}
+	if s == "" {
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	default:
+		return ErrUnsupported
+	}
+	return nil
+}
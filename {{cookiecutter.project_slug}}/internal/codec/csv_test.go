@@ -0,0 +1,80 @@
+package codec
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+)
+
+type row struct {
+	ID        string     `json:"id"`
+	Name      string     `json:"name"`
+	Count     int        `json:"count,omitempty"`
+	Secret    string     `json:"-"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+	hidden    string
+}
+
+type rows []row
+
+func (r rows) Rows() any {
+	return []row(r)
+}
+
+func TestCSV_Encode(t *testing.T) {
+	deleted := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		value   any
+		want    string
+		wantErr error
+	}{
+		{
+			name: "Rows In Struct Order",
+			value: rows{
+				{ID: "1", Name: "a", Count: 2, Secret: "s", hidden: "h"},
+				{ID: "2", Name: "b, c", DeletedAt: &deleted},
+			},
+			want: "id,name,count,deleted_at\n1,a,2,\n2,\"b, c\",0,2025-01-02T03:04:05Z\n",
+		},
+		{
+			name:  "No Rows",
+			value: rows{},
+			want:  "id,name,count,deleted_at\n",
+		},
+		{
+			name: "Formula",
+			value: rows{
+				{ID: "1", Name: "=SUM(A1:A2)"},
+			},
+			want: "id,name,count,deleted_at\n1,'=SUM(A1:A2),0,\n",
+		},
+		{
+			name:    "Not A Table",
+			value:   row{ID: "1"},
+			wantErr: ErrUnsupported,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			err := CSV{}.Encode(&buf, tt.value)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Encode() error = %v, want %v", err, tt.wantErr)
+			}
+			if got := buf.String(); got != tt.want {
+				t.Errorf("Encode() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCSV_Decode(t *testing.T) {
+	var v rows
+	if err := (CSV{}).Decode([]byte("id\n1\n"), &v, 32); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("Decode() error = %v, want %v", err, ErrUnsupported)
+	}
+}
@@ -0,0 +1,85 @@
+package codec
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/vmihailenco/msgpack/v5"
+	"github.com/vmihailenco/msgpack/v5/msgpcode"
+)
+
+// MessagePack is the codec of application/msgpack. Struct fields are named
+// by their json tags.
+type MessagePack struct{}
+
+func (MessagePack) MediaType() string {
+	return "application/msgpack"
+}
+
+func (MessagePack) Encode(w io.Writer, v any) error {
+	enc := msgpack.NewEncoder(w)
+	enc.SetCustomStructTag("json")
+	return enc.Encode(v)
+}
+
+func (MessagePack) Decode(data []byte, v any, maxDepth int) error {
+	if err := checkMessagePackDepth(data, maxDepth); err != nil {
+		return err
+	}
+	r := bytes.NewReader(data)
+	dec := msgpack.NewDecoder(r)
+	dec.SetCustomStructTag("json")
+	dec.DisallowUnknownFields(true)
+	if err := dec.Decode(v); err != nil {
+		return err
+	}
+	if r.Len() > 0 {
+		return errors.New("unexpected data after the MessagePack value")
+	}
+	return nil
+}
+
+// checkMessagePackDepth rejects arrays and maps that nest deeper than
+// maxDepth. The decoder has no limit of its own, so data is walked without
+// recursion first.
+func checkMessagePackDepth(data []byte, maxDepth int) error {
+	dec := msgpack.NewDecoder(bytes.NewReader(data))
+	// values left to read on each level, the outermost holds the one value
+	remaining := []int{1}
+	for len(remaining) > 0 {
+		top := len(remaining) - 1
+		if remaining[top] == 0 {
+			remaining = remaining[:top]
+			continue
+		}
+		remaining[top]--
+
+		c, err := dec.PeekCode()
+		if err != nil {
+			return err
+		}
+		var n int
+		switch {
+		case msgpcode.IsFixedArray(c) || c == msgpcode.Array16 || c == msgpcode.Array32:
+			n, err = dec.DecodeArrayLen()
+		case msgpcode.IsFixedMap(c) || c == msgpcode.Map16 || c == msgpcode.Map32:
+			n, err = dec.DecodeMapLen()
+			n *= 2 // keys and values
+		default:
+			if err := dec.Skip(); err != nil {
+				return err
+			}
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		remaining = append(remaining, n)
+		if len(remaining)-1 > maxDepth {
+			return fmt.Errorf("nested deeper than %d levels", maxDepth)
+		}
+	}
+	return nil
+}
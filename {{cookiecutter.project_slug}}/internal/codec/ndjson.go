@@ -0,0 +1,79 @@
+package codec
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+)
+
+// NDJSON is the codec of application/x-ndjson, newline delimited JSON. Like
+// CSV it only encodes Tables and only decodes row by row, each row is a JSON
+// object on its own line, which lets either side process a table before it
+// is complete.
+type NDJSON struct{}
+
+func (NDJSON) MediaType() string {
+	return "application/x-ndjson"
+}
+
+func (n NDJSON) Encode(w io.Writer, v any) error {
+	return encodeTable(n, w, v)
+}
+
+func (NDJSON) Decode(data []byte, v any, maxDepth int) error {
+	return ErrUnsupported
+}
+
+func (NDJSON) NewRowEncoder(w io.Writer, row any) (RowEncoder, error) {
+	buf := bufio.NewWriter(w)
+	return &ndjsonRowEncoder{buf: buf, enc: json.NewEncoder(buf)}, nil
+}
+
+type ndjsonRowEncoder struct {
+	buf *bufio.Writer
+	enc *json.Encoder
+}
+
+// Encode writes row followed by a newline.
+func (e *ndjsonRowEncoder) Encode(row any) error {
+	return e.enc.Encode(row)
+}
+
+func (e *ndjsonRowEncoder) Flush() error {
+	return e.buf.Flush()
+}
+
+// NewRowDecoder reads one row per line, blank lines are skipped. Each line is
+// decoded like a JSON body.
+func (NDJSON) NewRowDecoder(r io.Reader, maxDepth int) RowDecoder {
+	return &ndjsonRowDecoder{r: bufio.NewReader(r), maxDepth: maxDepth}
+}
+
+type ndjsonRowDecoder struct {
+	r        *bufio.Reader
+	maxDepth int
+	row      int
+}
+
+func (d *ndjsonRowDecoder) Decode(v any) error {
+	for {
+		line, err := d.r.ReadBytes('\n')
+		if len(bytes.TrimSpace(line)) == 0 {
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		// the last line may end without a newline
+		if err != nil && !errors.Is(err, io.EOF) {
+			return err
+		}
+		d.row++
+		if err := (JSON{}).Decode(line, v, d.maxDepth); err != nil {
+			return &RowError{Row: d.row, Err: err}
+		}
+		return nil
+	}
+}
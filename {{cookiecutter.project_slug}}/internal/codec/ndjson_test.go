@@ -0,0 +1,180 @@
+package codec
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNDJSON_Encode(t *testing.T) {
+	var buf bytes.Buffer
+	table := rows{
+		{ID: "1", Name: "a"},
+		{ID: "2", Name: "b"},
+	}
+	if err := (NDJSON{}).Encode(&buf, table); err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+	want := "{\"id\":\"1\",\"name\":\"a\"}\n{\"id\":\"2\",\"name\":\"b\"}\n"
+	if got := buf.String(); got != want {
+		t.Errorf("Encode() = %q, want %q", got, want)
+	}
+
+	if err := (NDJSON{}).Encode(&buf, row{ID: "1"}); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("Encode() of a row error = %v, want %v", err, ErrUnsupported)
+	}
+}
+
+func TestStreamer_RowEncoder(t *testing.T) {
+	table := rows{
+		{ID: "1", Name: "a"},
+		{ID: "2", Name: "b"},
+	}
+
+	tests := []struct {
+		streamer Streamer
+		want     string
+	}{
+		{
+			streamer: CSV{},
+			want:     "id,name,count,deleted_at\n1,a,0,\n2,b,0,\n",
+		},
+		{
+			streamer: NDJSON{},
+			want:     "{\"id\":\"1\",\"name\":\"a\"}\n{\"id\":\"2\",\"name\":\"b\"}\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.streamer.MediaType(), func(t *testing.T) {
+			var buf bytes.Buffer
+			enc, err := tt.streamer.NewRowEncoder(&buf, row{})
+			if err != nil {
+				t.Fatalf("NewRowEncoder() error = %v", err)
+			}
+			// rows are written in batches, each flushed on its own
+			for _, r := range table {
+				if err := enc.Encode(&r); err != nil {
+					t.Fatalf("Encode() error = %v", err)
+				}
+				if err := enc.Flush(); err != nil {
+					t.Fatalf("Flush() error = %v", err)
+				}
+			}
+			if got := buf.String(); got != tt.want {
+				t.Errorf("rows = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := (CSV{}).NewRowEncoder(&bytes.Buffer{}, "not a struct"); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("NewRowEncoder() error = %v, want %v", err, ErrUnsupported)
+	}
+}
+
+func TestStreamer_RowDecoder(t *testing.T) {
+	tests := []struct {
+		streamer Streamer
+		input    string
+	}{
+		{
+			streamer: CSV{},
+			input:    "name,id\na,1\nb,2,extra\n'=c,3\n",
+		},
+		{
+			streamer: NDJSON{},
+			input:    "{\"name\":\"a\",\"id\":\"1\"}\n\n{\"name\":\"b\",\"owner\":\"x\"}\n{\"name\":\"=c\",\"id\":\"3\"}",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.streamer.MediaType(), func(t *testing.T) {
+			dec := tt.streamer.NewRowDecoder(strings.NewReader(tt.input), 32)
+
+			var got []row
+			var rowErrs []int
+			for {
+				var r row
+				err := dec.Decode(&r)
+				if errors.Is(err, io.EOF) {
+					break
+				}
+				var rowErr *RowError
+				if errors.As(err, &rowErr) {
+					rowErrs = append(rowErrs, rowErr.Row)
+					continue
+				}
+				if err != nil {
+					t.Fatalf("Decode() error = %v", err)
+				}
+				got = append(got, r)
+			}
+
+			want := []row{
+				{ID: "1", Name: "a"},
+				{ID: "3", Name: "=c"},
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("rows = %+v, want %+v", got, want)
+			}
+			if !reflect.DeepEqual(rowErrs, []int{2}) {
+				t.Errorf("rows with errors = %v, want [2]", rowErrs)
+			}
+		})
+	}
+}
+
+func TestCSV_RowDecoder(t *testing.T) {
+	deleted := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	t.Run("Round Trip", func(t *testing.T) {
+		want := rows{
+			{ID: "1", Name: "+a", Count: 2},
+			{ID: "2", Name: "b, c", DeletedAt: &deleted},
+		}
+		var buf bytes.Buffer
+		if err := (CSV{}).Encode(&buf, want); err != nil {
+			t.Fatalf("Encode() error = %v", err)
+		}
+
+		dec := CSV{}.NewRowDecoder(&buf, 32)
+		var got rows
+		for {
+			var r row
+			err := dec.Decode(&r)
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				t.Fatalf("Decode() error = %v", err)
+			}
+			got = append(got, r)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("rows = %+v, want %+v", got, want)
+		}
+	})
+
+	t.Run("Invalid Value", func(t *testing.T) {
+		dec := CSV{}.NewRowDecoder(strings.NewReader("id,count\n1,many\n"), 32)
+		var r row
+		var rowErr *RowError
+		if err := dec.Decode(&r); !errors.As(err, &rowErr) || rowErr.Row != 1 {
+			t.Errorf("Decode() error = %v, want a RowError for row 1", err)
+		}
+	})
+
+	t.Run("Unknown Column", func(t *testing.T) {
+		dec := CSV{}.NewRowDecoder(strings.NewReader("id,owner\n1,x\n"), 32)
+		var r row
+		var rowErr *RowError
+		err := dec.Decode(&r)
+		if err == nil || errors.As(err, &rowErr) {
+			t.Errorf("Decode() error = %v, want an error for the table", err)
+		}
+	})
+}
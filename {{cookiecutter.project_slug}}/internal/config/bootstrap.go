@@ -2,11 +2,15 @@ package config
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"os"
+	"strconv"
+	"time"
 
 	"{{cookiecutter.module_name}}/internal/gcp"
+	"{{cookiecutter.module_name}}/internal/tracing"
 )
 
 // structs to help fetching secrets from gcp
@@ -14,23 +18,127 @@ import (
 type SecretCoordinates struct {
 	ProjectNumber string
 	DBPasswordKey string
+	AdminTokenKey string
+	APIKeyKey     string
 }
 
 type Secrets struct {
-	DBPassword string
+	DBPassword   string
+	AdminToken   string
+	APIKeySecret string
 }
 
+// localAPIKeySecretKey is the secret the local FakeSecretRepo holds the
+// API key hashing secret under.
+const localAPIKeySecretKey = "api-key-secret"
+
 // structs returned by load fn
 type Database struct {
 	DSN string // Data Source Name Native Postgres
 }
 
+// Auth configures bearer token authentication of the API routes.
+type Auth struct {
+	Issuer   string // expected "iss" claim, authentication is disabled when empty
+	Audience string // value the "aud" claim must contain
+	JWKSURL  string // where the issuer publishes its signing keys
+}
+
+// validate requires authentication outside of local development and a
+// complete configuration whenever it is enabled.
+func (a Auth) validate(env string) error {
+	if a.Issuer == "" {
+		if env != "local" {
+			return fmt.Errorf("OIDC_ISSUER is required in %s", env)
+		}
+		return nil
+	}
+	if a.Audience == "" || a.JWKSURL == "" {
+		return errors.New("OIDC_AUDIENCE and OIDC_JWKS_URL are required with OIDC_ISSUER")
+	}
+	return nil
+}
+
+// BodyLimits cap request bodies. Zero fields use the defaults of
+// middleware.DefaultBodyLimits.
+type BodyLimits struct {
+	MaxBytes int64 // size of a request body
+	MaxDepth int   // nesting of arrays and objects in a JSON body
+}
+
+// loadBodyLimits reads MAX_BODY_BYTES and MAX_JSON_DEPTH.
+func loadBodyLimits(getVariable GetVariable) (BodyLimits, error) {
+	maxBytes, err := parseLimit(getVariable, "MAX_BODY_BYTES")
+	if err != nil {
+		return BodyLimits{}, err
+	}
+	maxDepth, err := parseLimit(getVariable, "MAX_JSON_DEPTH")
+	if err != nil {
+		return BodyLimits{}, err
+	}
+	return BodyLimits{MaxBytes: maxBytes, MaxDepth: int(maxDepth)}, nil
+}
+
+// parseLimit reads the positive integer in key, zero when key is not set.
+func parseLimit(getVariable GetVariable, key string) (int64, error) {
+	value := getVariable(key)
+	if value == "" {
+		return 0, nil
+	}
+	n, err := strconv.ParseInt(value, 10, 32)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("%s must be a positive integer, got %q", key, value)
+	}
+	return n, nil
+}
+
+// parseDuration reads the positive duration in key, such as "24h", zero
+// when key is not set.
+func parseDuration(getVariable GetVariable, key string) (time.Duration, error) {
+	value := getVariable(key)
+	if value == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("%s must be a positive duration, got %q", key, value)
+	}
+	return d, nil
+}
+
+// Stores rate limits can be kept in, see AppConfig.RateLimitStore.
+const (
+	RateLimitMemory   = "memory"   // per instance
+	RateLimitPostgres = "postgres" // shared by every instance
+	RateLimitOff      = "off"
+)
+
+func validateRateLimitStore(store string) error {
+	switch store {
+	case "", RateLimitMemory, RateLimitPostgres, RateLimitOff:
+		return nil
+	}
+	return fmt.Errorf("RATE_LIMIT_STORE must be %s, %s or %s, got %q", RateLimitMemory, RateLimitPostgres, RateLimitOff, store)
+}
+
 type AppConfig struct {
 	Env                   string
 	DB                    Database
 	ProjectID             string
 	StorageBucket         string
 	StorageServiceAccount string
+	AdminToken            string // bearer token for admin-only routes, they are disabled when empty
+	APIKeySecret          string // HMAC secret API keys are hashed with, API keys are disabled when empty
+	Subscription          string // Pub/Sub subscription to consume, the consumer is disabled when empty
+	RateLimitStore        string // where rate limits are kept, RateLimitMemory when empty
+	ErrorReportFile       string // file panics are reported to as JSON lines, they are only logged when empty
+	TraceExporter         string // where spans are sent, tracing.ExporterNone when empty
+	BodyLimits            BodyLimits
+	MaxBatchOperations    int           // operations a batch request may hold, handler.DefaultMaxBatchOperations when zero
+	MaxPageSize           int           // {{cookiecutter.entity_name_lower}}s a list page may hold, handler.DefaultMaxPageSize when zero
+	IdempotencyTTL        time.Duration // how long responses are kept for Idempotency-Key retries, idempotency.DefaultTTL when zero
+	TrustedProxies        int           // proxies in front of the server whose X-Forwarded-For entries are trusted, none when zero
+	Auth                  Auth
 }
 
 type GetVariable func(key string) string
@@ -74,9 +182,12 @@ func NewBootStrap(ctx context.Context, log *slog.Logger) (BootStrap, error) {
 }
 
 func NewLocalBootStrap(ctx context.Context, log *slog.Logger) (BootStrap, error) {
+	repo := gcp.NewFakeSecretRepo()
+	repo.Secrets[localAPIKeySecretKey] = "local-api-key-secret"
+
 	return &bootStrap{
 		getVariable: readVariable,
-		repo:        gcp.NewFakeSecretRepo(),
+		repo:        repo,
 		log:         log,
 	}, nil
 }
@@ -95,9 +206,22 @@ func (b *bootStrap) Load(ctx context.Context) (*AppConfig, error) {
 	dbSSLMode := b.getVariable("DB_SSL_MODE")
 	dbUser := b.getVariable("DB_USER")
 
+	var adminToken, apiKeySecret string
 	if env == "local" {
 		dbPassword := b.getVariable("DB_PASSWORD")
 		dsn = fmt.Sprintf(dsnTemplate, dbHost, dbUser, dbPassword, dbName, dbPort, dbSSLMode)
+		adminToken = b.getVariable("ADMIN_TOKEN")
+
+		// the API key secret comes from the fake secret repository
+		coords := SecretCoordinates{
+			ProjectNumber: "local",
+			APIKeyKey:     b.getVariable("API_KEY_SECRET_KEY"),
+		}
+		secrets, err := b.FetchSecrets(ctx, coords)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch secrets: %w", err)
+		}
+		apiKeySecret = secrets.APIKeySecret
 	} else {
 		// get secrts from gcp
 		gcpProjectNumber := b.getVariable("GCP_PROJECT_NUMBER")
@@ -106,6 +230,8 @@ func (b *bootStrap) Load(ctx context.Context) (*AppConfig, error) {
 		coords := SecretCoordinates{
 			ProjectNumber: gcpProjectNumber,
 			DBPasswordKey: dbPasswordKey,
+			AdminTokenKey: b.getVariable("ADMIN_TOKEN_KEY"),
+			APIKeyKey:     b.getVariable("API_KEY_SECRET_KEY"),
 		}
 
 		secrets, err := b.FetchSecrets(ctx, coords)
@@ -114,10 +240,33 @@ func (b *bootStrap) Load(ctx context.Context) (*AppConfig, error) {
 		}
 
 		dsn = fmt.Sprintf(dsnTemplate, dbHost, dbUser, secrets.DBPassword, dbName, dbPort, dbSSLMode)
+		adminToken = secrets.AdminToken
+		apiKeySecret = secrets.APIKeySecret
 	}
 
 	storageBucket := b.getVariable("STORAGE_BUCKET")
 
+	bodyLimits, err := loadBodyLimits(b.getVariable)
+	if err != nil {
+		return nil, err
+	}
+	maxBatchOperations, err := parseLimit(b.getVariable, "MAX_BATCH_OPERATIONS")
+	if err != nil {
+		return nil, err
+	}
+	maxPageSize, err := parseLimit(b.getVariable, "MAX_PAGE_SIZE")
+	if err != nil {
+		return nil, err
+	}
+	idempotencyTTL, err := parseDuration(b.getVariable, "IDEMPOTENCY_TTL")
+	if err != nil {
+		return nil, err
+	}
+	trustedProxies, err := parseLimit(b.getVariable, "TRUSTED_PROXY_COUNT")
+	if err != nil {
+		return nil, err
+	}
+
 	// 3. Populate AppConfig
 	appConfig := &AppConfig{
 		Env: env,
@@ -127,6 +276,31 @@ func (b *bootStrap) Load(ctx context.Context) (*AppConfig, error) {
 		ProjectID:             b.getVariable("GCP_PROJECT_ID"),
 		StorageBucket:         storageBucket,
 		StorageServiceAccount: b.getVariable("STORAGE_SERVICE_ACCOUNT"),
+		AdminToken:            adminToken,
+		APIKeySecret:          apiKeySecret,
+		Subscription:          b.getVariable("PUBSUB_SUBSCRIPTION"),
+		RateLimitStore:        b.getVariable("RATE_LIMIT_STORE"),
+		ErrorReportFile:       b.getVariable("ERROR_REPORT_FILE"),
+		TraceExporter:         b.getVariable("TRACE_EXPORTER"),
+		BodyLimits:            bodyLimits,
+		MaxBatchOperations:    int(maxBatchOperations),
+		MaxPageSize:           int(maxPageSize),
+		IdempotencyTTL:        idempotencyTTL,
+		TrustedProxies:        int(trustedProxies),
+		Auth: Auth{
+			Issuer:   b.getVariable("OIDC_ISSUER"),
+			Audience: b.getVariable("OIDC_AUDIENCE"),
+			JWKSURL:  b.getVariable("OIDC_JWKS_URL"),
+		},
+	}
+	if err := appConfig.Auth.validate(env); err != nil {
+		return nil, err
+	}
+	if err := validateRateLimitStore(appConfig.RateLimitStore); err != nil {
+		return nil, err
+	}
+	if err := tracing.ValidateExporter(appConfig.TraceExporter); err != nil {
+		return nil, err
 	}
 
 	return appConfig, nil
@@ -144,5 +318,21 @@ func (b *bootStrap) FetchSecrets(ctx context.Context, coords SecretCoordinates)
 		secrets.DBPassword = val
 	}
 
+	if coords.AdminTokenKey != "" && coords.ProjectNumber != "" {
+		val, err := b.repo.GetSecret(ctx, coords.ProjectNumber, coords.AdminTokenKey, "latest")
+		if err != nil {
+			return Secrets{}, fmt.Errorf("failed to fetch secret 'adminToken' (project: %s, secret: %s, version: latest): %w", coords.ProjectNumber, coords.AdminTokenKey, err)
+		}
+		secrets.AdminToken = val
+	}
+
+	if coords.APIKeyKey != "" && coords.ProjectNumber != "" {
+		val, err := b.repo.GetSecret(ctx, coords.ProjectNumber, coords.APIKeyKey, "latest")
+		if err != nil {
+			return Secrets{}, fmt.Errorf("failed to fetch secret 'apiKeySecret' (project: %s, secret: %s, version: latest): %w", coords.ProjectNumber, coords.APIKeyKey, err)
+		}
+		secrets.APIKeySecret = val
+	}
+
 	return secrets, nil
 }
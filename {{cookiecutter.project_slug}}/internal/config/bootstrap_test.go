@@ -8,6 +8,7 @@ import (
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 )
 
 // MockSecretRepository mocks gcp.SecretRepository
@@ -42,17 +43,19 @@ func TestBootStrap_Load(t *testing.T) {
 		{
 			name: "local environment",
 			vars: map[string]string{
-				"ENV":             "local",
-				"DB_USER":         "user",
-				"DB_PASSWORD":     "password",
-				"DB_HOST":         "localhost",
-				"DB_NAME":         "shop-api",
-				"DB_PORT":         "5432",
-				"DB_SSL_MODE":     "disable",
-				"GCP_PROJECT_ID":  "project-id",
-				"ORIGINS_ALLOWED": "https://localhost:1234",
-				"METHODS_ALLOWED": "GET,HEAD,POST,PUT,OPTIONS",
-				"HEADERS_ALLOWED": "X-Requested-With",
+				"ENV":               "local",
+				"DB_USER":           "user",
+				"DB_PASSWORD":       "password",
+				"DB_HOST":           "localhost",
+				"DB_NAME":           "shop-api",
+				"DB_PORT":           "5432",
+				"DB_SSL_MODE":       "disable",
+				"GCP_PROJECT_ID":    "project-id",
+				"ADMIN_TOKEN":       "local-admin-token",
+				"ERROR_REPORT_FILE": "errors.jsonl",
+				"ORIGINS_ALLOWED":   "https://localhost:1234",
+				"METHODS_ALLOWED":   "GET,HEAD,POST,PUT,OPTIONS",
+				"HEADERS_ALLOWED":   "X-Requested-With",
 			},
 			mockRepo: &MockSecretRepository{},
 			wantConfig: &AppConfig{
@@ -60,7 +63,38 @@ func TestBootStrap_Load(t *testing.T) {
 				DB: Database{
 					DSN: "host=localhost user=user password=password dbname=shop-api port=5432 sslmode=disable",
 				},
-				ProjectID: "project-id",
+				ProjectID:       "project-id",
+				AdminToken:      "local-admin-token",
+				ErrorReportFile: "errors.jsonl",
+			},
+			wantErr: false,
+		},
+		{
+			name: "local environment with api keys",
+			vars: map[string]string{
+				"ENV":                "local",
+				"DB_USER":            "user",
+				"DB_PASSWORD":        "password",
+				"DB_HOST":            "localhost",
+				"DB_NAME":            "shop-api",
+				"DB_PORT":            "5432",
+				"DB_SSL_MODE":        "disable",
+				"API_KEY_SECRET_KEY": "api-key-secret",
+			},
+			mockRepo: &MockSecretRepository{
+				GetSecretFunc: func(ctx context.Context, projectNumber, secretID, version string) (string, error) {
+					if secretID == "api-key-secret" {
+						return "local-api-key-secret", nil
+					}
+					return "", errors.New("secret not found")
+				},
+			},
+			wantConfig: &AppConfig{
+				Env: "local",
+				DB: Database{
+					DSN: "host=localhost user=user password=password dbname=shop-api port=5432 sslmode=disable",
+				},
+				APIKeySecret: "local-api-key-secret",
 			},
 			wantErr: false,
 		},
@@ -71,11 +105,16 @@ func TestBootStrap_Load(t *testing.T) {
 				"GCP_PROJECT_NUMBER": "1234567890",
 				"DB_USER":            "api",
 				"DB_PASSWORD_KEY":    "db-pass-secret",
+				"ADMIN_TOKEN_KEY":    "admin-token-secret",
+				"API_KEY_SECRET_KEY": "api-key-secret",
 				"DB_HOST":            "prod-db",
 				"DB_NAME":            "shop-api",
 				"DB_PORT":            "5432",
 				"DB_SSL_MODE":        "disable",
 				"GCP_PROJECT_ID":     "project-id",
+				"OIDC_ISSUER":        "https://issuer.example.com",
+				"OIDC_AUDIENCE":      "shop-api",
+				"OIDC_JWKS_URL":      "https://issuer.example.com/jwks.json",
 				"ORIGINS_ALLOWED":    "https://localhost:1234",
 				"METHODS_ALLOWED":    "GET,HEAD,POST,PUT,OPTIONS",
 				"HEADERS_ALLOWED":    "X-Requested-With",
@@ -86,6 +125,12 @@ func TestBootStrap_Load(t *testing.T) {
 						if secretID == "db-pass-secret" {
 							return "prod-pass", nil
 						}
+						if secretID == "admin-token-secret" {
+							return "prod-admin-token", nil
+						}
+						if secretID == "api-key-secret" {
+							return "prod-api-key-secret", nil
+						}
 					}
 					return "", errors.New("secret not found")
 				},
@@ -95,10 +140,197 @@ func TestBootStrap_Load(t *testing.T) {
 				DB: Database{
 					DSN: "host=prod-db user=api password=prod-pass dbname=shop-api port=5432 sslmode=disable",
 				},
-				ProjectID: "project-id",
+				ProjectID:    "project-id",
+				AdminToken:   "prod-admin-token",
+				APIKeySecret: "prod-api-key-secret",
+				Auth: Auth{
+					Issuer:   "https://issuer.example.com",
+					Audience: "shop-api",
+					JWKSURL:  "https://issuer.example.com/jwks.json",
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "shared rate limits",
+			vars: map[string]string{
+				"ENV":              "local",
+				"RATE_LIMIT_STORE": "postgres",
+			},
+			mockRepo: &MockSecretRepository{},
+			wantConfig: &AppConfig{
+				Env: "local",
+				DB: Database{
+					DSN: "host= user= password= dbname= port= sslmode=",
+				},
+				RateLimitStore: RateLimitPostgres,
+			},
+			wantErr: false,
+		},
+		{
+			name: "unknown rate limit store",
+			vars: map[string]string{
+				"ENV":              "local",
+				"RATE_LIMIT_STORE": "redis",
+			},
+			mockRepo:    &MockSecretRepository{},
+			wantConfig:  nil,
+			wantErr:     true,
+			errContains: "RATE_LIMIT_STORE must be",
+		},
+		{
+			name: "body limits",
+			vars: map[string]string{
+				"ENV":            "local",
+				"MAX_BODY_BYTES": "65536",
+				"MAX_JSON_DEPTH": "8",
+			},
+			mockRepo: &MockSecretRepository{},
+			wantConfig: &AppConfig{
+				Env: "local",
+				DB: Database{
+					DSN: "host= user= password= dbname= port= sslmode=",
+				},
+				BodyLimits: BodyLimits{MaxBytes: 65536, MaxDepth: 8},
+			},
+			wantErr: false,
+		},
+		{
+			name: "max batch operations",
+			vars: map[string]string{
+				"ENV":                  "local",
+				"MAX_BATCH_OPERATIONS": "25",
+			},
+			mockRepo: &MockSecretRepository{},
+			wantConfig: &AppConfig{
+				Env: "local",
+				DB: Database{
+					DSN: "host= user= password= dbname= port= sslmode=",
+				},
+				MaxBatchOperations: 25,
+			},
+			wantErr: false,
+		},
+		{
+			name: "max page size",
+			vars: map[string]string{
+				"ENV":           "local",
+				"MAX_PAGE_SIZE": "50",
+			},
+			mockRepo: &MockSecretRepository{},
+			wantConfig: &AppConfig{
+				Env: "local",
+				DB: Database{
+					DSN: "host= user= password= dbname= port= sslmode=",
+				},
+				MaxPageSize: 50,
+			},
+			wantErr: false,
+		},
+		{
+			name: "trusted proxy count",
+			vars: map[string]string{
+				"ENV":                 "local",
+				"TRUSTED_PROXY_COUNT": "1",
+			},
+			mockRepo: &MockSecretRepository{},
+			wantConfig: &AppConfig{
+				Env: "local",
+				DB: Database{
+					DSN: "host= user= password= dbname= port= sslmode=",
+				},
+				TrustedProxies: 1,
+			},
+			wantErr: false,
+		},
+		{
+			name: "idempotency ttl",
+			vars: map[string]string{
+				"ENV":             "local",
+				"IDEMPOTENCY_TTL": "1h30m",
+			},
+			mockRepo: &MockSecretRepository{},
+			wantConfig: &AppConfig{
+				Env: "local",
+				DB: Database{
+					DSN: "host= user= password= dbname= port= sslmode=",
+				},
+				IdempotencyTTL: 90 * time.Minute,
 			},
 			wantErr: false,
 		},
+		{
+			name: "trace exporter",
+			vars: map[string]string{
+				"ENV":            "local",
+				"TRACE_EXPORTER": "stdout",
+			},
+			mockRepo: &MockSecretRepository{},
+			wantConfig: &AppConfig{
+				Env: "local",
+				DB: Database{
+					DSN: "host= user= password= dbname= port= sslmode=",
+				},
+				TraceExporter: "stdout",
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid trace exporter",
+			vars: map[string]string{
+				"ENV":            "local",
+				"TRACE_EXPORTER": "jaeger",
+			},
+			mockRepo:    &MockSecretRepository{},
+			wantConfig:  nil,
+			wantErr:     true,
+			errContains: "TRACE_EXPORTER must be otlp, stdout or none",
+		},
+		{
+			name: "invalid idempotency ttl",
+			vars: map[string]string{
+				"ENV":             "local",
+				"IDEMPOTENCY_TTL": "24",
+			},
+			mockRepo:    &MockSecretRepository{},
+			wantConfig:  nil,
+			wantErr:     true,
+			errContains: "IDEMPOTENCY_TTL must be a positive duration",
+		},
+		{
+			name: "invalid body limit",
+			vars: map[string]string{
+				"ENV":            "local",
+				"MAX_BODY_BYTES": "1MB",
+			},
+			mockRepo:    &MockSecretRepository{},
+			wantConfig:  nil,
+			wantErr:     true,
+			errContains: "MAX_BODY_BYTES must be a positive integer",
+		},
+		{
+			name: "prod environment without authentication",
+			vars: map[string]string{
+				"ENV":                "prod",
+				"GCP_PROJECT_NUMBER": "1234567890",
+				"DB_PASSWORD_KEY":    "db-pass-secret",
+			},
+			mockRepo:    &MockSecretRepository{},
+			wantConfig:  nil,
+			wantErr:     true,
+			errContains: "OIDC_ISSUER is required",
+		},
+		{
+			name: "incomplete authentication",
+			vars: map[string]string{
+				"ENV":         "local",
+				"OIDC_ISSUER": "https://issuer.example.com",
+			},
+			mockRepo:    &MockSecretRepository{},
+			wantConfig:  nil,
+			wantErr:     true,
+			errContains: "OIDC_AUDIENCE and OIDC_JWKS_URL are required",
+		},
 		{
 			name: "prod environment secret fetch failure",
 			vars: map[string]string{
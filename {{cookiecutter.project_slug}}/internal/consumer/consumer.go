@@ -0,0 +1,276 @@
+package consumer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"{{cookiecutter.module_name}}/internal/logger"
+	"{{cookiecutter.module_name}}/internal/tracing"
+)
+
+// EventAttribute is the message attribute messages are routed by. The
+// publishing side sets it in gcp.MessageRepository.
+const EventAttribute = "event"
+
+// Message is a message received from a Subscription. It must be acked or
+// nacked exactly once, the Consumer takes care of that for its handlers.
+type Message struct {
+	ID         string
+	Data       []byte
+	Attributes map[string]string
+	// DeliveryAttempt counts deliveries starting at 1. It is 0 when the
+	// subscription does not report it, Pub/Sub only does so for
+	// subscriptions with a dead letter policy.
+	DeliveryAttempt int
+
+	ack  func()
+	nack func()
+	once sync.Once
+}
+
+// NewMessage wraps a message of a Subscription implementation.
+func NewMessage(id string, data []byte, attributes map[string]string, deliveryAttempt int, ack, nack func()) *Message {
+	return &Message{
+		ID:              id,
+		Data:            data,
+		Attributes:      attributes,
+		DeliveryAttempt: deliveryAttempt,
+		ack:             ack,
+		nack:            nack,
+	}
+}
+
+// Event returns the event the message was published for.
+func (m *Message) Event() string {
+	return m.Attributes[EventAttribute]
+}
+
+// Ack tells the subscription the message was handled.
+func (m *Message) Ack() {
+	m.once.Do(m.ack)
+}
+
+// Nack asks the subscription to deliver the message again.
+func (m *Message) Nack() {
+	m.once.Do(m.nack)
+}
+
+// Subscription delivers messages to fn until ctx is done. Receive calls fn
+// concurrently and returns once every call has returned.
+// gcp.NewSubscription is the Pub/Sub implementation, MemorySubscription the
+// in-memory one.
+type Subscription interface {
+	Receive(ctx context.Context, fn func(ctx context.Context, m *Message)) error
+}
+
+// Handler handles a message. Returning an error has the message delivered
+// again, unless the error is Permanent.
+type Handler func(ctx context.Context, m *Message) error
+
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent marks err as one that retrying cannot fix, such as a payload
+// that does not decode. The message is treated as poison straight away.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// IsPermanent reports whether err was marked with Permanent.
+func IsPermanent(err error) bool {
+	var permanent *permanentError
+	return errors.As(err, &permanent)
+}
+
+type Config struct {
+	Concurrency int                              // handlers running at once, 10 when zero
+	MaxAttempts int                              // deliveries before a failing message is poison, 5 when zero
+	Backoff     func(attempts int) time.Duration // delay before a failed message is nacked, doubling from 1s up to 1m when nil
+	// DropUnhandled acks and drops messages without a handler, for
+	// subscriptions that carry events the service ignores. They are nacked
+	// after Backoff when false, so they reach the dead letter topic rather
+	// than being lost.
+	DropUnhandled bool
+}
+
+// Consumer routes the messages of a Subscription to handlers by their event
+// attribute.
+//
+// A message is acked when its handler succeeds and nacked after Backoff when
+// it fails, so Pub/Sub delivers it again. A message is poison when its
+// handler returns a Permanent error, panics, or fails on delivery
+// MaxAttempts. Poison messages are passed to the poison handler and acked so
+// they stop coming back. Messages without a handler are logged and nacked,
+// or dropped with Config.DropUnhandled.
+//
+// Delivery is at least once, so handlers must be idempotent.
+type Consumer struct {
+	sub      Subscription
+	log      *slog.Logger
+	cfg      Config
+	sem      chan struct{}
+	mu       sync.RWMutex
+	handlers map[string]Handler
+	poison   Handler
+}
+
+func New(sub Subscription, log *slog.Logger, cfg Config) *Consumer {
+	if cfg.Concurrency <= 0 {
+		cfg.Concurrency = 10
+	}
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = 5
+	}
+	if cfg.Backoff == nil {
+		cfg.Backoff = exponentialBackoff
+	}
+	c := &Consumer{
+		sub:      sub,
+		log:      log,
+		cfg:      cfg,
+		sem:      make(chan struct{}, cfg.Concurrency),
+		handlers: map[string]Handler{},
+	}
+	c.poison = c.logPoison
+	return c
+}
+
+// Handle registers h for messages of event, replacing any earlier handler.
+func (c *Consumer) Handle(event string, h Handler) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.handlers[event] = h
+}
+
+// OnPoison replaces the poison handler, which logs the message by default.
+// Its error is logged, the message is acked either way.
+func (c *Consumer) OnPoison(h Handler) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.poison = h
+}
+
+// Run receives messages until ctx is done, then drains: handlers already
+// running finish with a context that is not canceled, messages waiting for a
+// handler or for their backoff are nacked. Run returns once the subscription
+// has stopped.
+func (c *Consumer) Run(ctx context.Context) error {
+	c.log.Info("starting consumer")
+	err := c.sub.Receive(ctx, c.dispatch)
+	c.log.Info("consumer stopped")
+	if err != nil && ctx.Err() == nil {
+		return fmt.Errorf("receive: %w", err)
+	}
+	return nil
+}
+
+func (c *Consumer) dispatch(ctx context.Context, m *Message) {
+	// continue the trace of the publisher from the message attributes
+	ctx, span := tracing.Tracer().Start(tracing.Extract(ctx, m.Attributes), "process "+m.Event(),
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			semconv.MessagingOperationName("process"),
+			semconv.MessagingMessageID(m.ID),
+		),
+	)
+	defer span.End()
+	log := c.log.With(slog.String("message_id", m.ID), slog.String("event", m.Event()))
+	log = log.With(logger.TraceAttrs(ctx)...)
+
+	c.mu.RLock()
+	handler, ok := c.handlers[m.Event()]
+	poison := c.poison
+	c.mu.RUnlock()
+	if !ok {
+		if c.cfg.DropUnhandled {
+			log.Warn("no handler for message, dropping it")
+			m.Ack()
+			return
+		}
+		log.Warn("no handler for message, nacking it", slog.Int("attempt", m.DeliveryAttempt))
+		c.nackAfterBackoff(ctx, m)
+		return
+	}
+
+	select {
+	case c.sem <- struct{}{}:
+	case <-ctx.Done():
+		m.Nack()
+		return
+	}
+	err := c.call(context.WithoutCancel(ctx), handler, m)
+	<-c.sem
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	switch {
+	case err == nil:
+		m.Ack()
+	case IsPermanent(err) || m.DeliveryAttempt >= c.cfg.MaxAttempts:
+		log.Error("poison message", slog.Int("attempt", m.DeliveryAttempt), slog.String("error", err.Error()))
+		if err := c.call(context.WithoutCancel(ctx), poison, m); err != nil {
+			log.Error("failed to handle poison message", slog.String("error", err.Error()))
+		}
+		m.Ack()
+	default:
+		log.Warn("failed to handle message", slog.Int("attempt", m.DeliveryAttempt), slog.String("error", err.Error()))
+		// the handler slot is free again while the message waits
+		c.nackAfterBackoff(ctx, m)
+	}
+}
+
+// nackAfterBackoff nacks m once its backoff has passed or ctx is done.
+func (c *Consumer) nackAfterBackoff(ctx context.Context, m *Message) {
+	timer := time.NewTimer(c.cfg.Backoff(max(m.DeliveryAttempt, 1)))
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+	m.Nack()
+}
+
+// call runs h and turns a panic into a Permanent error, a handler that
+// panics on a message will most likely panic on it again.
+func (c *Consumer) call(ctx context.Context, h Handler, m *Message) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = Permanent(fmt.Errorf("handler panicked: %v", r))
+		}
+	}()
+	return h(ctx, m)
+}
+
+func (c *Consumer) logPoison(ctx context.Context, m *Message) error {
+	c.log.Error("dropping poison message",
+		slog.String("message_id", m.ID),
+		slog.String("event", m.Event()),
+		slog.String("data", string(m.Data)),
+	)
+	return nil
+}
+
+func exponentialBackoff(attempts int) time.Duration {
+	const maxBackoff = time.Minute
+	if attempts > 6 {
+		return maxBackoff
+	}
+	return min(time.Second<<(attempts-1), maxBackoff)
+}
@@ -0,0 +1,290 @@
+package consumer
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	"{{cookiecutter.module_name}}/internal/tracing"
+)
+
+func newTestConsumer(cfg Config) (*Consumer, *MemorySubscription) {
+	if cfg.Backoff == nil {
+		cfg.Backoff = func(int) time.Duration { return time.Millisecond }
+	}
+	sub := NewMemorySubscription()
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	return New(sub, log, cfg), sub
+}
+
+// start runs c until the test ends and waits for it to drain.
+func start(t *testing.T, c *Consumer) context.CancelFunc {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- c.Run(ctx) }()
+	t.Cleanup(func() {
+		cancel()
+		if err := <-done; err != nil {
+			t.Errorf("Run() error = %v", err)
+		}
+	})
+	return cancel
+}
+
+func publish(t *testing.T, sub *MemorySubscription, event, data string) {
+	t.Helper()
+	if err := sub.PublishOrdered(context.Background(), event, "", []byte(data)); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+}
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestConsumer_Routing(t *testing.T) {
+	c, sub := newTestConsumer(Config{DropUnhandled: true})
+	var mu sync.Mutex
+	got := map[string][]string{}
+	for _, event := range []string{"a", "b"} {
+		c.Handle(event, func(ctx context.Context, m *Message) error {
+			mu.Lock()
+			defer mu.Unlock()
+			got[event] = append(got[event], string(m.Data))
+			return nil
+		})
+	}
+	start(t, c)
+
+	publish(t, sub, "a", "1")
+	publish(t, sub, "b", "2")
+	publish(t, sub, "unknown", "3")
+
+	waitFor(t, "messages to be acked", func() bool { return len(sub.Acked()) == 3 })
+	mu.Lock()
+	defer mu.Unlock()
+	if len(got["a"]) != 1 || got["a"][0] != "1" || len(got["b"]) != 1 || got["b"][0] != "2" {
+		t.Errorf("handled = %v, want a: [1], b: [2]", got)
+	}
+}
+
+func TestConsumer_Unhandled(t *testing.T) {
+	c, sub := newTestConsumer(Config{})
+	var handled atomic.Int32
+	start(t, c)
+
+	publish(t, sub, "b", "1")
+	// redelivered until a handler for it is registered
+	time.Sleep(20 * time.Millisecond)
+	if got := len(sub.Acked()); got != 0 {
+		t.Fatalf("acked %d messages, want 0", got)
+	}
+	c.Handle("b", func(ctx context.Context, m *Message) error {
+		handled.Add(1)
+		return nil
+	})
+
+	waitFor(t, "the message to be acked", func() bool { return len(sub.Acked()) == 1 })
+	if got := sub.Acked()[0].DeliveryAttempt; got < 2 {
+		t.Errorf("acked on delivery %d, want a redelivery", got)
+	}
+	if handled.Load() != 1 {
+		t.Errorf("handled %d times, want 1", handled.Load())
+	}
+}
+
+func TestConsumer_ContinuesTrace(t *testing.T) {
+	provider, propagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	defer func() {
+		otel.SetTracerProvider(provider)
+		otel.SetTextMapPropagator(propagator)
+	}()
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	c, sub := newTestConsumer(Config{})
+	handled := make(chan trace.SpanContext, 1)
+	c.Handle("a", func(ctx context.Context, m *Message) error {
+		handled <- trace.SpanContextFromContext(ctx)
+		return nil
+	})
+	start(t, c)
+
+	ctx, publisher := tracing.Tracer().Start(context.Background(), "publish a")
+	if err := sub.PublishOrdered(ctx, "a", "", []byte("1")); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+	publisher.End()
+
+	got := <-handled
+	if got.TraceID() != publisher.SpanContext().TraceID() {
+		t.Errorf("handler trace = %s, want %s", got.TraceID(), publisher.SpanContext().TraceID())
+	}
+	waitFor(t, "the message to be acked", func() bool { return len(sub.Acked()) == 1 })
+	for _, span := range recorder.Ended() {
+		if span.Name() == "process a" && span.Parent().SpanID() != publisher.SpanContext().SpanID() {
+			t.Errorf("process span parent = %s, want the publish span", span.Parent().SpanID())
+		}
+	}
+}
+
+func TestConsumer_Failures(t *testing.T) {
+	tests := []struct {
+		name         string
+		handler      func(attempt int) error
+		wantAttempts int
+		wantPoison   bool
+	}{
+		{
+			name: "Retried Until Success",
+			handler: func(attempt int) error {
+				if attempt < 3 {
+					return errors.New("unavailable")
+				}
+				return nil
+			},
+			wantAttempts: 3,
+		},
+		{
+			name:         "Poison After Max Attempts",
+			handler:      func(int) error { return errors.New("unavailable") },
+			wantAttempts: 4,
+			wantPoison:   true,
+		},
+		{
+			name:         "Permanent Error",
+			handler:      func(int) error { return Permanent(errors.New("bad payload")) },
+			wantAttempts: 1,
+			wantPoison:   true,
+		},
+		{
+			name:         "Panic",
+			handler:      func(int) error { panic("boom") },
+			wantAttempts: 1,
+			wantPoison:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, sub := newTestConsumer(Config{MaxAttempts: 4})
+			var attempts, poisoned atomic.Int32
+			c.Handle("a", func(ctx context.Context, m *Message) error {
+				attempts.Add(1)
+				return tt.handler(m.DeliveryAttempt)
+			})
+			c.OnPoison(func(ctx context.Context, m *Message) error {
+				poisoned.Add(1)
+				return nil
+			})
+			start(t, c)
+
+			publish(t, sub, "a", "{}")
+
+			waitFor(t, "the message to be acked", func() bool { return len(sub.Acked()) == 1 })
+			if got := int(attempts.Load()); got != tt.wantAttempts {
+				t.Errorf("attempts = %d, want %d", got, tt.wantAttempts)
+			}
+			if got := poisoned.Load() == 1; got != tt.wantPoison {
+				t.Errorf("poisoned = %v, want %v", got, tt.wantPoison)
+			}
+			if got := sub.Acked()[0].DeliveryAttempt; got != tt.wantAttempts {
+				t.Errorf("acked on attempt %d, want %d", got, tt.wantAttempts)
+			}
+		})
+	}
+}
+
+func TestConsumer_BoundedConcurrency(t *testing.T) {
+	c, sub := newTestConsumer(Config{Concurrency: 2})
+	var running, peak atomic.Int32
+	c.Handle("a", func(ctx context.Context, m *Message) error {
+		n := running.Add(1)
+		defer running.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		return nil
+	})
+	start(t, c)
+
+	for range 10 {
+		publish(t, sub, "a", "{}")
+	}
+
+	waitFor(t, "messages to be acked", func() bool { return len(sub.Acked()) == 10 })
+	if got := peak.Load(); got != 2 {
+		t.Errorf("peak concurrency = %d, want 2", got)
+	}
+}
+
+func TestConsumer_Drain(t *testing.T) {
+	c, sub := newTestConsumer(Config{Backoff: func(int) time.Duration { return time.Hour }})
+	started := make(chan struct{})
+	release := make(chan struct{})
+	var handlerErr error
+	c.Handle("slow", func(ctx context.Context, m *Message) error {
+		close(started)
+		<-release
+		handlerErr = ctx.Err()
+		return nil
+	})
+	c.Handle("failing", func(ctx context.Context, m *Message) error {
+		return errors.New("unavailable")
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- c.Run(ctx) }()
+
+	publish(t, sub, "failing", "{}")
+	publish(t, sub, "slow", "{}")
+	<-started
+	cancel()
+
+	select {
+	case <-done:
+		t.Fatal("Run() returned before the running handler finished")
+	case <-time.After(20 * time.Millisecond):
+	}
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	if handlerErr != nil {
+		t.Errorf("handler context error = %v, want nil", handlerErr)
+	}
+	acked := sub.Acked()
+	if len(acked) != 1 || acked[0].Event() != "slow" {
+		t.Errorf("acked %d messages, want the slow one", len(acked))
+	}
+	// the failing message was nacked without waiting out its backoff
+	if got := sub.Pending(); got != 1 {
+		t.Errorf("Pending() = %d, want 1", got)
+	}
+}
@@ -0,0 +1,109 @@
+package consumer
+
+import (
+	"context"
+	"strconv"
+	"sync"
+
+	"{{cookiecutter.module_name}}/internal/tracing"
+)
+
+// MemorySubscription is a Subscription that keeps messages in memory. It
+// redelivers nacked messages with an incremented DeliveryAttempt like
+// Pub/Sub with a dead letter policy does, and stands in for Pub/Sub in tests.
+//
+// It also implements outbox.Publisher, so the outbox relay can feed it.
+type MemorySubscription struct {
+	mu      sync.Mutex
+	nextID  int
+	queue   []*Message
+	acked   []*Message
+	pending int
+	ready   chan struct{}
+}
+
+func NewMemorySubscription() *MemorySubscription {
+	return &MemorySubscription{ready: make(chan struct{}, 1)}
+}
+
+// PublishOrdered queues a message for event with the trace context of ctx, as
+// gcp.MessageRepository does. Messages are queued in order, so orderingKey
+// needs no handling.
+func (s *MemorySubscription) PublishOrdered(ctx context.Context, event, orderingKey string, data []byte) error {
+	s.mu.Lock()
+	s.nextID++
+	id := strconv.Itoa(s.nextID)
+	s.mu.Unlock()
+
+	attributes := map[string]string{EventAttribute: event}
+	tracing.Inject(ctx, attributes)
+	s.push(id, data, attributes, 1)
+	return nil
+}
+
+func (s *MemorySubscription) push(id string, data []byte, attributes map[string]string, attempt int) {
+	var m *Message
+	m = NewMessage(id, data, attributes, attempt,
+		func() {
+			s.mu.Lock()
+			s.acked = append(s.acked, m)
+			s.pending--
+			s.mu.Unlock()
+		},
+		func() {
+			s.mu.Lock()
+			s.pending--
+			s.mu.Unlock()
+			s.push(id, data, attributes, attempt+1)
+		},
+	)
+
+	s.mu.Lock()
+	s.queue = append(s.queue, m)
+	s.pending++
+	s.mu.Unlock()
+	select {
+	case s.ready <- struct{}{}:
+	default:
+	}
+}
+
+func (s *MemorySubscription) Receive(ctx context.Context, fn func(ctx context.Context, m *Message)) error {
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	for {
+		s.mu.Lock()
+		queue := s.queue
+		s.queue = nil
+		s.mu.Unlock()
+
+		for _, m := range queue {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				fn(ctx, m)
+			}()
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-s.ready:
+		}
+	}
+}
+
+// Acked returns the messages acked so far, in the order they were acked.
+func (s *MemorySubscription) Acked() []*Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*Message(nil), s.acked...)
+}
+
+// Pending reports how many messages have not been acked yet.
+func (s *MemorySubscription) Pending() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.pending
+}
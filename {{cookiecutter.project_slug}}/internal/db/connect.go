@@ -1,8 +1,8 @@
 package db
 
 import (
+	"fmt"
 	"log/slog"
-	"os"
 
 	"cloud.google.com/go/cloudsqlconn"
 	"cloud.google.com/go/cloudsqlconn/postgres/pgxv5"
@@ -13,7 +13,7 @@ import (
 	"gorm.io/gorm/schema"
 )
 
-type MakeDbFn func(dsn string, log *slog.Logger) (*gorm.DB, func())
+type MakeDbFn func(dsn string, log *slog.Logger) (*gorm.DB, func(), error)
 
 func MakeDbFactory(env string) MakeDbFn {
 	if env == "local" {
@@ -22,7 +22,7 @@ func MakeDbFactory(env string) MakeDbFn {
 	return MakeCloudSQLDb
 }
 
-func MakeLocalDb(dsn string, log *slog.Logger) (*gorm.DB, func()) {
+func MakeLocalDb(dsn string, log *slog.Logger) (*gorm.DB, func(), error) {
 	log.Info("connecting to local postgresdb")
 	// Initialize database connection
 	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
@@ -31,15 +31,14 @@ func MakeLocalDb(dsn string, log *slog.Logger) (*gorm.DB, func()) {
 		},
 	})
 	if err != nil {
-		log.Error("failed to connect to database", slog.String("error", err.Error()))
-		os.Exit(1)
+		return nil, nil, fmt.Errorf("connect to database: %w", err)
 	}
-	return db, func() {}
+	return db, func() {}, nil
 }
 
 // this uses the cloud sql connector approach
 // https://github.com/go-gorm/gorm/issues/6991
-func MakeCloudSQLDb(dsn string, log *slog.Logger) (*gorm.DB, func()) {
+func MakeCloudSQLDb(dsn string, log *slog.Logger) (*gorm.DB, func(), error) {
 	log.Info("connecting to cloud sql")
 	cleanup, err := pgxv5.RegisterDriver(
 		"cloudsql-postgres",
@@ -47,7 +46,7 @@ func MakeCloudSQLDb(dsn string, log *slog.Logger) (*gorm.DB, func()) {
 		cloudsqlconn.WithIAMAuthN(),
 		cloudsqlconn.WithDefaultDialOptions(cloudsqlconn.WithPrivateIP()))
 	if err != nil {
-		panic(err)
+		return nil, nil, fmt.Errorf("register cloud sql driver: %w", err)
 	}
 
 	db, err := gorm.Open(postgres.New(postgres.Config{
@@ -59,15 +58,15 @@ func MakeCloudSQLDb(dsn string, log *slog.Logger) (*gorm.DB, func()) {
 		},
 	})
 	if err != nil {
-		log.Error("failed to connect to database", slog.String("error", err.Error()))
-		os.Exit(1)
+		cleanup()
+		return nil, nil, fmt.Errorf("connect to database: %w", err)
 	}
 	return db, func() {
 		err := cleanup()
 		if err != nil {
 			log.Error("failed to cleanup cloud sql driver", slog.String("error", err.Error()))
 		}
-	}
+	}, nil
 }
 
 func MakeDbSqlite() (*gorm.DB, error) {
@@ -0,0 +1,61 @@
+package entity
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// APIKey is a credential for callers that cannot use OIDC. Only a hash of
+// the key is stored, the key itself is shown once when it is minted.
+// LineageID is the ID of the first key of a rotation chain, replacements
+// inherit it so the caller keeps its identity across rotations.
+type APIKey struct {
+	ID         uuid.UUID `gorm:"primaryKey"`
+	LineageID  uuid.UUID `gorm:"not null"`
+	Name       string    `gorm:"not null"`
+	Hash       string    `gorm:"not null"`
+	Scopes     string    `gorm:"not null;default:''"` // space separated, like the scope claim of a JWT
+	ExpiresAt  *time.Time
+	RevokedAt  *time.Time
+	LastUsedAt *time.Time
+	CreatedAt  time.Time `gorm:"not null;default:CURRENT_TIMESTAMP"`
+}
+
+// NewAPIKey creates the first key of a lineage, Replace rotates it.
+func NewAPIKey(name string, scopes []string, expiresAt *time.Time) *APIKey {
+	id := uuid.New()
+	return &APIKey{
+		ID:        id,
+		LineageID: id,
+		Name:      name,
+		Scopes:    strings.Join(scopes, " "),
+		ExpiresAt: expiresAt,
+	}
+}
+
+// Replace creates a key with a new ID that inherits the lineage, name,
+// scopes and expiry of k.
+func (k *APIKey) Replace() *APIKey {
+	return &APIKey{
+		ID:        uuid.New(),
+		LineageID: k.LineageID,
+		Name:      k.Name,
+		Scopes:    k.Scopes,
+		ExpiresAt: k.ExpiresAt,
+	}
+}
+
+// ScopeList returns the scopes of the key.
+func (k *APIKey) ScopeList() []string {
+	return strings.Fields(k.Scopes)
+}
+
+// Active reports whether the key can be used at now.
+func (k *APIKey) Active(now time.Time) bool {
+	if k.RevokedAt != nil {
+		return false
+	}
+	return k.ExpiresAt == nil || now.Before(*k.ExpiresAt)
+}
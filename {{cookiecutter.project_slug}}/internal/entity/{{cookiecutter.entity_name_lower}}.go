@@ -1,22 +1,50 @@
 package entity
 
 import (
+	"regexp"
 	"time"
 
 	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"{{cookiecutter.module_name}}/internal/query"
+	"{{cookiecutter.module_name}}/internal/validation"
 )
 
 // {{cookiecutter.entity_name}} represents a {{cookiecutter.entity_name_lower}} in the system.
+// DeletedAt opts the {{cookiecutter.entity_name_lower}} into soft delete and Version into
+// optimistic concurrency. CreatedBy is the subject that created it, empty
+// when authentication was off.
 type {{cookiecutter.entity_name}} struct {
-	ID        uuid.UUID `gorm:"primaryKey"`
-	Name      string    `gorm:"not null"`
-	CreatedAt time.Time `gorm:"not null;default:CURRENT_TIMESTAMP"`
-	UpdatedAt time.Time `gorm:"not null;default:CURRENT_TIMESTAMP"`
+	ID        uuid.UUID      `gorm:"primaryKey"`
+	Name      string         `gorm:"not null"`
+	CreatedAt time.Time      `gorm:"not null;default:CURRENT_TIMESTAMP"`
+	UpdatedAt time.Time      `gorm:"not null;default:CURRENT_TIMESTAMP"`
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+	Version   int64          `gorm:"not null;default:1"`
+	CreatedBy string         `gorm:"not null;default:''"`
+}
+
+// {{cookiecutter.entity_name}}Fields are the fields clients may filter and sort {{cookiecutter.entity_name_lower}}s by.
+var {{cookiecutter.entity_name}}Fields = query.Fields{
+	"id":         {Column: "id", Type: query.UUID, Ops: []query.Operator{query.Eq, query.Ne, query.In}},
+	"name":       {Column: "name", Type: query.String, Ops: []query.Operator{query.Eq, query.Ne, query.Like, query.ILike, query.In}, Sortable: true},
+	"created_at": {Column: "created_at", Type: query.Time, Ops: []query.Operator{query.Eq, query.Lt, query.Lte, query.Gt, query.Gte}, Sortable: true},
+	"updated_at": {Column: "updated_at", Type: query.Time, Ops: []query.Operator{query.Eq, query.Lt, query.Lte, query.Gt, query.Gte}, Sortable: true},
+}
+
+// {{cookiecutter.entity_name}}NameRules are the rules every {{cookiecutter.entity_name_lower}} name satisfies, however it
+// is written. The length matches the name column.
+var {{cookiecutter.entity_name}}NameRules = []validation.Rule[string]{
+	validation.Required(),
+	validation.MaxLen(255),
+	validation.Pattern(regexp.MustCompile(`^[^\x00-\x1f\x7f]*$`), "must not contain control characters"),
 }
 
 func New{{cookiecutter.entity_name}}(name string) *{{cookiecutter.entity_name}} {
 	return &{{cookiecutter.entity_name}}{
-		ID:   uuid.New(),
-		Name: name,
+		ID:      uuid.New(),
+		Name:    name,
+		Version: 1,
 	}
 }
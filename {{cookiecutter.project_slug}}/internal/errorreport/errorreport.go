@@ -0,0 +1,22 @@
+package errorreport
+
+import (
+	"context"
+	"time"
+)
+
+// Event describes a failure worth a human looking at, such as a panic in a
+// handler.
+type Event struct {
+	Time          time.Time `json:"time"`
+	Message       string    `json:"message"`
+	Stack         string    `json:"stack"`
+	CorrelationID string    `json:"correlation_id,omitempty"`
+	Method        string    `json:"method,omitempty"`
+	Path          string    `json:"path,omitempty"`
+}
+
+// Reporter forwards events to an error reporting service.
+type Reporter interface {
+	Report(ctx context.Context, event Event) error
+}
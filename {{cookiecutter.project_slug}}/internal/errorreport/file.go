@@ -0,0 +1,40 @@
+package errorreport
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// FileReporter appends events to a file as JSON lines. It stands in for an
+// error reporting service when running locally.
+type FileReporter struct {
+	path string
+	mu   sync.Mutex
+}
+
+func NewFileReporter(path string) *FileReporter {
+	return &FileReporter{path: path}
+}
+
+func (r *FileReporter) Report(ctx context.Context, event Event) error {
+	line, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("encode event: %w", err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	f, err := os.OpenFile(r.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("open error report file: %w", err)
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return fmt.Errorf("write error report: %w", err)
+	}
+	return f.Close()
+}
@@ -0,0 +1,57 @@
+package errorreport
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFileReporter_Report(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "errors.jsonl")
+	reporter := NewFileReporter(path)
+
+	events := []Event{
+		{Time: time.Now().UTC(), Message: "first", Stack: "goroutine 1", CorrelationID: "abc", Method: "GET", Path: "/a"},
+		{Time: time.Now().UTC(), Message: "second", Stack: "goroutine 2"},
+	}
+	for _, event := range events {
+		if err := reporter.Report(context.Background(), event); err != nil {
+			t.Fatalf("Report() error = %v", err)
+		}
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("failed to open report file: %v", err)
+	}
+	defer f.Close()
+
+	var got []Event
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var event Event
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			t.Fatalf("failed to decode line %q: %v", scanner.Text(), err)
+		}
+		got = append(got, event)
+	}
+	if len(got) != len(events) {
+		t.Fatalf("got %d events, want %d", len(got), len(events))
+	}
+	for i := range events {
+		if !got[i].Time.Equal(events[i].Time) || got[i].Message != events[i].Message || got[i].CorrelationID != events[i].CorrelationID {
+			t.Errorf("event %d = %+v, want %+v", i, got[i], events[i])
+		}
+	}
+}
+
+func TestFileReporter_Unwritable(t *testing.T) {
+	reporter := NewFileReporter(filepath.Join(t.TempDir(), "missing", "errors.jsonl"))
+	if err := reporter.Report(context.Background(), Event{Message: "lost"}); err == nil {
+		t.Error("Report() error = nil, want an error for a missing directory")
+	}
+}
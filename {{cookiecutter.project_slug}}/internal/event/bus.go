@@ -0,0 +1,44 @@
+package event
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// Handler consumes an event delivered by a Bus.
+type Handler func(ctx context.Context, e Event) error
+
+// Bus is an in-process Publisher. Publish calls the handlers subscribed to
+// the event's type synchronously, inside the caller's transaction, so a
+// handler error fails the change that raised the event. It is meant for
+// tests and for in-process listeners.
+type Bus struct {
+	mu       sync.RWMutex
+	handlers map[string][]Handler
+}
+
+func NewBus() *Bus {
+	return &Bus{handlers: map[string][]Handler{}}
+}
+
+// Subscribe registers handler for events of eventType, "*" subscribes to all events.
+func (b *Bus) Subscribe(eventType string, handler Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers[eventType] = append(b.handlers[eventType], handler)
+}
+
+func (b *Bus) Publish(ctx context.Context, e Event) error {
+	b.mu.RLock()
+	handlers := append(append([]Handler(nil), b.handlers[e.Type]...), b.handlers["*"]...)
+	b.mu.RUnlock()
+
+	var errs []error
+	for _, handler := range handlers {
+		if err := handler(ctx, e); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,60 @@
+package event
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"{{cookiecutter.module_name}}/internal/logger"
+)
+
+// SchemaVersion is the version of the Event envelope. Consumers should
+// check it and it must be bumped on any change they could break on.
+const SchemaVersion = 1
+
+// Event describes a change to an aggregate. Before is null when the
+// aggregate was created and After is null when it was deleted.
+type Event struct {
+	ID            string          `json:"id"`
+	Type          string          `json:"type"`
+	SchemaVersion int             `json:"schema_version"`
+	AggregateType string          `json:"aggregate_type"`
+	AggregateID   string          `json:"aggregate_id"`
+	OccurredAt    time.Time       `json:"occurred_at"`
+	CorrelationID string          `json:"correlation_id,omitempty"`
+	Before        json.RawMessage `json:"before"`
+	After         json.RawMessage `json:"after"`
+}
+
+// Publisher delivers events to consumers.
+type Publisher interface {
+	Publish(ctx context.Context, e Event) error
+}
+
+// New builds an event of eventType with before and after snapshots of the
+// aggregate. The correlation ID of the request in ctx, if any, is attached.
+func New(ctx context.Context, eventType, aggregateType, aggregateID string, before, after any) (Event, error) {
+	beforeJSON, err := json.Marshal(before)
+	if err != nil {
+		return Event{}, fmt.Errorf("marshal before: %w", err)
+	}
+	afterJSON, err := json.Marshal(after)
+	if err != nil {
+		return Event{}, fmt.Errorf("marshal after: %w", err)
+	}
+
+	return Event{
+		ID:            uuid.New().String(),
+		Type:          eventType,
+		SchemaVersion: SchemaVersion,
+		AggregateType: aggregateType,
+		AggregateID:   aggregateID,
+		OccurredAt:    time.Now().UTC(),
+		CorrelationID: logger.CorrelationIDFromContext(ctx),
+		Before:        beforeJSON,
+		After:         afterJSON,
+	}, nil
+}
@@ -0,0 +1,139 @@
+package event
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"{{cookiecutter.module_name}}/internal/db"
+	"{{cookiecutter.module_name}}/internal/logger"
+	"{{cookiecutter.module_name}}/internal/outbox"
+)
+
+type snapshot struct {
+	Name string `json:"name"`
+}
+
+func TestNew(t *testing.T) {
+	ctx := logger.CorrelationIDToContext(context.Background(), "test-correlation-id")
+
+	tests := []struct {
+		name       string
+		before     any
+		after      any
+		wantBefore string
+		wantAfter  string
+	}{
+		{
+			name:       "created",
+			before:     (*snapshot)(nil),
+			after:      &snapshot{Name: "new"},
+			wantBefore: `null`,
+			wantAfter:  `{"name":"new"}`,
+		},
+		{
+			name:       "updated",
+			before:     &snapshot{Name: "old"},
+			after:      &snapshot{Name: "new"},
+			wantBefore: `{"name":"old"}`,
+			wantAfter:  `{"name":"new"}`,
+		},
+		{
+			name:       "deleted",
+			before:     &snapshot{Name: "old"},
+			after:      nil,
+			wantBefore: `{"name":"old"}`,
+			wantAfter:  `null`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, err := New(ctx, "test."+tt.name, "test", "1", tt.before, tt.after)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			if e.ID == "" || e.OccurredAt.IsZero() {
+				t.Errorf("New() = %+v, want ID and OccurredAt set", e)
+			}
+			if e.SchemaVersion != SchemaVersion || e.CorrelationID != "test-correlation-id" {
+				t.Errorf("New() = %+v, want schema version %d and the correlation ID", e, SchemaVersion)
+			}
+			if string(e.Before) != tt.wantBefore || string(e.After) != tt.wantAfter {
+				t.Errorf("New() before = %s after = %s, want %s and %s", e.Before, e.After, tt.wantBefore, tt.wantAfter)
+			}
+		})
+	}
+}
+
+func TestBus(t *testing.T) {
+	bus := NewBus()
+	ctx := context.Background()
+	errBoom := errors.New("boom")
+
+	var got []string
+	bus.Subscribe("test.created", func(ctx context.Context, e Event) error {
+		got = append(got, "created:"+e.AggregateID)
+		return nil
+	})
+	bus.Subscribe("*", func(ctx context.Context, e Event) error {
+		got = append(got, "all:"+e.AggregateID)
+		if e.Type == "test.failed" {
+			return errBoom
+		}
+		return nil
+	})
+
+	if err := bus.Publish(ctx, Event{Type: "test.created", AggregateID: "1"}); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+	if err := bus.Publish(ctx, Event{Type: "test.failed", AggregateID: "2"}); !errors.Is(err, errBoom) {
+		t.Fatalf("Publish() error = %v, want %v", err, errBoom)
+	}
+
+	want := []string{"created:1", "all:1", "all:2"}
+	if len(got) != len(want) {
+		t.Fatalf("handlers saw %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("handlers saw %v, want %v", got, want)
+			break
+		}
+	}
+}
+
+func TestOutboxPublisher(t *testing.T) {
+	db, err := db.MakeDbSqlite()
+	if err != nil {
+		t.Fatalf("failed to connect database: %v", err)
+	}
+	if err := db.AutoMigrate(&outbox.Message{}); err != nil {
+		t.Fatalf("failed to migrate database: %v", err)
+	}
+	ctx := context.Background()
+
+	e, err := New(ctx, "test.created", "test", "1", nil, &snapshot{Name: "new"})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if err := NewOutboxPublisher(outbox.New(db)).Publish(ctx, e); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+
+	var m outbox.Message
+	if err := db.First(&m).Error; err != nil {
+		t.Fatalf("failed to read outbox: %v", err)
+	}
+	if m.Event != e.Type || m.AggregateType != "test" || m.AggregateID != "1" {
+		t.Errorf("outbox message = %+v, want %s for test/1", m, e.Type)
+	}
+	var stored Event
+	if err := json.Unmarshal(m.Payload, &stored); err != nil {
+		t.Fatalf("failed to decode payload: %v", err)
+	}
+	if stored.ID != e.ID {
+		t.Errorf("payload ID = %q, want %q", stored.ID, e.ID)
+	}
+}
@@ -0,0 +1,27 @@
+package event
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"{{cookiecutter.module_name}}/internal/outbox"
+)
+
+type outboxPublisher struct {
+	outbox *outbox.Outbox
+}
+
+// NewOutboxPublisher publishes events by writing them to the outbox, in the
+// transaction carried by ctx. The outbox relay sends them on to Pub/Sub.
+func NewOutboxPublisher(o *outbox.Outbox) Publisher {
+	return &outboxPublisher{outbox: o}
+}
+
+func (p *outboxPublisher) Publish(ctx context.Context, e Event) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("marshal event: %w", err)
+	}
+	return p.outbox.Add(ctx, e.AggregateType, e.AggregateID, e.Type, data)
+}
@@ -7,11 +7,14 @@ import (
 	"time"
 
 	"cloud.google.com/go/pubsub"
+
+	"{{cookiecutter.module_name}}/internal/tracing"
 )
 
 // MessageRepository defines the interface for interacting with GCP Pub/Sub.
 type MessageRepository interface {
 	Publish(ctx context.Context, event string, data []byte) error
+	PublishOrdered(ctx context.Context, event, orderingKey string, data []byte) error
 	PublishEvent(ctx context.Context, event string, data []byte) error
 }
 
@@ -31,6 +34,9 @@ func NewMessageRepository(ctx context.Context, log *slog.Logger, projectID strin
 	// Disable batching
 	topic.PublishSettings.CountThreshold = 1
 	topic.PublishSettings.DelayThreshold = 0
+	// Messages with an ordering key are delivered in order to subscriptions
+	// that enable message ordering
+	topic.EnableMessageOrdering = true
 
 	return &messageRepository{
 		log:   log,
@@ -38,19 +44,36 @@ func NewMessageRepository(ctx context.Context, log *slog.Logger, projectID strin
 	}, nil
 }
 
+// Publish sends a message with the event set as an attribute.
 func (r *messageRepository) Publish(ctx context.Context, event string, data []byte) error {
+	return r.PublishOrdered(ctx, event, "", data)
+}
+
+// PublishOrdered sends a message like Publish. Messages with the same
+// non-empty orderingKey are delivered in order.
+func (r *messageRepository) PublishOrdered(ctx context.Context, event, orderingKey string, data []byte) error {
 	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
+	// subscribers continue the trace from the traceparent attribute
+	attributes := map[string]string{
+		"event": event,
+	}
+	tracing.Inject(ctx, attributes)
+
 	result := r.topic.Publish(ctx, &pubsub.Message{
-		Data: data,
-		Attributes: map[string]string{
-			"event": event,
-		},
+		Data:        data,
+		Attributes:  attributes,
+		OrderingKey: orderingKey,
 	})
 
 	id, err := result.Get(ctx)
 	if err != nil {
+		// a failed publish pauses its ordering key until it is resumed, the
+		// caller retries the message before any later one of the key
+		if orderingKey != "" {
+			r.topic.ResumePublish(orderingKey)
+		}
 		return fmt.Errorf("publish failed: %w", err)
 	}
 
@@ -58,7 +81,7 @@ func (r *messageRepository) Publish(ctx context.Context, event string, data []by
 	return nil
 }
 
-// Publish sends a message to the specified topic with the event set as an attribute.
+// PublishEvent sends a message without an ordering key.
 func (r *messageRepository) PublishEvent(ctx context.Context, event string, data []byte) error {
 	return r.Publish(ctx, event, data)
 }
@@ -0,0 +1,51 @@
+package gcp
+
+import (
+	"context"
+	"fmt"
+
+	"cloud.google.com/go/pubsub"
+
+	"{{cookiecutter.module_name}}/internal/consumer"
+)
+
+type subscription struct {
+	sub *pubsub.Subscription
+}
+
+// NewSubscription connects to the Pub/Sub subscription id. At most
+// maxOutstanding messages are held at once, handed out or waiting for a
+// retry. The client uses the emulator when PUBSUB_EMULATOR_HOST is set.
+//
+// The subscription must have a dead letter policy. Pub/Sub only counts
+// delivery attempts for such subscriptions, without them a failing message
+// would never reach consumer.Config.MaxAttempts and come back forever.
+func NewSubscription(ctx context.Context, projectID, id string, maxOutstanding int) (consumer.Subscription, error) {
+	client, err := pubsub.NewClient(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	sub := client.Subscription(id)
+	sub.ReceiveSettings.MaxOutstandingMessages = maxOutstanding
+
+	cfg, err := sub.Config(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("read subscription %s: %w", id, err)
+	}
+	if cfg.DeadLetterPolicy == nil {
+		return nil, fmt.Errorf("subscription %s has no dead letter policy, delivery attempts are not counted without one", id)
+	}
+
+	return &subscription{sub: sub}, nil
+}
+
+func (s *subscription) Receive(ctx context.Context, fn func(ctx context.Context, m *consumer.Message)) error {
+	return s.sub.Receive(ctx, func(ctx context.Context, msg *pubsub.Message) {
+		attempt := 0
+		if msg.DeliveryAttempt != nil {
+			attempt = *msg.DeliveryAttempt
+		}
+		fn(ctx, consumer.NewMessage(msg.ID, msg.Data, msg.Attributes, attempt, msg.Ack, msg.Nack))
+	})
+}
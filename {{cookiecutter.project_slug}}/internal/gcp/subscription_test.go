@@ -0,0 +1,55 @@
+package gcp
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"cloud.google.com/go/pubsub"
+	"cloud.google.com/go/pubsub/pstest"
+)
+
+func TestNewSubscription_RequiresDeadLetterPolicy(t *testing.T) {
+	srv := pstest.NewServer()
+	t.Cleanup(func() { srv.Close() })
+	t.Setenv("PUBSUB_EMULATOR_HOST", srv.Addr)
+
+	ctx := context.Background()
+	client, err := pubsub.NewClient(ctx, "test-project")
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+	topic, err := client.CreateTopic(ctx, "event-bus")
+	if err != nil {
+		t.Fatalf("failed to create topic: %v", err)
+	}
+	deadLetter, err := client.CreateTopic(ctx, "event-bus-dead-letter")
+	if err != nil {
+		t.Fatalf("failed to create dead letter topic: %v", err)
+	}
+	if _, err := client.CreateSubscription(ctx, "without-policy", pubsub.SubscriptionConfig{Topic: topic}); err != nil {
+		t.Fatalf("failed to create subscription: %v", err)
+	}
+	withPolicy := pubsub.SubscriptionConfig{
+		Topic: topic,
+		DeadLetterPolicy: &pubsub.DeadLetterPolicy{
+			DeadLetterTopic:     deadLetter.String(),
+			MaxDeliveryAttempts: 5,
+		},
+	}
+	if _, err := client.CreateSubscription(ctx, "with-policy", withPolicy); err != nil {
+		t.Fatalf("failed to create subscription: %v", err)
+	}
+
+	if _, err := NewSubscription(ctx, "test-project", "with-policy", 10); err != nil {
+		t.Errorf("NewSubscription() with a dead letter policy error = %v", err)
+	}
+	_, err = NewSubscription(ctx, "test-project", "without-policy", 10)
+	if err == nil || !strings.Contains(err.Error(), "no dead letter policy") {
+		t.Errorf("NewSubscription() without a dead letter policy error = %v, want one about the policy", err)
+	}
+	if _, err := NewSubscription(ctx, "test-project", "missing", 10); err == nil {
+		t.Error("expected an error for a missing subscription")
+	}
+}
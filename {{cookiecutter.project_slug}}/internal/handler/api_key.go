@@ -0,0 +1,154 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"time"
+
+	"{{cookiecutter.module_name}}/internal/entity"
+	"{{cookiecutter.module_name}}/internal/logger"
+	"{{cookiecutter.module_name}}/internal/service"
+	"{{cookiecutter.module_name}}/internal/validation"
+)
+
+// defaultRotationOverlap is how long a rotated key keeps working when the
+// request does not say.
+const defaultRotationOverlap = 24 * time.Hour
+
+type APIKeyHandler struct {
+	service service.APIKeyService
+}
+
+func NewAPIKeyHandler(service service.APIKeyService) *APIKeyHandler {
+	return &APIKeyHandler{service: service}
+}
+
+type MintAPIKeyRequest struct {
+	Name      string     `json:"name"`
+	Scopes    []string   `json:"scopes"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// scopePattern keeps scopes free of whitespace, they are stored space
+// separated.
+var scopePattern = regexp.MustCompile(`^\S+$`)
+
+func (req MintAPIKeyRequest) Validate() error {
+	var v validation.Validator
+	validation.Field(&v, "name", req.Name, validation.Required(), validation.MaxLen(255))
+	for i, scope := range req.Scopes {
+		validation.Field(&v, fmt.Sprintf("scopes[%d]", i), scope, validation.Required(), validation.Pattern(scopePattern, "must not contain whitespace"))
+	}
+	return v.Err()
+}
+
+type RotateAPIKeyRequest struct {
+	Overlap string `json:"overlap,omitempty"` // Go duration, 24h when empty
+}
+
+// APIKeyResponse carries the key itself, it is only returned when a key is
+// minted or rotated.
+type APIKeyResponse struct {
+	ID        string   `json:"id"`
+	LineageID string   `json:"lineage_id"`
+	Name      string   `json:"name"`
+	Scopes    []string `json:"scopes"`
+	ExpiresAt string   `json:"expires_at,omitempty"`
+	CreatedAt string   `json:"created_at"`
+	Key       string   `json:"key"`
+}
+
+func toAPIKeyResponse(k *entity.APIKey, key string) APIKeyResponse {
+	resp := APIKeyResponse{
+		ID:        k.ID.String(),
+		LineageID: k.LineageID.String(),
+		Name:      k.Name,
+		Scopes:    k.ScopeList(),
+		CreatedAt: k.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		Key:       key,
+	}
+	if k.ExpiresAt != nil {
+		resp.ExpiresAt = k.ExpiresAt.Format("2006-01-02T15:04:05Z07:00")
+	}
+	return resp
+}
+
+// HandleMintAPIKey creates a new API key
+func (h *APIKeyHandler) HandleMintAPIKey() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		log := logger.FromContext(r.Context())
+		log.Info("handling mint api key request")
+
+		req, err := decode[MintAPIKeyRequest](r)
+		if err != nil {
+			writeError(w, r, err, "failed to decode request")
+			return
+		}
+
+		apiKey, key, err := h.service.Mint(r.Context(), req.Name, req.Scopes, req.ExpiresAt)
+		if err != nil {
+			writeError(w, r, err, "failed to mint api key")
+			return
+		}
+
+		log.Info("api key minted successfully", slog.String("id", apiKey.ID.String()))
+		encode(w, r, http.StatusCreated, toAPIKeyResponse(apiKey, key))
+	})
+}
+
+// HandleRevokeAPIKey disables an API key by ID
+func (h *APIKeyHandler) HandleRevokeAPIKey() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		log := logger.FromContext(r.Context())
+
+		idStr := r.PathValue("id")
+		log.Info("handling revoke api key request", slog.String("id", idStr))
+
+		if err := h.service.Revoke(r.Context(), idStr); err != nil {
+			writeError(w, r, err, "failed to revoke api key")
+			return
+		}
+
+		log.Info("api key revoked successfully", slog.String("id", idStr))
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+// HandleRotateAPIKey replaces an API key. The old key keeps working for the
+// requested overlap.
+func (h *APIKeyHandler) HandleRotateAPIKey() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		log := logger.FromContext(r.Context())
+
+		idStr := r.PathValue("id")
+		log.Info("handling rotate api key request", slog.String("id", idStr))
+
+		// the body is optional
+		req, err := decode[RotateAPIKeyRequest](r)
+		if err != nil && !errors.Is(err, io.EOF) {
+			writeError(w, r, err, "failed to decode request")
+			return
+		}
+		overlap := defaultRotationOverlap
+		if req.Overlap != "" {
+			overlap, err = time.ParseDuration(req.Overlap)
+			if err != nil || overlap < 0 {
+				writeError(w, r, errInvalidOverlap, "invalid overlap")
+				return
+			}
+		}
+
+		apiKey, key, err := h.service.Rotate(r.Context(), idStr, overlap)
+		if err != nil {
+			writeError(w, r, err, "failed to rotate api key")
+			return
+		}
+
+		log.Info("api key rotated successfully", slog.String("id", idStr), slog.String("replacement", apiKey.ID.String()))
+		encode(w, r, http.StatusCreated, toAPIKeyResponse(apiKey, key))
+	})
+}
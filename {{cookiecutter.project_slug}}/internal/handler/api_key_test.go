@@ -0,0 +1,176 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"{{cookiecutter.module_name}}/internal/db"
+	"{{cookiecutter.module_name}}/internal/entity"
+	"{{cookiecutter.module_name}}/internal/repository"
+	"{{cookiecutter.module_name}}/internal/service"
+)
+
+func setupAPIKeyService(t *testing.T) service.APIKeyService {
+	db, err := db.MakeDbSqlite()
+	if err != nil {
+		t.Fatalf("failed to connect database: %v", err)
+	}
+
+	if err := db.AutoMigrate(&entity.APIKey{}); err != nil {
+		t.Fatalf("failed to migrate database: %v", err)
+	}
+
+	return service.NewAPIKeyService(repository.NewEntityRepository[entity.APIKey](db), []byte("test-secret"))
+}
+
+func TestAPIKeyHandler_Mint(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		expectedStatus int
+	}{
+		{
+			name:           "Success",
+			body:           `{"name":"ci","scopes":["project:read"]}`,
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:           "With Expiry",
+			body:           `{"name":"ci","expires_at":"2999-01-01T00:00:00Z"}`,
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:           "Expiry In The Past",
+			body:           `{"name":"ci","expires_at":"2000-01-01T00:00:00Z"}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Empty Name",
+			body:           `{"scopes":["project:read"]}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:           "Scope With Whitespace",
+			body:           `{"name":"ci","scopes":["project:read project:write"]}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:           "Unknown Field",
+			body:           `{"name":"ci","scope":"project:read"}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Invalid JSON",
+			body:           `{`,
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := setupAPIKeyService(t)
+			h := NewAPIKeyHandler(svc)
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/admin/api-key", bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			h.HandleMintAPIKey().ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if w.Code != http.StatusCreated {
+				return
+			}
+
+			var resp APIKeyResponse
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if _, err := svc.Verify(context.Background(), resp.Key); err != nil {
+				t.Errorf("minted key does not verify: %v", err)
+			}
+		})
+	}
+}
+
+func TestAPIKeyHandler_RevokeAndRotate(t *testing.T) {
+	svc := setupAPIKeyService(t)
+	h := NewAPIKeyHandler(svc)
+	mux := http.NewServeMux()
+	mux.Handle("DELETE /api/v1/admin/api-key/{id}", h.HandleRevokeAPIKey())
+	mux.Handle("POST /api/v1/admin/api-key/{id}/rotate", h.HandleRotateAPIKey())
+
+	mint := func() string {
+		t.Helper()
+		apiKey, _, err := svc.Mint(context.Background(), "ci", nil, nil)
+		if err != nil {
+			t.Fatalf("Mint() error = %v", err)
+		}
+		return apiKey.ID.String()
+	}
+
+	tests := []struct {
+		name           string
+		method         string
+		path           func(id string) string
+		body           string
+		expectedStatus int
+	}{
+		{
+			name:           "Revoke",
+			method:         http.MethodDelete,
+			path:           func(id string) string { return "/api/v1/admin/api-key/" + id },
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			name:           "Revoke Not Found",
+			method:         http.MethodDelete,
+			path:           func(string) string { return "/api/v1/admin/api-key/00000000-0000-0000-0000-000000000000" },
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "Rotate With Default Overlap",
+			method:         http.MethodPost,
+			path:           func(id string) string { return "/api/v1/admin/api-key/" + id + "/rotate" },
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:           "Rotate With Overlap",
+			method:         http.MethodPost,
+			path:           func(id string) string { return "/api/v1/admin/api-key/" + id + "/rotate" },
+			body:           `{"overlap":"1h"}`,
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:           "Rotate Invalid Overlap",
+			method:         http.MethodPost,
+			path:           func(id string) string { return "/api/v1/admin/api-key/" + id + "/rotate" },
+			body:           `{"overlap":"soon"}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Rotate Not Found",
+			method:         http.MethodPost,
+			path:           func(string) string { return "/api/v1/admin/api-key/invalid/rotate" },
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path(mint()), bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+		})
+	}
+}
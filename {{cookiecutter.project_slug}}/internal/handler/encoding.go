@@ -1,27 +1,117 @@
 package handler
 
 import (
-	"encoding/json"
+	"bytes"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"strings"
+
+	"{{cookiecutter.module_name}}/internal/codec"
+	"{{cookiecutter.module_name}}/internal/middleware"
+	"{{cookiecutter.module_name}}/internal/problem"
 )
 
-// encode writes a JSON response to the http.ResponseWriter.
+// codecs are the media types handlers read and write, JSON unless the
+// request asks for another.
+var codecs = codec.NewRegistry(codec.JSON{}, codec.CBOR{}, codec.MessagePack{}, codec.CSV{}, codec.NDJSON{})
+
+var (
+	errBodyTooLarge         = errors.New("request body too large")
+	errUnsupportedMediaType = errors.New("unsupported content type")
+	errNotAcceptable        = errors.New("no acceptable representation")
+)
+
+// encode writes v in the first codec the Accept header of r allows that can
+// represent v, or answers 406 when there is none. Problem details are always
+// sent as application/problem+json.
 func encode[T any](w http.ResponseWriter, r *http.Request, status int, v T) error {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(status)
+	if p, ok := any(v).(problem.Problem); ok {
+		return problem.Write(w, p)
+	}
 
-	if err := json.NewEncoder(w).Encode(v); err != nil {
-		return fmt.Errorf("encode json: %w", err)
+	w.Header().Add("Vary", "Accept")
+	var buf bytes.Buffer
+	for _, c := range codecs.ForAccept(r.Header.Get("Accept")) {
+		buf.Reset()
+		err := c.Encode(&buf, v)
+		if errors.Is(err, codec.ErrUnsupported) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("encode %s: %w", c.MediaType(), err)
+		}
+		w.Header().Set("Content-Type", c.MediaType())
+		w.WriteHeader(status)
+		_, err = buf.WriteTo(w)
+		return err
 	}
-	return nil
+
+	err := public(fmt.Errorf("%w, available are %s", errNotAcceptable, strings.Join(codecs.MediaTypes(), ", ")))
+	writeError(w, r, err, "failed to negotiate response")
+	return err
+}
+
+// validator is implemented by request types that check their fields, see
+// the validation package.
+type validator interface {
+	Validate() error
 }
 
-// decode reads a JSON request body into the provided type.
+// decode reads a request body in the codec its Content-Type names and
+// validates it when T is a validator. The body must fit the limits of the
+// request, hold a single value and only fields T has. A body without a
+// Content-Type is unsupported unless it is empty, errors of malformed bodies
+// wrap errInvalidBody.
 func decode[T any](r *http.Request) (T, error) {
 	var v T
-	if err := json.NewDecoder(r.Body).Decode(&v); err != nil {
-		return v, fmt.Errorf("decode json: %w", err)
+	contentType := r.Header.Get("Content-Type")
+	c, ok := codecs.ForContentType(contentType)
+	if !ok && contentType != "" {
+		return v, public(fmt.Errorf("%w %q", errUnsupportedMediaType, contentType))
+	}
+	limits := middleware.BodyLimitsFromContext(r.Context())
+	body, err := readBody(r, limits.MaxBytes)
+	if err != nil {
+		return v, err
+	}
+	if !ok {
+		if len(body) > 0 {
+			return v, public(fmt.Errorf("%w: the body has no Content-Type", errUnsupportedMediaType))
+		}
+		// an empty body decodes to io.EOF for handlers whose body is optional
+		c = codec.JSON{}
+	}
+
+	err = c.Decode(body, &v, limits.MaxDepth)
+	if errors.Is(err, codec.ErrUnsupported) {
+		return v, public(fmt.Errorf("%w %q", errUnsupportedMediaType, contentType))
+	}
+	if err != nil {
+		return v, fmt.Errorf("%w: decode %s: %w", errInvalidBody, c.MediaType(), err)
+	}
+
+	if val, ok := any(v).(validator); ok {
+		if err := val.Validate(); err != nil {
+			return v, err
+		}
 	}
 	return v, nil
 }
+
+// readBody reads the body of r, bodies longer than maxBytes are rejected
+// with errBodyTooLarge.
+func readBody(r *http.Request, maxBytes int64) ([]byte, error) {
+	if r.ContentLength > maxBytes {
+		return nil, public(fmt.Errorf("%w: limit is %d bytes", errBodyTooLarge, maxBytes))
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("read body: %w", err)
+	}
+	if int64(len(body)) > maxBytes {
+		return nil, public(fmt.Errorf("%w: limit is %d bytes", errBodyTooLarge, maxBytes))
+	}
+	return body, nil
+}
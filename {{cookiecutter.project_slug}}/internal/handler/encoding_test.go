@@ -3,11 +3,16 @@ package handler
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"io"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+
+	"{{cookiecutter.module_name}}/internal/codec"
+	"{{cookiecutter.module_name}}/internal/middleware"
+	"{{cookiecutter.module_name}}/internal/problem"
 )
 
 func TestEncode(t *testing.T) {
@@ -130,6 +135,89 @@ func TestEncode(t *testing.T) {
 	}
 }
 
+func TestEncode_Negotiation(t *testing.T) {
+	tests := []struct {
+		name            string
+		accept          string
+		wantStatus      int
+		wantContentType string
+	}{
+		{name: "default", accept: "", wantStatus: http.StatusOK, wantContentType: "application/json"},
+		{name: "cbor", accept: "application/cbor", wantStatus: http.StatusOK, wantContentType: "application/cbor"},
+		{name: "msgpack preferred", accept: "application/json;q=0.9, application/msgpack", wantStatus: http.StatusOK, wantContentType: "application/msgpack"},
+		{name: "csv of a single value", accept: "text/csv", wantStatus: http.StatusNotAcceptable, wantContentType: problem.ContentType},
+		{name: "csv or json", accept: "text/csv, application/json;q=0.5", wantStatus: http.StatusOK, wantContentType: "application/json"},
+		{name: "unknown", accept: "application/xml", wantStatus: http.StatusNotAcceptable, wantContentType: problem.ContentType},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/test", nil)
+			if tt.accept != "" {
+				r.Header.Set("Accept", tt.accept)
+			}
+
+			encode(w, r, http.StatusOK, map[string]string{"message": "hello"})
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("encode() status = %v, want %v", w.Code, tt.wantStatus)
+			}
+			if got := w.Header().Get("Content-Type"); got != tt.wantContentType {
+				t.Errorf("encode() Content-Type = %v, want %v", got, tt.wantContentType)
+			}
+			if got := w.Header().Get("Vary"); got != "Accept" {
+				t.Errorf("encode() Vary = %q, want Accept", got)
+			}
+		})
+	}
+}
+
+func TestDecode_ContentType(t *testing.T) {
+	type testStruct struct {
+		Name string `json:"name"`
+	}
+	cbor, msgpack := &bytes.Buffer{}, &bytes.Buffer{}
+	if err := (codec.CBOR{}).Encode(cbor, testStruct{Name: "test"}); err != nil {
+		t.Fatalf("failed to encode cbor: %v", err)
+	}
+	if err := (codec.MessagePack{}).Encode(msgpack, testStruct{Name: "test"}); err != nil {
+		t.Fatalf("failed to encode msgpack: %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        []byte
+		wantErr     error
+	}{
+		{name: "cbor", contentType: "application/cbor", body: cbor.Bytes()},
+		{name: "msgpack", contentType: "application/msgpack", body: msgpack.Bytes()},
+		{name: "csv", contentType: "text/csv", body: []byte("name\ntest\n"), wantErr: errUnsupportedMediaType},
+		{name: "cbor declared as json", contentType: "application/json", body: cbor.Bytes(), wantErr: errInvalidBody},
+		{name: "json without content type", contentType: "", body: []byte(`{"name":"test"}`), wantErr: errUnsupportedMediaType},
+		{name: "empty without content type", contentType: "", body: nil, wantErr: io.EOF},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/test", bytes.NewReader(tt.body))
+			r.Header.Set("Content-Type", tt.contentType)
+
+			got, err := decode[testStruct](r)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("decode() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || got.Name != "test" {
+				t.Errorf("decode() = %+v, %v, want name test", got, err)
+			}
+		})
+	}
+}
+
 func TestDecode(t *testing.T) {
 	type testStruct struct {
 		Name  string `json:"name"`
@@ -172,21 +260,21 @@ func TestDecode(t *testing.T) {
 			body:    `{invalid json}`,
 			want:    map[string]interface{}(nil),
 			wantErr: true,
-			errMsg:  "decode json",
+			errMsg:  "decode application/json",
 		},
 		{
 			name:    "decode empty body",
 			body:    ``,
 			want:    map[string]interface{}(nil),
 			wantErr: true,
-			errMsg:  "decode json",
+			errMsg:  "decode application/json",
 		},
 		{
 			name:    "decode malformed JSON",
 			body:    `{"name":"test"`,
 			want:    map[string]interface{}(nil),
 			wantErr: true,
-			errMsg:  "decode json",
+			errMsg:  "decode application/json",
 		},
 		{
 			name:    "decode nested structure",
@@ -199,6 +287,7 @@ func TestDecode(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			r := httptest.NewRequest(http.MethodPost, "/test", strings.NewReader(tt.body))
+			r.Header.Set("Content-Type", "application/json")
 
 			// Use type assertion to call decode with the correct type
 			switch tt.want.(type) {
@@ -270,6 +359,7 @@ func TestDecode_EdgeCases(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			r := httptest.NewRequest(http.MethodPost, "/test", tt.body)
+			r.Header.Set("Content-Type", "application/json")
 
 			_, err := decode[map[string]interface{}](r)
 
@@ -280,6 +370,91 @@ func TestDecode_EdgeCases(t *testing.T) {
 	}
 }
 
+func TestDecode_Limits(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        string
+		contentType string
+		limits      middleware.BodyLimits
+		wantErr     error
+	}{
+		{
+			name:        "json with charset",
+			body:        `{"a":1}`,
+			contentType: "application/json; charset=utf-8",
+		},
+		{
+			name:        "not json",
+			body:        `a=1`,
+			contentType: "application/x-www-form-urlencoded",
+			wantErr:     errUnsupportedMediaType,
+		},
+		{
+			name:    "too large",
+			body:    `{"a":"` + strings.Repeat("x", 32) + `"}`,
+			limits:  middleware.BodyLimits{MaxBytes: 16},
+			wantErr: errBodyTooLarge,
+		},
+		{
+			name:   "at the size limit",
+			body:   `{"a":"xxxxxxxxx"}`,
+			limits: middleware.BodyLimits{MaxBytes: 17},
+		},
+		{
+			name:    "second document",
+			body:    `{"a":1} {"a":2}`,
+			wantErr: errInvalidBody,
+		},
+		{
+			name:    "trailing garbage",
+			body:    `{"a":1}x`,
+			wantErr: errInvalidBody,
+		},
+		{
+			name: "trailing whitespace",
+			body: "{\"a\":1}\n",
+		},
+		{
+			name:    "too deep",
+			body:    `{"a":{"b":[{"c":1}]}}`,
+			limits:  middleware.BodyLimits{MaxDepth: 3},
+			wantErr: errInvalidBody,
+		},
+		{
+			name:   "brackets in strings do not nest",
+			body:   `{"a":"[[[[{ { ]"}`,
+			limits: middleware.BodyLimits{MaxDepth: 1},
+		},
+		{
+			name:   "escaped quotes in strings",
+			body:   `{"a":"\"[[\\"}`,
+			limits: middleware.BodyLimits{MaxDepth: 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/test", strings.NewReader(tt.body))
+			r.Header.Set("Content-Type", "application/json")
+			if tt.contentType != "" {
+				r.Header.Set("Content-Type", tt.contentType)
+			}
+			// the middleware hands decode the limits in the request context
+			middleware.BodyLimitMiddleware(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				r = req
+			}), tt.limits).ServeHTTP(httptest.NewRecorder(), r)
+
+			_, err := decode[map[string]any](r)
+			if tt.wantErr == nil && err != nil {
+				t.Errorf("decode() error = %v, want nil", err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("decode() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
 func TestEncodeDecode_RoundTrip(t *testing.T) {
 	type testData struct {
 		Name   string   `json:"name"`
@@ -332,6 +507,7 @@ func TestEncodeDecode_RoundTrip(t *testing.T) {
 
 			// Decode
 			r2 := httptest.NewRequest(http.MethodPost, "/test", w.Body)
+			r2.Header.Set("Content-Type", w.Header().Get("Content-Type"))
 			got, err := decode[testData](r2)
 			if err != nil {
 				t.Fatalf("decode() failed: %v", err)
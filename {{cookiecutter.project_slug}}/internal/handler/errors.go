@@ -0,0 +1,78 @@
+package handler
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"{{cookiecutter.module_name}}/internal/logger"
+	"{{cookiecutter.module_name}}/internal/problem"
+	"{{cookiecutter.module_name}}/internal/query"
+	"{{cookiecutter.module_name}}/internal/service"
+	"{{cookiecutter.module_name}}/internal/validation"
+)
+
+var (
+	errInvalidBody    = errors.New("invalid request body")
+	errInvalidOverlap = errors.New("overlap must be a non-negative duration")
+)
+
+// clientError is an error whose whole message is written for the client, so
+// it is the detail of its problem rather than the detail of its entry.
+type clientError struct {
+	err error
+}
+
+// public marks err as written for the client. It must only wrap sentinels
+// and values of the request, never errors of a decoder or the database.
+func public(err error) error {
+	return clientError{err: err}
+}
+
+func (e clientError) Error() string  { return e.err.Error() }
+func (e clientError) Unwrap() error  { return e.err }
+func (e clientError) Detail() string { return e.err.Error() }
+
+// problems maps the errors handlers answer with to problem details. Making
+// a new error part of the API takes one entry here.
+var problems = problem.NewRegistry().
+	// malformed requests
+	Register(problem.Is(errInvalidBody), http.StatusBadRequest, "invalid-body", "Invalid Request Body", "invalid request body").
+	Register(problem.Is(errMalformedPatch), http.StatusBadRequest, "invalid-body", "Invalid Request Body", "malformed patch").
+	Register(problem.As[*query.ParamError](), http.StatusBadRequest, "invalid-query", "Invalid Query Parameter", "invalid query parameter").
+	Register(problem.As[validation.Errors](), http.StatusUnprocessableEntity, "validation-failed", "Validation Failed", "invalid fields").
+	Register(problem.Is(errBodyTooLarge), http.StatusRequestEntityTooLarge, "body-too-large", "Request Body Too Large", "request body too large").
+	Register(problem.Is(errUnsupportedMediaType), http.StatusUnsupportedMediaType, "unsupported-media-type", "Unsupported Media Type", "unsupported content type").
+	Register(problem.Is(errNotAcceptable), http.StatusNotAcceptable, "not-acceptable", "Not Acceptable", "no acceptable representation").
+	Register(problem.Is(errUnsupportedPatch), http.StatusUnsupportedMediaType, "unsupported-media-type", "Unsupported Media Type", "unsupported patch media type").
+	Register(problem.Is(errPreconditionRequired), http.StatusPreconditionRequired, "precondition-required", "Precondition Required", "missing If-Match header").
+	Register(problem.Is(errPreconditionFailed), http.StatusPreconditionFailed, "precondition-failed", "Precondition Failed", "invalid If-Match header").
+	// {{cookiecutter.entity_name_lower}}s
+	Register(problem.Is(service.ErrInvalidID), http.StatusBadRequest, "invalid-id", "Invalid ID", "invalid {{cookiecutter.entity_name_lower}} ID").
+	Register(problem.Is(service.ErrInvalidCursor), http.StatusBadRequest, "invalid-cursor", "Invalid Cursor", "invalid cursor").
+	Register(problem.Is(service.ErrForbidden), http.StatusForbidden, "access-denied", "Access Denied", "access denied").
+	Register(problem.Is(service.Err{{cookiecutter.entity_name}}NotFound), http.StatusNotFound, "{{cookiecutter.entity_name_lower}}-not-found", "{{cookiecutter.entity_name}} Not Found", "{{cookiecutter.entity_name_lower}} not found").
+	Register(problem.Is(service.ErrVersionConflict), http.StatusPreconditionFailed, "version-conflict", "Version Conflict", "{{cookiecutter.entity_name_lower}} version does not match").
+	Register(problem.Is(service.ErrInvalidPatch), http.StatusUnprocessableEntity, "invalid-patch", "Invalid Patch", "patch cannot be applied").
+	Register(problem.Is(service.ErrImportAborted), http.StatusUnprocessableEntity, "import-aborted", "Import Aborted", "not imported, another row was rejected").
+	Register(problem.Is(service.ErrBatchAborted), http.StatusFailedDependency, "batch-aborted", "Batch Aborted", "not applied, another operation failed").
+	// api keys
+	Register(problem.Is(service.ErrAPIKeyNameRequired), http.StatusBadRequest, "name-required", "Name Required", "api key name is required").
+	Register(problem.Is(service.ErrInvalidExpiry), http.StatusBadRequest, "invalid-expiry", "Invalid Expiry", "expiry must be in the future").
+	Register(problem.Is(errInvalidOverlap), http.StatusBadRequest, "invalid-overlap", "Invalid Overlap", "overlap must be a non-negative duration").
+	Register(problem.Is(service.ErrAPIKeyNotFound), http.StatusNotFound, "api-key-not-found", "API Key Not Found", "api key not found")
+
+// writeError answers err with its problem details. Errors that are not
+// registered are logged and answered with a 500 that carries fallback, so
+// internal details never reach the client.
+func writeError(w http.ResponseWriter, r *http.Request, err error, fallback string) {
+	log := logger.FromContext(r.Context())
+
+	p, registered := problems.Lookup(r, err, fallback)
+	if registered {
+		log.Info("request rejected", slog.Int("status", p.Status), slog.String("error", err.Error()))
+	} else {
+		log.Error(fallback, slog.String("error", err.Error()))
+	}
+	encode(w, r, p.Status, p)
+}
@@ -0,0 +1,56 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Entity tags are the quoted version of a resource, e.g. "3". Clients send
+// them back in If-Match to make writes conditional and in If-None-Match to
+// revalidate cached reads.
+
+var (
+	errPreconditionRequired = errors.New("missing If-Match header")
+	errPreconditionFailed   = errors.New("invalid If-Match header")
+)
+
+// etag formats a version as a strong entity tag.
+func etag(version int64) string {
+	return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+// ifMatchVersion reads the version a client expects from If-Match. The header
+// must hold exactly one strong entity tag, weak tags and * cannot be used to
+// guard a write.
+func ifMatchVersion(r *http.Request) (int64, error) {
+	header := strings.TrimSpace(r.Header.Get("If-Match"))
+	if header == "" {
+		return 0, errPreconditionRequired
+	}
+	if len(header) < 2 || header[0] != '"' || header[len(header)-1] != '"' {
+		return 0, errPreconditionFailed
+	}
+	version, err := strconv.ParseInt(header[1:len(header)-1], 10, 64)
+	if err != nil {
+		return 0, errPreconditionFailed
+	}
+	return version, nil
+}
+
+// noneMatch reports whether If-None-Match lists tag, using the weak
+// comparison RFC 9110 prescribes for this header.
+func noneMatch(r *http.Request, tag string) bool {
+	header := r.Header.Get("If-None-Match")
+	if header == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == tag {
+			return true
+		}
+	}
+	return false
+}
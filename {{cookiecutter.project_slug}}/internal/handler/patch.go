@@ -0,0 +1,61 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+
+	jsonpatch "github.com/evanphx/json-patch/v5"
+
+	"{{cookiecutter.module_name}}/internal/codec"
+	"{{cookiecutter.module_name}}/internal/middleware"
+	"{{cookiecutter.module_name}}/internal/service"
+)
+
+const (
+	mergePatchType = "application/merge-patch+json" // RFC 7396
+	jsonPatchType  = "application/json-patch+json"  // RFC 6902
+
+	// acceptPatch is advertised in the Accept-Patch header of unsupported requests
+	acceptPatch = mergePatchType + ", " + jsonPatchType
+)
+
+var (
+	errUnsupportedPatch = errors.New("unsupported patch media type")
+	errMalformedPatch   = errors.New("malformed patch")
+)
+
+// decodePatch reads a PATCH body according to its Content-Type, within the
+// same limits as decode.
+func decodePatch(r *http.Request) (service.PatchFunc, error) {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || (mediaType != mergePatchType && mediaType != jsonPatchType) {
+		return nil, errUnsupportedPatch
+	}
+
+	limits := middleware.BodyLimitsFromContext(r.Context())
+	body, err := readBody(r, limits.MaxBytes)
+	if err != nil {
+		return nil, err
+	}
+	if err := codec.CheckJSONDepth(body, limits.MaxDepth); err != nil {
+		return nil, fmt.Errorf("%w: %w", errMalformedPatch, err)
+	}
+
+	if mediaType == mergePatchType {
+		if !json.Valid(body) {
+			return nil, errMalformedPatch
+		}
+		return func(doc []byte) ([]byte, error) {
+			return jsonpatch.MergePatch(doc, body)
+		}, nil
+	}
+
+	patch, err := jsonpatch.DecodePatch(body)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errMalformedPatch, err)
+	}
+	return patch.Apply, nil
+}
@@ -2,12 +2,21 @@ package handler
 
 import (
 	"errors"
+	"fmt"
+	"io"
 	"log/slog"
 	"net/http"
+	"strconv"
+	"strings"
 
+	"{{cookiecutter.module_name}}/internal/codec"
 	"{{cookiecutter.module_name}}/internal/entity"
 	"{{cookiecutter.module_name}}/internal/logger"
+	"{{cookiecutter.module_name}}/internal/middleware"
+	"{{cookiecutter.module_name}}/internal/problem"
+	"{{cookiecutter.module_name}}/internal/query"
 	"{{cookiecutter.module_name}}/internal/service"
+	"{{cookiecutter.module_name}}/internal/validation"
 )
 
 type {{cookiecutter.entity_name}}Handler struct {
@@ -27,20 +36,110 @@ type Update{{cookiecutter.entity_name}}Request struct {
 	Name string `json:"name"`
 }
 
+func (req Create{{cookiecutter.entity_name}}Request) Validate() error {
+	return validate{{cookiecutter.entity_name}}Name(req.Name)
+}
+
+func (req Update{{cookiecutter.entity_name}}Request) Validate() error {
+	return validate{{cookiecutter.entity_name}}Name(req.Name)
+}
+
+// validate{{cookiecutter.entity_name}}Name checks the name of a create or update request.
+func validate{{cookiecutter.entity_name}}Name(name string) error {
+	var v validation.Validator
+	validation.Field(&v, "name", name, entity.{{cookiecutter.entity_name}}NameRules...)
+	return v.Err()
+}
+
+// BatchOperationRequest is an operation of a batch. Updates and deletes send
+// the version they expect in the body, other routes take it from If-Match.
+type BatchOperationRequest struct {
+	Op      string `json:"op"`
+	ID      string `json:"id,omitempty"`
+	Name    string `json:"name,omitempty"`
+	Version int64  `json:"version,omitempty"`
+}
+
+type Batch{{cookiecutter.entity_name}}Request struct {
+	Operations []BatchOperationRequest `json:"operations"`
+}
+
+// Validate checks the shape of the operations. Names are validated as each
+// operation runs, so in best-effort mode only the operation at fault fails.
+func (req Batch{{cookiecutter.entity_name}}Request) Validate() error {
+	var v validation.Validator
+	v.Check(len(req.Operations) > 0, "operations", "must not be empty")
+	for i, op := range req.Operations {
+		field := fmt.Sprintf("operations[%d]", i)
+		validation.Field(&v, field+".op", op.Op, validation.Required(), validation.OneOf(service.BatchCreate, service.BatchUpdate, service.BatchDelete))
+		if op.Op == service.BatchUpdate || op.Op == service.BatchDelete {
+			validation.Field(&v, field+".id", op.ID, validation.Required())
+			v.Check(op.Version > 0, field+".version", "must be a positive integer")
+		}
+	}
+	return v.Err()
+}
+
 type {{cookiecutter.entity_name}}Response struct {
 	ID        string `json:"id"`
 	Name      string `json:"name"`
 	CreatedAt string `json:"created_at"`
 	UpdatedAt string `json:"updated_at"`
+	Version   int64  `json:"version"`
+	CreatedBy string `json:"created_by,omitempty"`
 }
 
+// List{{cookiecutter.entity_name}}Response is returned by both pagination modes. Offset mode
+// reports Total, cursor mode reports NextCursor and PrevCursor instead.
 type List{{cookiecutter.entity_name}}Response struct {
-	{{cookiecutter.entity_name}}s []{{cookiecutter.entity_name}}Response `json:"{{cookiecutter.entity_name}}s"`
-	Total    int               `json:"total"`
+	{{cookiecutter.entity_name}}s   []{{cookiecutter.entity_name}}Response `json:"{{cookiecutter.entity_name}}s"`
+	Total      *int              `json:"total,omitempty"`
+	NextCursor string            `json:"next_cursor,omitempty"`
+	PrevCursor string            `json:"prev_cursor,omitempty"`
+}
+
+// Rows makes the list a codec.Table, so it can be downloaded as CSV with
+// the columns of {{cookiecutter.entity_name}}Response.
+func (resp List{{cookiecutter.entity_name}}Response) Rows() any {
+	return resp.{{cookiecutter.entity_name}}s
+}
+
+// ImportRowResult reports what became of a row of an import. Row counts the
+// rows of the upload from 1, a CSV header is not a row.
+type ImportRowResult struct {
+	Row    int                  `json:"row"`
+	Status string               `json:"status"`
+	ID     string               `json:"id,omitempty"`
+	Reason string               `json:"reason,omitempty"`
+	Errors []problem.FieldError `json:"errors,omitempty"`
+}
+
+// Statuses of ImportRowResult.
+const (
+	importCreated  = "created"
+	importRejected = "rejected"
+)
+
+// Import{{cookiecutter.entity_name}}Response is the report of an import, a result per row in the
+// order of the upload.
+type Import{{cookiecutter.entity_name}}Response struct {
+	Created  int               `json:"created"`
+	Rejected int               `json:"rejected"`
+	Rows     []ImportRowResult `json:"rows"`
 }
 
-type ErrorResponse struct {
-	Error string `json:"error"`
+// BatchOperationResult is the outcome of an operation of a batch: the status
+// it would have had as a request of its own, with the {{cookiecutter.entity_name_lower}} it created or
+// updated or the problem it failed with.
+type BatchOperationResult struct {
+	Status  int              `json:"status"`
+	{{cookiecutter.entity_name}} *{{cookiecutter.entity_name}}Response `json:"{{cookiecutter.entity_name_lower}},omitempty"`
+	Error   *problem.Problem `json:"error,omitempty"`
+}
+
+// Batch{{cookiecutter.entity_name}}Response holds a result per operation, in the order of the request.
+type Batch{{cookiecutter.entity_name}}Response struct {
+	Results []BatchOperationResult `json:"results"`
 }
 
 // toResponse converts entity.{{cookiecutter.entity_name}} to {{cookiecutter.entity_name}}Response
@@ -50,6 +149,8 @@ func toResponse(p *entity.{{cookiecutter.entity_name}}) {{cookiecutter.entity_na
 		Name:      p.Name,
 		CreatedAt: p.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
 		UpdatedAt: p.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		Version:   p.Version,
+		CreatedBy: p.CreatedBy,
 	}
 }
 
@@ -61,28 +162,24 @@ func (h *{{cookiecutter.entity_name}}Handler) HandleCreate{{cookiecutter.entity_
 
 		req, err := decode[Create{{cookiecutter.entity_name}}Request](r)
 		if err != nil {
-			log.Error("failed to decode request", slog.String("error", err.Error()))
-			encode(w, r, http.StatusBadRequest, ErrorResponse{Error: "invalid request body"})
+			writeError(w, r, err, "failed to decode request")
 			return
 		}
 
 		{{cookiecutter.entity_name_lower}}, err := h.service.Create(r.Context(), req.Name)
 		if err != nil {
-			if errors.Is(err, service.ErrNameRequired) {
-				encode(w, r, http.StatusBadRequest, ErrorResponse{Error: "name is required"})
-				return
-			}
-			log.Error("failed to create {{cookiecutter.entity_name_lower}}", slog.String("error", err.Error()))
-			encode(w, r, http.StatusInternalServerError, ErrorResponse{Error: "failed to create {{cookiecutter.entity_name_lower}}"})
+			writeError(w, r, err, "failed to create {{cookiecutter.entity_name_lower}}")
 			return
 		}
 
 		log.Info("{{cookiecutter.entity_name_lower}} created successfully", slog.String("id", {{cookiecutter.entity_name_lower}}.ID.String()))
+		w.Header().Set("ETag", etag({{cookiecutter.entity_name_lower}}.Version))
 		encode(w, r, http.StatusCreated, toResponse({{cookiecutter.entity_name_lower}}))
 	})
 }
 
-// HandleGet{{cookiecutter.entity_name}} retrieves a {{cookiecutter.entity_name}} by ID
+// HandleGet{{cookiecutter.entity_name}} retrieves a {{cookiecutter.entity_name}} by ID. The response carries the version as
+// an ETag and If-None-Match answers 304 Not Modified while it still matches.
 func (h *{{cookiecutter.entity_name}}Handler) HandleGet{{cookiecutter.entity_name}}() http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		log := logger.FromContext(r.Context())
@@ -92,18 +189,15 @@ func (h *{{cookiecutter.entity_name}}Handler) HandleGet{{cookiecutter.entity_nam
 
 		{{cookiecutter.entity_name_lower}}, err := h.service.Get(r.Context(), idStr)
 		if err != nil {
-			if errors.Is(err, service.ErrInvalidID) {
-				log.Error("invalid {{cookiecutter.entity_name_lower}} ID", slog.String("error", err.Error()))
-				encode(w, r, http.StatusBadRequest, ErrorResponse{Error: "invalid {{cookiecutter.entity_name_lower}} ID"})
-				return
-			}
-			if errors.Is(err, service.Err{{cookiecutter.entity_name}}NotFound) {
-				log.Error("{{cookiecutter.entity_name_lower}} not found", slog.String("error", err.Error()))
-				encode(w, r, http.StatusNotFound, ErrorResponse{Error: "{{cookiecutter.entity_name_lower}} not found"})
-				return
-			}
-			log.Error("failed to get {{cookiecutter.entity_name_lower}}", slog.String("error", err.Error()))
-			encode(w, r, http.StatusInternalServerError, ErrorResponse{Error: "failed to get {{cookiecutter.entity_name_lower}}"})
+			writeError(w, r, err, "failed to get {{cookiecutter.entity_name_lower}}")
+			return
+		}
+
+		tag := etag({{cookiecutter.entity_name_lower}}.Version)
+		w.Header().Set("ETag", tag)
+		if noneMatch(r, tag) {
+			log.Info("{{cookiecutter.entity_name_lower}} not modified", slog.String("id", idStr))
+			w.WriteHeader(http.StatusNotModified)
 			return
 		}
 
@@ -112,7 +206,8 @@ func (h *{{cookiecutter.entity_name}}Handler) HandleGet{{cookiecutter.entity_nam
 	})
 }
 
-// HandleUpdate{{cookiecutter.entity_name}} updates an existing {{cookiecutter.entity_name}}
+// HandleUpdate{{cookiecutter.entity_name}} updates an existing {{cookiecutter.entity_name}}. If-Match must carry the ETag the
+// client last saw, the update is rejected when someone else changed it since.
 func (h *{{cookiecutter.entity_name}}Handler) HandleUpdate{{cookiecutter.entity_name}}() http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		log := logger.FromContext(r.Context())
@@ -120,40 +215,65 @@ func (h *{{cookiecutter.entity_name}}Handler) HandleUpdate{{cookiecutter.entity_
 		idStr := r.PathValue("id")
 		log.Info("handling update {{cookiecutter.entity_name_lower}} request", slog.String("id", idStr))
 
+		version, ok := h.ifMatch(w, r)
+		if !ok {
+			return
+		}
+
 		req, err := decode[Update{{cookiecutter.entity_name}}Request](r)
 		if err != nil {
-			log.Error("failed to decode request", slog.String("error", err.Error()))
-			encode(w, r, http.StatusBadRequest, ErrorResponse{Error: "invalid request body"})
+			writeError(w, r, err, "failed to decode request")
 			return
 		}
 
-		{{cookiecutter.entity_name_lower}}, err := h.service.Update(r.Context(), idStr, req.Name)
+		{{cookiecutter.entity_name_lower}}, err := h.service.Update(r.Context(), idStr, req.Name, version)
 		if err != nil {
-			if errors.Is(err, service.ErrInvalidID) {
-				log.Error("invalid {{cookiecutter.entity_name_lower}} ID", slog.String("error", err.Error()))
-				encode(w, r, http.StatusBadRequest, ErrorResponse{Error: "invalid {{cookiecutter.entity_name_lower}} ID"})
-				return
-			}
-			if errors.Is(err, service.Err{{cookiecutter.entity_name}}NotFound) {
-				log.Error("{{cookiecutter.entity_name_lower}} not found", slog.String("error", err.Error()))
-				encode(w, r, http.StatusNotFound, ErrorResponse{Error: "{{cookiecutter.entity_name_lower}} not found"})
-				return
-			}
-			if errors.Is(err, service.ErrNameRequired) {
-				encode(w, r, http.StatusBadRequest, ErrorResponse{Error: "name is required"})
-				return
-			}
-			log.Error("failed to update {{cookiecutter.entity_name_lower}}", slog.String("error", err.Error()))
-			encode(w, r, http.StatusInternalServerError, ErrorResponse{Error: "failed to update {{cookiecutter.entity_name_lower}}"})
+			writeError(w, r, err, "failed to update {{cookiecutter.entity_name_lower}}")
 			return
 		}
 
 		log.Info("{{cookiecutter.entity_name_lower}} updated successfully", slog.String("id", idStr))
+		w.Header().Set("ETag", etag({{cookiecutter.entity_name_lower}}.Version))
 		encode(w, r, http.StatusOK, toResponse({{cookiecutter.entity_name_lower}}))
 	})
 }
 
-// HandleDelete{{cookiecutter.entity_name}} deletes a {{cookiecutter.entity_name}} by ID
+// HandlePatch{{cookiecutter.entity_name}} partially updates a {{cookiecutter.entity_name}} with a JSON Merge Patch or a JSON
+// Patch, chosen by Content-Type. Like updates, it requires If-Match.
+func (h *{{cookiecutter.entity_name}}Handler) HandlePatch{{cookiecutter.entity_name}}() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		log := logger.FromContext(r.Context())
+
+		idStr := r.PathValue("id")
+		log.Info("handling patch {{cookiecutter.entity_name_lower}} request", slog.String("id", idStr))
+
+		version, ok := h.ifMatch(w, r)
+		if !ok {
+			return
+		}
+
+		patch, err := decodePatch(r)
+		if err != nil {
+			if errors.Is(err, errUnsupportedPatch) {
+				w.Header().Set("Accept-Patch", acceptPatch)
+			}
+			writeError(w, r, err, "failed to decode patch")
+			return
+		}
+
+		{{cookiecutter.entity_name_lower}}, err := h.service.Patch(r.Context(), idStr, version, patch)
+		if err != nil {
+			writeError(w, r, err, "failed to patch {{cookiecutter.entity_name_lower}}")
+			return
+		}
+
+		log.Info("{{cookiecutter.entity_name_lower}} patched successfully", slog.String("id", idStr))
+		w.Header().Set("ETag", etag({{cookiecutter.entity_name_lower}}.Version))
+		encode(w, r, http.StatusOK, toResponse({{cookiecutter.entity_name_lower}}))
+	})
+}
+
+// HandleDelete{{cookiecutter.entity_name}} deletes a {{cookiecutter.entity_name}} by ID. Like updates, it requires If-Match.
 func (h *{{cookiecutter.entity_name}}Handler) HandleDelete{{cookiecutter.entity_name}}() http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		log := logger.FromContext(r.Context())
@@ -161,14 +281,13 @@ func (h *{{cookiecutter.entity_name}}Handler) HandleDelete{{cookiecutter.entity_
 		idStr := r.PathValue("id")
 		log.Info("handling delete {{cookiecutter.entity_name}} request", slog.String("id", idStr))
 
-		if err := h.service.Delete(r.Context(), idStr); err != nil {
-			if errors.Is(err, service.ErrInvalidID) {
-				log.Error("invalid {{cookiecutter.entity_name_lower}} ID", slog.String("error", err.Error()))
-				encode(w, r, http.StatusBadRequest, ErrorResponse{Error: "invalid {{cookiecutter.entity_name_lower}} ID"})
-				return
-			}
-			log.Error("failed to delete {{cookiecutter.entity_name_lower}}", slog.String("error", err.Error()))
-			encode(w, r, http.StatusInternalServerError, ErrorResponse{Error: "failed to delete {{cookiecutter.entity_name_lower}}"})
+		version, ok := h.ifMatch(w, r)
+		if !ok {
+			return
+		}
+
+		if err := h.service.Delete(r.Context(), idStr, version); err != nil {
+			writeError(w, r, err, "failed to delete {{cookiecutter.entity_name_lower}}")
 			return
 		}
 
@@ -177,20 +296,99 @@ func (h *{{cookiecutter.entity_name}}Handler) HandleDelete{{cookiecutter.entity_
 	})
 }
 
-// HandleList{{cookiecutter.entity_name}} retrieves all {{cookiecutter.entity_name}} with pagination
-func (h *{{cookiecutter.entity_name}}Handler) HandleList{{cookiecutter.entity_name}}() http.Handler {
+// HandleRestore{{cookiecutter.entity_name}} restores a soft deleted {{cookiecutter.entity_name}} by ID
+func (h *{{cookiecutter.entity_name}}Handler) HandleRestore{{cookiecutter.entity_name}}() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		log := logger.FromContext(r.Context())
+
+		idStr := r.PathValue("id")
+		log.Info("handling restore {{cookiecutter.entity_name_lower}} request", slog.String("id", idStr))
+
+		{{cookiecutter.entity_name_lower}}, err := h.service.Restore(r.Context(), idStr)
+		if err != nil {
+			writeError(w, r, err, "failed to restore {{cookiecutter.entity_name_lower}}")
+			return
+		}
+
+		log.Info("{{cookiecutter.entity_name_lower}} restored successfully", slog.String("id", idStr))
+		w.Header().Set("ETag", etag({{cookiecutter.entity_name_lower}}.Version))
+		encode(w, r, http.StatusOK, toResponse({{cookiecutter.entity_name_lower}}))
+	})
+}
+
+// HandlePurge{{cookiecutter.entity_name}} permanently removes a {{cookiecutter.entity_name}} by ID.
+// It is registered behind the admin middleware.
+func (h *{{cookiecutter.entity_name}}Handler) HandlePurge{{cookiecutter.entity_name}}() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		log := logger.FromContext(r.Context())
+
+		idStr := r.PathValue("id")
+		log.Info("handling purge {{cookiecutter.entity_name_lower}} request", slog.String("id", idStr))
+
+		if err := h.service.Purge(r.Context(), idStr); err != nil {
+			writeError(w, r, err, "failed to purge {{cookiecutter.entity_name_lower}}")
+			return
+		}
+
+		log.Info("{{cookiecutter.entity_name_lower}} purged successfully", slog.String("id", idStr))
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+// ifMatch reads the version from If-Match and answers 428 or 412 when it is
+// missing or unusable.
+func (h *{{cookiecutter.entity_name}}Handler) ifMatch(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		writeError(w, r, err, "invalid precondition")
+		return 0, false
+	}
+	return version, true
+}
+
+// DefaultMaxPageSize is the number of {{cookiecutter.entity_name_lower}}s a list page may hold when
+// the server does not set another.
+const DefaultMaxPageSize = 100
+
+// HandleList{{cookiecutter.entity_name}} retrieves all {{cookiecutter.entity_name}} with pagination.
+// A cursor query parameter, even an empty one, selects keyset pagination,
+// otherwise limit and offset are used. Results can be narrowed with
+// filter[field][operator]=value and ordered with sort=-field,field. Pages
+// hold at most maxPageSize {{cookiecutter.entity_name_lower}}s, DefaultMaxPageSize when it is zero, larger
+// limits are lowered to it.
+func (h *{{cookiecutter.entity_name}}Handler) HandleList{{cookiecutter.entity_name}}(maxPageSize int) http.Handler {
+	if maxPageSize == 0 {
+		maxPageSize = DefaultMaxPageSize
+	}
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		log := logger.FromContext(r.Context())
 		log.Info("handling list {{cookiecutter.entity_name}} request")
 
 		// Parse query parameters
-		limitStr := r.URL.Query().Get("limit")
-		offsetStr := r.URL.Query().Get("offset")
+		values := r.URL.Query()
+		limitStr := values.Get("limit")
+		if limit, err := strconv.Atoi(limitStr); err == nil && limit > maxPageSize {
+			limitStr = strconv.Itoa(maxPageSize)
+		}
+		offsetStr := values.Get("offset")
 
-		{{cookiecutter.entity_name_lower}}s, total, err := h.service.List(r.Context(), limitStr, offsetStr)
+		params, err := query.Parse(values, entity.{{cookiecutter.entity_name}}Fields)
+		if err == nil && values.Has("cursor") && len(params.Sorts) > 0 {
+			err = &query.ParamError{Param: "sort", Reason: "cannot be combined with cursor"}
+		}
 		if err != nil {
-			log.Error("failed to list {{cookiecutter.entity_name_lower}}s", slog.String("error", err.Error()))
-			encode(w, r, http.StatusInternalServerError, ErrorResponse{Error: "failed to list {{cookiecutter.entity_name_lower}}s"})
+			writeError(w, r, err, "invalid list query")
+			return
+		}
+
+		if values.Has("cursor") {
+			h.listByCursor(w, r, limitStr, values.Get("cursor"), params)
+			return
+		}
+
+		{{cookiecutter.entity_name_lower}}s, total, err := h.service.List(r.Context(), limitStr, offsetStr, params)
+		if err != nil {
+			writeError(w, r, err, "failed to list {{cookiecutter.entity_name_lower}}s")
 			return
 		}
 
@@ -202,7 +400,302 @@ func (h *{{cookiecutter.entity_name}}Handler) HandleList{{cookiecutter.entity_na
 		log.Info("{{cookiecutter.entity_name_lower}}s listed successfully", slog.Int("count", len({{cookiecutter.entity_name_lower}}s)))
 		encode(w, r, http.StatusOK, List{{cookiecutter.entity_name}}Response{
 			{{cookiecutter.entity_name}}s: responses,
-			Total:    total,
+			Total:    &total,
+		})
+	})
+}
+
+func (h *{{cookiecutter.entity_name}}Handler) listByCursor(w http.ResponseWriter, r *http.Request, limitStr, cursor string, params query.Params) {
+	log := logger.FromContext(r.Context())
+
+	page, err := h.service.ListByCursor(r.Context(), limitStr, cursor, params)
+	if err != nil {
+		writeError(w, r, err, "failed to list {{cookiecutter.entity_name_lower}}s")
+		return
+	}
+
+	responses := make([]{{cookiecutter.entity_name}}Response, len(page.Items))
+	for i, p := range page.Items {
+		responses[i] = toResponse(&p)
+	}
+
+	log.Info("{{cookiecutter.entity_name_lower}}s listed successfully", slog.Int("count", len(page.Items)))
+	encode(w, r, http.StatusOK, List{{cookiecutter.entity_name}}Response{
+		{{cookiecutter.entity_name}}s:   responses,
+		NextCursor: page.NextCursor,
+		PrevCursor: page.PrevCursor,
+	})
+}
+
+// tableCodecs are the formats of exports and imports, NDJSON unless the
+// request names CSV. Both read and write rows one at a time.
+var tableCodecs = codec.NewRegistry(codec.NDJSON{}, codec.CSV{})
+
+// HandleExport{{cookiecutter.entity_name}} streams every {{cookiecutter.entity_name_lower}} that matches the filters of the request
+// as NDJSON, or as CSV when the Accept header prefers it. Rows are read and
+// flushed in batches, so memory stays flat however many there are, and a
+// client that goes away cancels the export. Errors once the first batch has
+// begun can no longer be answered with a problem, the connection is aborted
+// instead so the client does not take a truncated export for a complete one.
+func (h *{{cookiecutter.entity_name}}Handler) HandleExport{{cookiecutter.entity_name}}() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		log := logger.FromContext(r.Context())
+		log.Info("handling export {{cookiecutter.entity_name}} request")
+
+		params, err := query.Parse(r.URL.Query(), entity.{{cookiecutter.entity_name}}Fields)
+		if err == nil && len(params.Sorts) > 0 {
+			err = &query.ParamError{Param: "sort", Reason: "exports are ordered by created_at and id"}
+		}
+		if err != nil {
+			writeError(w, r, err, "invalid export query")
+			return
+		}
+
+		w.Header().Add("Vary", "Accept")
+		accepted := tableCodecs.ForAccept(r.Header.Get("Accept"))
+		if len(accepted) == 0 {
+			err := public(fmt.Errorf("%w, available are %s", errNotAcceptable, strings.Join(tableCodecs.MediaTypes(), ", ")))
+			writeError(w, r, err, "failed to negotiate export")
+			return
+		}
+		format := accepted[0].(codec.Streamer)
+
+		// the encoder buffers rows but writes them out whenever its buffer of
+		// 4 KB fills, so once the first batch has begun part of the body may
+		// have reached the client
+		enc, err := format.NewRowEncoder(w, {{cookiecutter.entity_name}}Response{})
+		if err != nil {
+			writeError(w, r, err, "failed to export {{cookiecutter.entity_name_lower}}s")
+			return
+		}
+		w.Header().Set("Content-Type", format.MediaType())
+
+		rc := http.NewResponseController(w)
+		started := false
+		count := 0
+		err = h.service.Export(r.Context(), params, func(batch []entity.{{cookiecutter.entity_name}}) error {
+			started = true
+			for i := range batch {
+				if err := enc.Encode(toResponse(&batch[i])); err != nil {
+					return err
+				}
+			}
+			if err := enc.Flush(); err != nil {
+				return err
+			}
+			count += len(batch)
+			if err := rc.Flush(); err != nil && !errors.Is(err, http.ErrNotSupported) {
+				return err
+			}
+			return nil
 		})
+		if err == nil {
+			err = enc.Flush()
+		}
+
+		switch {
+		case err == nil:
+			log.Info("{{cookiecutter.entity_name_lower}}s exported successfully", slog.Int("count", count))
+		case r.Context().Err() != nil:
+			log.Info("export canceled by the client", slog.Int("count", count))
+		case !started:
+			writeError(w, r, err, "failed to export {{cookiecutter.entity_name_lower}}s")
+		default:
+			log.Error("failed to export {{cookiecutter.entity_name_lower}}s",
+				slog.String("error", err.Error()),
+				slog.Int("count", count),
+			)
+			panic(http.ErrAbortHandler)
+		}
+	})
+}
+
+// writeModes are the values of the mode parameter of imports and batches.
+var writeModes = map[string]service.WriteMode{
+	"":            service.Atomic,
+	"atomic":      service.Atomic,
+	"best-effort": service.BestEffort,
+}
+
+// HandleImport{{cookiecutter.entity_name}} creates {{cookiecutter.entity_name_lower}}s from an upload of CSV or NDJSON rows with the
+// fields of Create{{cookiecutter.entity_name}}Request, as the Content-Type names. With
+// ?mode=atomic, the default, every row is created or none is and a rejected
+// row fails the request with 422. With ?mode=best-effort the valid rows are
+// created and the others rejected. Either way the response reports every row.
+func (h *{{cookiecutter.entity_name}}Handler) HandleImport{{cookiecutter.entity_name}}() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		log := logger.FromContext(r.Context())
+		log.Info("handling import {{cookiecutter.entity_name}} request")
+
+		mode, ok := writeModes[r.URL.Query().Get("mode")]
+		if !ok {
+			writeError(w, r, &query.ParamError{Param: "mode", Reason: "must be atomic or best-effort"}, "invalid import query")
+			return
+		}
+		contentType := r.Header.Get("Content-Type")
+		format, ok := tableCodecs.ForContentType(contentType)
+		if !ok {
+			writeError(w, r, public(fmt.Errorf("%w %q", errUnsupportedMediaType, contentType)), "failed to read import")
+			return
+		}
+
+		rows, err := readImport(w, r, format.(codec.Streamer))
+		if err != nil {
+			writeError(w, r, err, "failed to read import")
+			return
+		}
+
+		results, err := h.service.Import(r.Context(), rows, mode)
+		if err != nil {
+			writeError(w, r, err, "failed to import {{cookiecutter.entity_name_lower}}s")
+			return
+		}
+
+		resp := Import{{cookiecutter.entity_name}}Response{Rows: make([]ImportRowResult, len(results))}
+		for i, result := range results {
+			row := ImportRowResult{Row: i + 1}
+			if result.Err != nil {
+				row.Status = importRejected
+				row.Reason, row.Errors = importReason(r, result.Err)
+				resp.Rejected++
+			} else {
+				row.Status = importCreated
+				row.ID = result.{{cookiecutter.entity_name}}.ID.String()
+				resp.Created++
+			}
+			resp.Rows[i] = row
+		}
+
+		status := http.StatusOK
+		if mode == service.Atomic && resp.Rejected > 0 {
+			status = http.StatusUnprocessableEntity
+		}
+		log.Info("{{cookiecutter.entity_name_lower}}s imported", slog.Int("created", resp.Created), slog.Int("rejected", resp.Rejected))
+		encode(w, r, status, resp)
+	})
+}
+
+// readImport reads the rows of an import. Rows that cannot be decoded are
+// kept with an error wrapping errInvalidBody, so they are reported along with
+// the rest. The upload must fit the body limits of the request.
+func readImport(w http.ResponseWriter, r *http.Request, format codec.Streamer) ([]service.Import{{cookiecutter.entity_name}}, error) {
+	limits := middleware.BodyLimitsFromContext(r.Context())
+	dec := format.NewRowDecoder(http.MaxBytesReader(w, r.Body, limits.MaxBytes), limits.MaxDepth)
+
+	var rows []service.Import{{cookiecutter.entity_name}}
+	for {
+		var req Create{{cookiecutter.entity_name}}Request
+		err := dec.Decode(&req)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		var rowErr *codec.RowError
+		var maxBytesErr *http.MaxBytesError
+		switch {
+		case err == nil:
+			rows = append(rows, service.Import{{cookiecutter.entity_name}}{Name: req.Name})
+		case errors.As(err, &rowErr):
+			rows = append(rows, service.Import{{cookiecutter.entity_name}}{Err: fmt.Errorf("%w: %w", errInvalidBody, rowErr.Err)})
+		case errors.As(err, &maxBytesErr):
+			return nil, public(fmt.Errorf("%w: limit is %d bytes", errBodyTooLarge, limits.MaxBytes))
+		default:
+			return nil, fmt.Errorf("%w: read %s: %w", errInvalidBody, format.MediaType(), err)
+		}
+	}
+	if len(rows) == 0 {
+		return nil, public(fmt.Errorf("%w: no rows to import", errInvalidBody))
+	}
+	return rows, nil
+}
+
+// importReason explains why a row was rejected, with the detail and field
+// errors of the problem its error maps to. Like writeError it keeps errors
+// that are not registered from the client and logs them instead.
+func importReason(r *http.Request, err error) (string, []problem.FieldError) {
+	p, registered := problems.Lookup(r, err, "could not be stored")
+	if !registered {
+		logger.FromContext(r.Context()).Error("failed to import {{cookiecutter.entity_name_lower}}", slog.String("error", err.Error()))
+	}
+	return p.Detail, p.Errors
+}
+
+// DefaultMaxBatchOperations is the number of operations a batch may hold
+// when the server does not set another.
+const DefaultMaxBatchOperations = 100
+
+// batchStatuses are the statuses of operations that succeed, the same as
+// their routes answer with.
+var batchStatuses = map[string]int{
+	service.BatchCreate: http.StatusCreated,
+	service.BatchUpdate: http.StatusOK,
+	service.BatchDelete: http.StatusNoContent,
+}
+
+// HandleBatch{{cookiecutter.entity_name}} applies the create, update and delete operations of a
+// request in order, at most maxOperations of them, DefaultMaxBatchOperations
+// when it is zero. With ?mode=atomic, the default, they share a transaction
+// and the first that fails rolls back the others and fails the request with
+// 422. With ?mode=best-effort each operation commits on its own. Either way
+// the response holds the result of every operation.
+func (h *{{cookiecutter.entity_name}}Handler) HandleBatch{{cookiecutter.entity_name}}(maxOperations int) http.Handler {
+	if maxOperations == 0 {
+		maxOperations = DefaultMaxBatchOperations
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		log := logger.FromContext(r.Context())
+		log.Info("handling batch {{cookiecutter.entity_name}} request")
+
+		mode, ok := writeModes[r.URL.Query().Get("mode")]
+		if !ok {
+			writeError(w, r, &query.ParamError{Param: "mode", Reason: "must be atomic or best-effort"}, "invalid batch query")
+			return
+		}
+		req, err := decode[Batch{{cookiecutter.entity_name}}Request](r)
+		if err == nil && len(req.Operations) > maxOperations {
+			var v validation.Validator
+			v.Check(false, "operations", fmt.Sprintf("must hold at most %d operations", maxOperations))
+			err = v.Err()
+		}
+		if err != nil {
+			writeError(w, r, err, "failed to decode batch")
+			return
+		}
+
+		ops := make([]service.BatchOperation, len(req.Operations))
+		for i, op := range req.Operations {
+			ops[i] = service.BatchOperation{Op: op.Op, ID: op.ID, Name: op.Name, Version: op.Version}
+		}
+		results, err := h.service.Batch(r.Context(), ops, mode)
+		if err != nil {
+			writeError(w, r, err, "failed to apply batch")
+			return
+		}
+
+		resp := Batch{{cookiecutter.entity_name}}Response{Results: make([]BatchOperationResult, len(results))}
+		failed := 0
+		for i, result := range results {
+			if result.Err != nil {
+				p, registered := problems.Lookup(r, result.Err, "failed to apply operation")
+				if !registered {
+					log.Error("failed to apply batch operation", slog.Int("operation", i), slog.String("error", result.Err.Error()))
+				}
+				resp.Results[i] = BatchOperationResult{Status: p.Status, Error: &p}
+				failed++
+				continue
+			}
+			resp.Results[i].Status = batchStatuses[ops[i].Op]
+			if result.{{cookiecutter.entity_name}} != nil {
+				{{cookiecutter.entity_name_lower}} := toResponse(result.{{cookiecutter.entity_name}})
+				resp.Results[i].{{cookiecutter.entity_name}} = &{{cookiecutter.entity_name_lower}}
+			}
+		}
+
+		status := http.StatusOK
+		if mode == service.Atomic && failed > 0 {
+			status = http.StatusUnprocessableEntity
+		}
+		log.Info("batch applied", slog.Int("operations", len(ops)), slog.Int("failed", failed))
+		encode(w, r, status, resp)
 	})
 }
@@ -7,11 +7,15 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"{{cookiecutter.module_name}}/internal/entity"
+	"{{cookiecutter.module_name}}/internal/event"
 	"{{cookiecutter.module_name}}/internal/db"
+	"{{cookiecutter.module_name}}/internal/problem"
 	"{{cookiecutter.module_name}}/internal/repository"
 	"{{cookiecutter.module_name}}/internal/service"
 )
@@ -58,14 +62,43 @@ func Test{{cookiecutter.entity_name}}Handler_Create(t *testing.T) {
 		{
 			name: "Missing Name",
 			body: Create{{cookiecutter.entity_name}}Request{},
+			expectedStatus: http.StatusUnprocessableEntity,
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				var resp problem.Problem
+				if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+					t.Fatalf("failed to decode response: %v", err)
+				}
+				if len(resp.Errors) != 1 || resp.Errors[0] != (problem.FieldError{Field: "name", Message: "is required"}) {
+					t.Errorf("expected a field error for name, got %+v", resp.Errors)
+				}
+			},
+		},
+		{
+			name:           "Name Too Long",
+			body:           Create{{cookiecutter.entity_name}}Request{Name: strings.Repeat("a", 256)},
+			expectedStatus: http.StatusUnprocessableEntity,
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				var resp problem.Problem
+				if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+					t.Fatalf("failed to decode response: %v", err)
+				}
+				if resp.Type != "/problems/validation-failed" || len(resp.Errors) != 1 || resp.Errors[0].Field != "name" {
+					t.Errorf("expected a validation problem for name, got %+v", resp)
+				}
+			},
+		},
+		{
+			name:           "Unknown Field",
+			body:           `{"name":"New","owner":"someone"}`,
 			expectedStatus: http.StatusBadRequest,
 			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
-				var resp ErrorResponse
+				var resp problem.Problem
 				if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
 					t.Fatalf("failed to decode response: %v", err)
 				}
-				if resp.Error != "name is required" {
-					t.Errorf("expected error %q, got %q", "name is required", resp.Error)
+				// the decoder's message stays in the logs
+				if resp.Detail != "invalid request body" {
+					t.Errorf("expected detail %q, got %q", "invalid request body", resp.Detail)
 				}
 			},
 		},
@@ -74,12 +107,12 @@ func Test{{cookiecutter.entity_name}}Handler_Create(t *testing.T) {
 			body:           "invalid json",
 			expectedStatus: http.StatusBadRequest,
 			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
-				var resp ErrorResponse
+				var resp problem.Problem
 				if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
 					t.Fatalf("failed to decode response: %v", err)
 				}
-				if resp.Error != "invalid request body" {
-					t.Errorf("expected error %q, got %q", "invalid request body", resp.Error)
+				if !strings.HasPrefix(resp.Detail, "invalid request body") {
+					t.Errorf("expected error to start with %q, got %q", "invalid request body", resp.Detail)
 				}
 			},
 		},
@@ -88,7 +121,7 @@ func Test{{cookiecutter.entity_name}}Handler_Create(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			repo := setupTestDB(t)
-			svc := service.New{{cookiecutter.entity_name}}Service(repo)
+			svc := service.New{{cookiecutter.entity_name}}Service(repo, event.NewBus(), nil)
 			h := New{{cookiecutter.entity_name}}Handler(svc)
 
 			var body []byte
@@ -103,6 +136,7 @@ func Test{{cookiecutter.entity_name}}Handler_Create(t *testing.T) {
 			}
 
 			req := httptest.NewRequest(http.MethodPost, "/api/v1/{{cookiecutter.entity_name_lower}}", bytes.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
 			w := httptest.NewRecorder()
 
 			h.HandleCreate{{cookiecutter.entity_name}}().ServeHTTP(w, req)
@@ -120,7 +154,7 @@ func Test{{cookiecutter.entity_name}}Handler_Create(t *testing.T) {
 
 func Test{{cookiecutter.entity_name}}Handler_Get(t *testing.T) {
 	repo := setupTestDB(t)
-	svc := service.New{{cookiecutter.entity_name}}Service(repo)
+	svc := service.New{{cookiecutter.entity_name}}Service(repo, event.NewBus(), nil)
 	h := New{{cookiecutter.entity_name}}Handler(svc)
 	ctx := context.Background()
 
@@ -133,6 +167,7 @@ func Test{{cookiecutter.entity_name}}Handler_Get(t *testing.T) {
 	tests := []struct {
 		name           string
 		id             string
+		ifNoneMatch    string
 		expectedStatus int
 		checkResponse  func(t *testing.T, w *httptest.ResponseRecorder)
 	}{
@@ -148,19 +183,45 @@ func Test{{cookiecutter.entity_name}}Handler_Get(t *testing.T) {
 				if resp.ID != {{cookiecutter.entity_name_lower}}.ID.String() {
 					t.Errorf("expected ID %q, got %q", {{cookiecutter.entity_name_lower}}.ID.String(), resp.ID)
 				}
+				if got := w.Header().Get("ETag"); got != `"1"` {
+					t.Errorf("expected ETag %q, got %q", `"1"`, got)
+				}
+			},
+		},
+		{
+			name:           "Not Modified",
+			id:             {{cookiecutter.entity_name_lower}}.ID.String(),
+			ifNoneMatch:    `W/"0", "1"`,
+			expectedStatus: http.StatusNotModified,
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				if w.Body.Len() != 0 {
+					t.Errorf("expected empty body, got %q", w.Body.String())
+				}
 			},
 		},
+		{
+			name:           "Modified Since",
+			id:             {{cookiecutter.entity_name_lower}}.ID.String(),
+			ifNoneMatch:    `"0"`,
+			expectedStatus: http.StatusOK,
+		},
 		{
 			name:           "Not Found",
 			id:             uuid.New().String(),
 			expectedStatus: http.StatusNotFound,
 			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
-				var resp ErrorResponse
+				var resp problem.Problem
 				if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
 					t.Fatalf("failed to decode response: %v", err)
 				}
-				if resp.Error != "{{cookiecutter.entity_name_lower}} not found" {
-					t.Errorf("expected error %q, got %q", "{{cookiecutter.entity_name_lower}} not found", resp.Error)
+				if resp.Detail != "{{cookiecutter.entity_name_lower}} not found" {
+					t.Errorf("expected error %q, got %q", "{{cookiecutter.entity_name_lower}} not found", resp.Detail)
+				}
+				if got := w.Header().Get("Content-Type"); got != problem.ContentType {
+					t.Errorf("expected Content-Type %q, got %q", problem.ContentType, got)
+				}
+				if resp.Type != "/problems/{{cookiecutter.entity_name_lower}}-not-found" || resp.Status != http.StatusNotFound {
+					t.Errorf("expected a not-found problem, got %+v", resp)
 				}
 			},
 		},
@@ -169,12 +230,12 @@ func Test{{cookiecutter.entity_name}}Handler_Get(t *testing.T) {
 			id:             "invalid-uuid",
 			expectedStatus: http.StatusBadRequest,
 			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
-				var resp ErrorResponse
+				var resp problem.Problem
 				if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
 					t.Fatalf("failed to decode response: %v", err)
 				}
-				if resp.Error != "invalid {{cookiecutter.entity_name_lower}} ID" {
-					t.Errorf("expected error %q, got %q", "invalid {{cookiecutter.entity_name_lower}} ID", resp.Error)
+				if resp.Detail != "invalid {{cookiecutter.entity_name_lower}} ID" {
+					t.Errorf("expected error %q, got %q", "invalid {{cookiecutter.entity_name_lower}} ID", resp.Detail)
 				}
 			},
 		},
@@ -184,6 +245,9 @@ func Test{{cookiecutter.entity_name}}Handler_Get(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			req := httptest.NewRequest(http.MethodGet, "/api/v1/{{cookiecutter.entity_name_lower}}/"+tt.id, nil)
 			req.SetPathValue("id", tt.id)
+			if tt.ifNoneMatch != "" {
+				req.Header.Set("If-None-Match", tt.ifNoneMatch)
+			}
 			w := httptest.NewRecorder()
 
 			h.HandleGet{{cookiecutter.entity_name}}().ServeHTTP(w, req)
@@ -201,7 +265,7 @@ func Test{{cookiecutter.entity_name}}Handler_Get(t *testing.T) {
 
 func Test{{cookiecutter.entity_name}}Handler_Update(t *testing.T) {
 	repo := setupTestDB(t)
-	svc := service.New{{cookiecutter.entity_name}}Service(repo)
+	svc := service.New{{cookiecutter.entity_name}}Service(repo, event.NewBus(), nil)
 	h := New{{cookiecutter.entity_name}}Handler(svc)
 	ctx := context.Background()
 
@@ -213,13 +277,15 @@ func Test{{cookiecutter.entity_name}}Handler_Update(t *testing.T) {
 	tests := []struct {
 		name           string
 		id             string
+		ifMatch        string
 		body           interface{}
 		expectedStatus int
 		checkResponse  func(t *testing.T, w *httptest.ResponseRecorder)
 	}{
 		{
-			name: "Success",
-			id:   {{cookiecutter.entity_name_lower}}.ID.String(),
+			name:    "Success",
+			id:      {{cookiecutter.entity_name_lower}}.ID.String(),
+			ifMatch: `"1"`,
 			body: Update{{cookiecutter.entity_name}}Request{
 				Name: "Updated Name",
 			},
@@ -232,11 +298,15 @@ func Test{{cookiecutter.entity_name}}Handler_Update(t *testing.T) {
 				if resp.Name != "Updated Name" {
 					t.Errorf("expected name %q, got %q", "Updated Name", resp.Name)
 				}
+				if got := w.Header().Get("ETag"); got != `"2"` {
+					t.Errorf("expected ETag %q, got %q", `"2"`, got)
+				}
 			},
 		},
 		{
-			name: "Not Found",
-			id:   uuid.New().String(),
+			name:    "Not Found",
+			id:      uuid.New().String(),
+			ifMatch: `"1"`,
 			body: Update{{cookiecutter.entity_name}}Request{
 				Name: "Updated Name",
 			},
@@ -244,8 +314,9 @@ func Test{{cookiecutter.entity_name}}Handler_Update(t *testing.T) {
 			checkResponse:  nil,
 		},
 		{
-			name: "Invalid ID",
-			id:   "invalid-uuid",
+			name:    "Invalid ID",
+			id:      "invalid-uuid",
+			ifMatch: `"1"`,
 			body: Update{{cookiecutter.entity_name}}Request{
 				Name: "Updated Name",
 			},
@@ -253,20 +324,47 @@ func Test{{cookiecutter.entity_name}}Handler_Update(t *testing.T) {
 			checkResponse:  nil,
 		},
 		{
-			name: "Missing Name",
-			id:   {{cookiecutter.entity_name_lower}}.ID.String(),
-			body: Update{{cookiecutter.entity_name}}Request{},
-			expectedStatus: http.StatusBadRequest,
+			name:           "Missing Name",
+			id:             {{cookiecutter.entity_name_lower}}.ID.String(),
+			ifMatch:        `"1"`,
+			body:           Update{{cookiecutter.entity_name}}Request{},
+			expectedStatus: http.StatusUnprocessableEntity,
 			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
-				var resp ErrorResponse
+				var resp problem.Problem
 				if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
 					t.Fatalf("failed to decode response: %v", err)
 				}
-				if resp.Error != "name is required" {
-					t.Errorf("expected error %q, got %q", "name is required", resp.Error)
+				if len(resp.Errors) != 1 || resp.Errors[0] != (problem.FieldError{Field: "name", Message: "is required"}) {
+					t.Errorf("expected a field error for name, got %+v", resp.Errors)
 				}
 			},
 		},
+		{
+			name:    "Stale Version",
+			id:      {{cookiecutter.entity_name_lower}}.ID.String(),
+			ifMatch: `"1"`,
+			body: Update{{cookiecutter.entity_name}}Request{
+				Name: "Lost Update",
+			},
+			expectedStatus: http.StatusPreconditionFailed,
+		},
+		{
+			name:    "Weak ETag",
+			id:      {{cookiecutter.entity_name_lower}}.ID.String(),
+			ifMatch: `W/"2"`,
+			body: Update{{cookiecutter.entity_name}}Request{
+				Name: "Lost Update",
+			},
+			expectedStatus: http.StatusPreconditionFailed,
+		},
+		{
+			name: "Missing If-Match",
+			id:   {{cookiecutter.entity_name_lower}}.ID.String(),
+			body: Update{{cookiecutter.entity_name}}Request{
+				Name: "Lost Update",
+			},
+			expectedStatus: http.StatusPreconditionRequired,
+		},
 	}
 
 	for _, tt := range tests {
@@ -283,7 +381,11 @@ func Test{{cookiecutter.entity_name}}Handler_Update(t *testing.T) {
 			}
 
 			req := httptest.NewRequest(http.MethodPut, "/api/v1/{{cookiecutter.entity_name_lower}}/"+tt.id, bytes.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
 			req.SetPathValue("id", tt.id)
+			if tt.ifMatch != "" {
+				req.Header.Set("If-Match", tt.ifMatch)
+			}
 			w := httptest.NewRecorder()
 
 			h.HandleUpdate{{cookiecutter.entity_name}}().ServeHTTP(w, req)
@@ -299,9 +401,151 @@ func Test{{cookiecutter.entity_name}}Handler_Update(t *testing.T) {
 	}
 }
 
+func Test{{cookiecutter.entity_name}}Handler_Patch(t *testing.T) {
+	repo := setupTestDB(t)
+	svc := service.New{{cookiecutter.entity_name}}Service(repo, event.NewBus(), nil)
+	h := New{{cookiecutter.entity_name}}Handler(svc)
+	ctx := context.Background()
+
+	{{cookiecutter.entity_name_lower}} := entity.New{{cookiecutter.entity_name}}("Original Name")
+	if err := repo.Create(ctx, {{cookiecutter.entity_name_lower}}); err != nil {
+		t.Fatalf("failed to create {{cookiecutter.entity_name_lower}}: %v", err)
+	}
+
+	tests := []struct {
+		name           string
+		id             string
+		contentType    string
+		ifMatch        string
+		body           string
+		expectedStatus int
+		expectedName   string
+		expectedETag   string
+	}{
+		{
+			name:           "Merge Patch",
+			id:             {{cookiecutter.entity_name_lower}}.ID.String(),
+			contentType:    "application/merge-patch+json",
+			ifMatch:        `"1"`,
+			body:           `{"name":"Merged"}`,
+			expectedStatus: http.StatusOK,
+			expectedName:   "Merged",
+			expectedETag:   `"2"`,
+		},
+		{
+			name:           "JSON Patch",
+			id:             {{cookiecutter.entity_name_lower}}.ID.String(),
+			contentType:    "application/json-patch+json",
+			ifMatch:        `"2"`,
+			body:           `[{"op":"test","path":"/name","value":"Merged"},{"op":"replace","path":"/name","value":"Replaced"}]`,
+			expectedStatus: http.StatusOK,
+			expectedName:   "Replaced",
+			expectedETag:   `"3"`,
+		},
+		{
+			name:           "Failed Test Operation",
+			id:             {{cookiecutter.entity_name_lower}}.ID.String(),
+			contentType:    "application/json-patch+json",
+			ifMatch:        `"3"`,
+			body:           `[{"op":"test","path":"/name","value":"Merged"}]`,
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:           "Unknown Field",
+			id:             {{cookiecutter.entity_name_lower}}.ID.String(),
+			contentType:    "application/merge-patch+json",
+			ifMatch:        `"3"`,
+			body:           `{"owner":"someone"}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:           "Name Removed",
+			id:             {{cookiecutter.entity_name_lower}}.ID.String(),
+			contentType:    "application/merge-patch+json",
+			ifMatch:        `"3"`,
+			body:           `{"name":null}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:           "Malformed Patch",
+			id:             {{cookiecutter.entity_name_lower}}.ID.String(),
+			contentType:    "application/json-patch+json",
+			ifMatch:        `"3"`,
+			body:           `{"op":"replace"}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Unsupported Media Type",
+			id:             {{cookiecutter.entity_name_lower}}.ID.String(),
+			contentType:    "application/json",
+			ifMatch:        `"3"`,
+			body:           `{"name":"Plain"}`,
+			expectedStatus: http.StatusUnsupportedMediaType,
+		},
+		{
+			name:           "Stale Version",
+			id:             {{cookiecutter.entity_name_lower}}.ID.String(),
+			contentType:    "application/merge-patch+json",
+			ifMatch:        `"1"`,
+			body:           `{"name":"Lost Update"}`,
+			expectedStatus: http.StatusPreconditionFailed,
+		},
+		{
+			name:           "Missing If-Match",
+			id:             {{cookiecutter.entity_name_lower}}.ID.String(),
+			contentType:    "application/merge-patch+json",
+			body:           `{"name":"Lost Update"}`,
+			expectedStatus: http.StatusPreconditionRequired,
+		},
+		{
+			name:           "Not Found",
+			id:             uuid.New().String(),
+			contentType:    "application/merge-patch+json",
+			ifMatch:        `"1"`,
+			body:           `{"name":"Missing"}`,
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPatch, "/api/v1/{{cookiecutter.entity_name_lower}}/"+tt.id, strings.NewReader(tt.body))
+			req.SetPathValue("id", tt.id)
+			req.Header.Set("Content-Type", tt.contentType)
+			if tt.ifMatch != "" {
+				req.Header.Set("If-Match", tt.ifMatch)
+			}
+			w := httptest.NewRecorder()
+
+			h.HandlePatch{{cookiecutter.entity_name}}().ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if tt.expectedStatus == http.StatusUnsupportedMediaType && w.Header().Get("Accept-Patch") == "" {
+				t.Error("expected Accept-Patch header")
+			}
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+
+			var resp {{cookiecutter.entity_name}}Response
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if resp.Name != tt.expectedName {
+				t.Errorf("expected name %q, got %q", tt.expectedName, resp.Name)
+			}
+			if got := w.Header().Get("ETag"); got != tt.expectedETag {
+				t.Errorf("expected ETag %q, got %q", tt.expectedETag, got)
+			}
+		})
+	}
+}
+
 func Test{{cookiecutter.entity_name}}Handler_Delete(t *testing.T) {
 	repo := setupTestDB(t)
-	svc := service.New{{cookiecutter.entity_name}}Service(repo)
+	svc := service.New{{cookiecutter.entity_name}}Service(repo, event.NewBus(), nil)
 	h := New{{cookiecutter.entity_name}}Handler(svc)
 	ctx := context.Background()
 
@@ -309,26 +553,54 @@ func Test{{cookiecutter.entity_name}}Handler_Delete(t *testing.T) {
 	if err := repo.Create(ctx, {{cookiecutter.entity_name_lower}}); err != nil {
 		t.Fatalf("failed to create {{cookiecutter.entity_name_lower}}: %v", err)
 	}
+	edited := entity.New{{cookiecutter.entity_name}}("Edited")
+	if err := repo.Create(ctx, edited); err != nil {
+		t.Fatalf("failed to create {{cookiecutter.entity_name_lower}}: %v", err)
+	}
+	if _, err := svc.Update(ctx, edited.ID.String(), "Edited Again", edited.Version); err != nil {
+		t.Fatalf("failed to update {{cookiecutter.entity_name_lower}}: %v", err)
+	}
 
 	tests := []struct {
 		name           string
 		id             string
+		ifMatch        string
 		expectedStatus int
 	}{
 		{
 			name:           "Success",
 			id:             {{cookiecutter.entity_name_lower}}.ID.String(),
+			ifMatch:        `"1"`,
 			expectedStatus: http.StatusNoContent,
 		},
 		{
 			name:           "Invalid ID",
 			id:             "invalid-uuid",
+			ifMatch:        `"1"`,
 			expectedStatus: http.StatusBadRequest,
 		},
 		{
-			name:           "Not Found (Idempotent)",
+			name:           "Not Found",
 			id:             uuid.New().String(),
-			expectedStatus: http.StatusNoContent,
+			ifMatch:        `"1"`,
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "Already Deleted",
+			id:             {{cookiecutter.entity_name_lower}}.ID.String(),
+			ifMatch:        `"1"`,
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "Stale Version",
+			id:             edited.ID.String(),
+			ifMatch:        `"1"`,
+			expectedStatus: http.StatusPreconditionFailed,
+		},
+		{
+			name:           "Missing If-Match",
+			id:             edited.ID.String(),
+			expectedStatus: http.StatusPreconditionRequired,
 		},
 	}
 
@@ -336,6 +608,9 @@ func Test{{cookiecutter.entity_name}}Handler_Delete(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			req := httptest.NewRequest(http.MethodDelete, "/api/v1/{{cookiecutter.entity_name_lower}}/"+tt.id, nil)
 			req.SetPathValue("id", tt.id)
+			if tt.ifMatch != "" {
+				req.Header.Set("If-Match", tt.ifMatch)
+			}
 			w := httptest.NewRecorder()
 
 			h.HandleDelete{{cookiecutter.entity_name}}().ServeHTTP(w, req)
@@ -347,9 +622,108 @@ func Test{{cookiecutter.entity_name}}Handler_Delete(t *testing.T) {
 	}
 }
 
+func Test{{cookiecutter.entity_name}}Handler_Restore(t *testing.T) {
+	repo := setupTestDB(t)
+	svc := service.New{{cookiecutter.entity_name}}Service(repo, event.NewBus(), nil)
+	h := New{{cookiecutter.entity_name}}Handler(svc)
+	ctx := context.Background()
+
+	{{cookiecutter.entity_name_lower}} := entity.New{{cookiecutter.entity_name}}("To Restore")
+	if err := repo.Create(ctx, {{cookiecutter.entity_name_lower}}); err != nil {
+		t.Fatalf("failed to create {{cookiecutter.entity_name_lower}}: %v", err)
+	}
+	if err := repo.Delete(ctx, {{cookiecutter.entity_name_lower}}.ID); err != nil {
+		t.Fatalf("failed to delete {{cookiecutter.entity_name_lower}}: %v", err)
+	}
+
+	tests := []struct {
+		name           string
+		id             string
+		expectedStatus int
+	}{
+		{
+			name:           "Success",
+			id:             {{cookiecutter.entity_name_lower}}.ID.String(),
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Invalid ID",
+			id:             "invalid-uuid",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Not Found",
+			id:             uuid.New().String(),
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/{{cookiecutter.entity_name_lower}}/"+tt.id+"/restore", nil)
+			req.SetPathValue("id", tt.id)
+			w := httptest.NewRecorder()
+
+			h.HandleRestore{{cookiecutter.entity_name}}().ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+		})
+	}
+}
+
+func Test{{cookiecutter.entity_name}}Handler_Purge(t *testing.T) {
+	repo := setupTestDB(t)
+	svc := service.New{{cookiecutter.entity_name}}Service(repo, event.NewBus(), nil)
+	h := New{{cookiecutter.entity_name}}Handler(svc)
+	ctx := context.Background()
+
+	{{cookiecutter.entity_name_lower}} := entity.New{{cookiecutter.entity_name}}("To Purge")
+	if err := repo.Create(ctx, {{cookiecutter.entity_name_lower}}); err != nil {
+		t.Fatalf("failed to create {{cookiecutter.entity_name_lower}}: %v", err)
+	}
+
+	tests := []struct {
+		name           string
+		id             string
+		expectedStatus int
+	}{
+		{
+			name:           "Success",
+			id:             {{cookiecutter.entity_name_lower}}.ID.String(),
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			name:           "Invalid ID",
+			id:             "invalid-uuid",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Already Purged",
+			id:             {{cookiecutter.entity_name_lower}}.ID.String(),
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/{{cookiecutter.entity_name_lower}}/"+tt.id+"/purge", nil)
+			req.SetPathValue("id", tt.id)
+			w := httptest.NewRecorder()
+
+			h.HandlePurge{{cookiecutter.entity_name}}().ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+		})
+	}
+}
+
 func Test{{cookiecutter.entity_name}}Handler_List(t *testing.T) {
 	repo := setupTestDB(t)
-	svc := service.New{{cookiecutter.entity_name}}Service(repo)
+	svc := service.New{{cookiecutter.entity_name}}Service(repo, event.NewBus(), nil)
 	h := New{{cookiecutter.entity_name}}Handler(svc)
 	ctx := context.Background()
 
@@ -376,8 +750,11 @@ func Test{{cookiecutter.entity_name}}Handler_List(t *testing.T) {
 				if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
 					t.Fatalf("failed to decode response: %v", err)
 				}
-				if resp.Total != 10 { // Default limit is 10
-					t.Errorf("expected 10 {{cookiecutter.entity_name_lower}}, got %d", resp.Total)
+				if len(resp.{{cookiecutter.entity_name}}s) != 10 { // Default limit is 10
+					t.Errorf("expected 10 {{cookiecutter.entity_name_lower}}, got %d", len(resp.{{cookiecutter.entity_name}}s))
+				}
+				if resp.Total == nil || *resp.Total != 15 {
+					t.Errorf("expected total 15, got %v", resp.Total)
 				}
 			},
 		},
@@ -390,8 +767,39 @@ func Test{{cookiecutter.entity_name}}Handler_List(t *testing.T) {
 				if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
 					t.Fatalf("failed to decode response: %v", err)
 				}
-				if resp.Total != 5 {
-					t.Errorf("expected 5 {{cookiecutter.entity_name_lower}}, got %d", resp.Total)
+				if len(resp.{{cookiecutter.entity_name}}s) != 5 {
+					t.Errorf("expected 5 {{cookiecutter.entity_name_lower}}, got %d", len(resp.{{cookiecutter.entity_name}}s))
+				}
+				if resp.Total == nil || *resp.Total != 15 {
+					t.Errorf("expected total 15, got %v", resp.Total)
+				}
+			},
+		},
+		{
+			name:           "Limit Above Maximum",
+			query:          "?limit=1000000000",
+			expectedStatus: http.StatusOK,
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				var resp List{{cookiecutter.entity_name}}Response
+				if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+					t.Fatalf("failed to decode response: %v", err)
+				}
+				if len(resp.{{cookiecutter.entity_name}}s) != 12 {
+					t.Errorf("expected 12 {{cookiecutter.entity_name_lower}}, got %d", len(resp.{{cookiecutter.entity_name}}s))
+				}
+			},
+		},
+		{
+			name:           "Cursor Limit Above Maximum",
+			query:          "?cursor=&limit=9223372036854775807",
+			expectedStatus: http.StatusOK,
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				var resp List{{cookiecutter.entity_name}}Response
+				if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+					t.Fatalf("failed to decode response: %v", err)
+				}
+				if len(resp.{{cookiecutter.entity_name}}s) != 12 {
+					t.Errorf("expected 12 {{cookiecutter.entity_name_lower}}, got %d", len(resp.{{cookiecutter.entity_name}}s))
 				}
 			},
 		},
@@ -404,8 +812,94 @@ func Test{{cookiecutter.entity_name}}Handler_List(t *testing.T) {
 				if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
 					t.Fatalf("failed to decode response: %v", err)
 				}
-				if resp.Total != 5 { // Should get the remaining 5 (10-14)
-					t.Errorf("expected 5 {{cookiecutter.entity_name_lower}}, got %d", resp.Total)
+				if len(resp.{{cookiecutter.entity_name}}s) != 5 { // Should get the remaining 5 (10-14)
+					t.Errorf("expected 5 {{cookiecutter.entity_name_lower}}, got %d", len(resp.{{cookiecutter.entity_name}}s))
+				}
+				if resp.Total == nil || *resp.Total != 15 {
+					t.Errorf("expected total 15, got %v", resp.Total)
+				}
+			},
+		},
+		{
+			name:           "Cursor First Page",
+			query:          "?cursor=&limit=5",
+			expectedStatus: http.StatusOK,
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				var resp List{{cookiecutter.entity_name}}Response
+				if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+					t.Fatalf("failed to decode response: %v", err)
+				}
+				if len(resp.{{cookiecutter.entity_name}}s) != 5 {
+					t.Errorf("expected 5 {{cookiecutter.entity_name_lower}}, got %d", len(resp.{{cookiecutter.entity_name}}s))
+				}
+				if resp.NextCursor == "" {
+					t.Error("expected next_cursor to be set")
+				}
+				if resp.PrevCursor != "" {
+					t.Errorf("expected no prev_cursor on the first page, got %q", resp.PrevCursor)
+				}
+				if resp.Total != nil {
+					t.Errorf("expected no total in cursor mode, got %d", *resp.Total)
+				}
+			},
+		},
+		{
+			name:           "Filter And Sort",
+			query:          "?filter[name][ilike]={{cookiecutter.entity_name}}%201&sort=-name",
+			expectedStatus: http.StatusOK,
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				var resp List{{cookiecutter.entity_name}}Response
+				if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+					t.Fatalf("failed to decode response: %v", err)
+				}
+				// {{cookiecutter.entity_name}} 1 and {{cookiecutter.entity_name}} 10 to 14
+				if resp.Total == nil || *resp.Total != 6 {
+					t.Fatalf("expected total 6, got %v", resp.Total)
+				}
+				if resp.{{cookiecutter.entity_name}}s[0].Name != "{{cookiecutter.entity_name}} 14" {
+					t.Errorf("expected first name %q, got %q", "{{cookiecutter.entity_name}} 14", resp.{{cookiecutter.entity_name}}s[0].Name)
+				}
+			},
+		},
+		{
+			name:           "Unknown Filter Field",
+			query:          "?filter[password]=secret",
+			expectedStatus: http.StatusBadRequest,
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				var resp problem.Problem
+				if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+					t.Fatalf("failed to decode response: %v", err)
+				}
+				if len(resp.Errors) != 1 || resp.Errors[0].Field != "filter[password]" {
+					t.Errorf("expected a field error for %q, got %+v", "filter[password]", resp.Errors)
+				}
+			},
+		},
+		{
+			name:           "Sort With Cursor",
+			query:          "?cursor=&sort=name",
+			expectedStatus: http.StatusBadRequest,
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				var resp problem.Problem
+				if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+					t.Fatalf("failed to decode response: %v", err)
+				}
+				if !strings.Contains(resp.Detail, "sort") {
+					t.Errorf("expected error to name %q, got %q", "sort", resp.Detail)
+				}
+			},
+		},
+		{
+			name:           "Invalid Cursor",
+			query:          "?cursor=not-a-cursor",
+			expectedStatus: http.StatusBadRequest,
+			checkResponse: func(t *testing.T, w *httptest.ResponseRecorder) {
+				var resp problem.Problem
+				if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+					t.Fatalf("failed to decode response: %v", err)
+				}
+				if resp.Detail != "invalid cursor" {
+					t.Errorf("expected error %q, got %q", "invalid cursor", resp.Detail)
 				}
 			},
 		},
@@ -416,7 +910,7 @@ func Test{{cookiecutter.entity_name}}Handler_List(t *testing.T) {
 			req := httptest.NewRequest(http.MethodGet, "/api/v1/{{cookiecutter.entity_name_lower}}"+tt.query, nil)
 			w := httptest.NewRecorder()
 
-			h.HandleList{{cookiecutter.entity_name}}().ServeHTTP(w, req)
+			h.HandleList{{cookiecutter.entity_name}}(12).ServeHTTP(w, req)
 
 			if w.Code != tt.expectedStatus {
 				t.Errorf("expected status %d, got %d", tt.expectedStatus, w.Code)
@@ -428,3 +922,528 @@ func Test{{cookiecutter.entity_name}}Handler_List(t *testing.T) {
 		})
 	}
 }
+
+func Test{{cookiecutter.entity_name}}Handler_CSV(t *testing.T) {
+	repo := setupTestDB(t)
+	svc := service.New{{cookiecutter.entity_name}}Service(repo, event.NewBus(), nil)
+	h := New{{cookiecutter.entity_name}}Handler(svc)
+	{{cookiecutter.entity_name_lower}} := entity.New{{cookiecutter.entity_name}}("Exported")
+	if err := repo.Create(context.Background(), {{cookiecutter.entity_name_lower}}); err != nil {
+		t.Fatalf("failed to create {{cookiecutter.entity_name_lower}}: %v", err)
+	}
+
+	t.Run("List", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/{{cookiecutter.entity_name_lower}}", nil)
+		req.Header.Set("Accept", "text/csv")
+		w := httptest.NewRecorder()
+		h.HandleList{{cookiecutter.entity_name}}(0).ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+		if got := w.Header().Get("Content-Type"); got != "text/csv" {
+			t.Errorf("expected Content-Type %q, got %q", "text/csv", got)
+		}
+		lines := strings.Split(strings.TrimSpace(w.Body.String()), "\n")
+		if len(lines) != 2 || lines[0] != "id,name,created_at,updated_at,version,created_by" {
+			t.Fatalf("expected a header and one row, got %q", w.Body.String())
+		}
+		if !strings.HasPrefix(lines[1], {{cookiecutter.entity_name_lower}}.ID.String()+",Exported,") {
+			t.Errorf("unexpected row %q", lines[1])
+		}
+	})
+
+	t.Run("Single {{cookiecutter.entity_name}} Not Acceptable", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/{{cookiecutter.entity_name_lower}}/"+{{cookiecutter.entity_name_lower}}.ID.String(), nil)
+		req.SetPathValue("id", {{cookiecutter.entity_name_lower}}.ID.String())
+		req.Header.Set("Accept", "text/csv")
+		w := httptest.NewRecorder()
+		h.HandleGet{{cookiecutter.entity_name}}().ServeHTTP(w, req)
+
+		if w.Code != http.StatusNotAcceptable {
+			t.Errorf("expected status %d, got %d: %s", http.StatusNotAcceptable, w.Code, w.Body.String())
+		}
+		if got := w.Header().Get("Content-Type"); got != problem.ContentType {
+			t.Errorf("expected Content-Type %q, got %q", problem.ContentType, got)
+		}
+	})
+}
+
+func Test{{cookiecutter.entity_name}}Handler_Export(t *testing.T) {
+	repo := setupTestDB(t)
+	svc := service.New{{cookiecutter.entity_name}}Service(repo, event.NewBus(), nil)
+	h := New{{cookiecutter.entity_name}}Handler(svc)
+	// exports are ordered by creation time
+	start := time.Now().Add(-time.Hour)
+	for i, name := range []string{"Alpha", "Beta", "Gamma"} {
+		{{cookiecutter.entity_name_lower}} := entity.New{{cookiecutter.entity_name}}(name)
+		{{cookiecutter.entity_name_lower}}.CreatedAt = start.Add(time.Duration(i) * time.Minute)
+		if err := repo.Create(context.Background(), {{cookiecutter.entity_name_lower}}); err != nil {
+			t.Fatalf("failed to create {{cookiecutter.entity_name_lower}}: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name            string
+		query           string
+		accept          string
+		wantStatus      int
+		wantContentType string
+		wantLines       []string
+	}{
+		{
+			name:            "NDJSON By Default",
+			wantStatus:      http.StatusOK,
+			wantContentType: "application/x-ndjson",
+			wantLines:       []string{"Alpha", "Beta", "Gamma"},
+		},
+		{
+			name:            "CSV",
+			accept:          "text/csv",
+			wantStatus:      http.StatusOK,
+			wantContentType: "text/csv",
+			wantLines:       []string{"id,name,created_at,updated_at,version,created_by", "Alpha", "Beta", "Gamma"},
+		},
+		{
+			name:            "Filtered",
+			query:           "?filter[name]=Beta",
+			wantStatus:      http.StatusOK,
+			wantContentType: "application/x-ndjson",
+			wantLines:       []string{"Beta"},
+		},
+		{
+			name:            "Sort Rejected",
+			query:           "?sort=name",
+			wantStatus:      http.StatusBadRequest,
+			wantContentType: problem.ContentType,
+		},
+		{
+			name:            "Not Acceptable",
+			accept:          "application/json",
+			wantStatus:      http.StatusNotAcceptable,
+			wantContentType: problem.ContentType,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/{{cookiecutter.entity_name_lower}}/export"+tt.query, nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			w := httptest.NewRecorder()
+			h.HandleExport{{cookiecutter.entity_name}}().ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+			if got := w.Header().Get("Content-Type"); got != tt.wantContentType {
+				t.Errorf("expected Content-Type %q, got %q", tt.wantContentType, got)
+			}
+			if tt.wantLines == nil {
+				return
+			}
+
+			lines := strings.Split(strings.TrimSpace(w.Body.String()), "\n")
+			if len(lines) != len(tt.wantLines) {
+				t.Fatalf("expected %d lines, got %q", len(tt.wantLines), w.Body.String())
+			}
+			for i, line := range lines {
+				if !strings.Contains(line, tt.wantLines[i]) {
+					t.Errorf("line %d = %q, want it to contain %q", i, line, tt.wantLines[i])
+				}
+			}
+			if tt.wantContentType == "application/x-ndjson" {
+				var resp {{cookiecutter.entity_name}}Response
+				if err := json.Unmarshal([]byte(lines[0]), &resp); err != nil || resp.Name != tt.wantLines[0] {
+					t.Errorf("expected line 0 to decode to {{cookiecutter.entity_name_lower}} %q, got %+v (%v)", tt.wantLines[0], resp, err)
+				}
+			}
+		})
+	}
+}
+
+func Test{{cookiecutter.entity_name}}Handler_Import(t *testing.T) {
+	tests := []struct {
+		name         string
+		query        string
+		contentType  string
+		body         string
+		wantStatus   int
+		wantCreated  int
+		wantStatuses []string
+		checkRows    func(t *testing.T, rows []ImportRowResult)
+	}{
+		{
+			name:         "NDJSON",
+			contentType:  "application/x-ndjson",
+			body:         "{\"name\":\"A\"}\n{\"name\":\"B\"}\n",
+			wantStatus:   http.StatusOK,
+			wantCreated:  2,
+			wantStatuses: []string{"created", "created"},
+		},
+		{
+			name:         "CSV",
+			contentType:  "text/csv",
+			body:         "name\nA\nB\nC\n",
+			wantStatus:   http.StatusOK,
+			wantCreated:  3,
+			wantStatuses: []string{"created", "created", "created"},
+		},
+		{
+			name:         "Atomic With Rejected Rows",
+			contentType:  "application/x-ndjson",
+			body:         "{\"name\":\"A\"}\n{\"name\":\"\"}\n",
+			wantStatus:   http.StatusUnprocessableEntity,
+			wantCreated:  0,
+			wantStatuses: []string{"rejected", "rejected"},
+			checkRows: func(t *testing.T, rows []ImportRowResult) {
+				if rows[0].Reason != service.ErrImportAborted.Error() {
+					t.Errorf("row 1 reason = %q, want %q", rows[0].Reason, service.ErrImportAborted.Error())
+				}
+				if len(rows[1].Errors) != 1 || rows[1].Errors[0].Field != "name" {
+					t.Errorf("row 2 errors = %+v, want one for name", rows[1].Errors)
+				}
+			},
+		},
+		{
+			name:         "Best Effort",
+			query:        "?mode=best-effort",
+			contentType:  "application/x-ndjson",
+			body:         "{\"name\":\"A\"}\n{\"name\":\"\"}\nnot json\n{\"name\":\"D\",\"owner\":\"x\"}\n{\"name\":\"E\"}\n",
+			wantStatus:   http.StatusOK,
+			wantCreated:  2,
+			wantStatuses: []string{"created", "rejected", "rejected", "rejected", "created"},
+			checkRows: func(t *testing.T, rows []ImportRowResult) {
+				for _, row := range rows[2:4] {
+					if !strings.HasPrefix(row.Reason, "invalid request body") {
+						t.Errorf("row %d reason = %q, want an invalid body", row.Row, row.Reason)
+					}
+				}
+				if rows[4].Row != 5 || rows[4].ID == "" {
+					t.Errorf("row 5 = %+v, want a created {{cookiecutter.entity_name_lower}}", rows[4])
+				}
+			},
+		},
+		{
+			name:        "Unknown Mode",
+			query:       "?mode=some",
+			contentType: "application/x-ndjson",
+			body:        "{\"name\":\"A\"}\n",
+			wantStatus:  http.StatusBadRequest,
+			wantCreated: 0,
+		},
+		{
+			name:        "Unsupported Content Type",
+			contentType: "application/json",
+			body:        "{\"name\":\"A\"}\n",
+			wantStatus:  http.StatusUnsupportedMediaType,
+			wantCreated: 0,
+		},
+		{
+			name:        "No Rows",
+			contentType: "application/x-ndjson",
+			body:        "\n",
+			wantStatus:  http.StatusBadRequest,
+			wantCreated: 0,
+		},
+		{
+			name:        "No Content Type",
+			body:        "{\"name\":\"A\"}\n",
+			wantStatus:  http.StatusUnsupportedMediaType,
+			wantCreated: 0,
+		},
+		{
+			name:        "Unknown CSV Column",
+			contentType: "text/csv",
+			body:        "name,owner\nA,x\n",
+			wantStatus:  http.StatusBadRequest,
+			wantCreated: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := setupTestDB(t)
+			svc := service.New{{cookiecutter.entity_name}}Service(repo, event.NewBus(), nil)
+			h := New{{cookiecutter.entity_name}}Handler(svc)
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/{{cookiecutter.entity_name_lower}}/import"+tt.query, strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			w := httptest.NewRecorder()
+			h.HandleImport{{cookiecutter.entity_name}}().ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+			_, total, err := repo.ListWithCount(context.Background(), 100, 0, nil)
+			if err != nil {
+				t.Fatalf("failed to count {{cookiecutter.entity_name_lower}}s: %v", err)
+			}
+			if int(total) != tt.wantCreated {
+				t.Errorf("expected %d {{cookiecutter.entity_name_lower}}s, got %d", tt.wantCreated, total)
+			}
+			if tt.wantStatuses == nil {
+				return
+			}
+
+			var resp Import{{cookiecutter.entity_name}}Response
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if resp.Created != tt.wantCreated || resp.Created+resp.Rejected != len(tt.wantStatuses) {
+				t.Errorf("expected %d created of %d, got %+v", tt.wantCreated, len(tt.wantStatuses), resp)
+			}
+			if len(resp.Rows) != len(tt.wantStatuses) {
+				t.Fatalf("expected %d rows, got %+v", len(tt.wantStatuses), resp.Rows)
+			}
+			for i, row := range resp.Rows {
+				if row.Row != i+1 || row.Status != tt.wantStatuses[i] {
+					t.Errorf("row %d = %+v, want status %q", i+1, row, tt.wantStatuses[i])
+				}
+			}
+			if tt.checkRows != nil {
+				tt.checkRows(t, resp.Rows)
+			}
+		})
+	}
+}
+
+func Test{{cookiecutter.entity_name}}Handler_Batch(t *testing.T) {
+	tests := []struct {
+		name         string
+		query        string
+		ops          func(id string) []BatchOperationRequest
+		wantStatus   int
+		wantStatuses []int
+		wantName     string
+		wantField    string
+	}{
+		{
+			name: "Atomic",
+			ops: func(id string) []BatchOperationRequest {
+				return []BatchOperationRequest{
+					{Op: "create", Name: "Created"},
+					{Op: "update", ID: id, Name: "Renamed", Version: 1},
+				}
+			},
+			wantStatus:   http.StatusOK,
+			wantStatuses: []int{http.StatusCreated, http.StatusOK},
+			wantName:     "Renamed",
+		},
+		{
+			name: "Atomic With Failed Operation",
+			ops: func(id string) []BatchOperationRequest {
+				return []BatchOperationRequest{
+					{Op: "update", ID: id, Name: "Renamed", Version: 1},
+					{Op: "delete", ID: id, Version: 5},
+				}
+			},
+			wantStatus:   http.StatusUnprocessableEntity,
+			wantStatuses: []int{http.StatusFailedDependency, http.StatusPreconditionFailed},
+			wantName:     "Kept",
+		},
+		{
+			name:  "Best Effort",
+			query: "?mode=best-effort",
+			ops: func(id string) []BatchOperationRequest {
+				return []BatchOperationRequest{
+					{Op: "create", Name: ""},
+					{Op: "delete", ID: id, Version: 1},
+				}
+			},
+			wantStatus:   http.StatusOK,
+			wantStatuses: []int{http.StatusUnprocessableEntity, http.StatusNoContent},
+		},
+		{
+			name: "Unknown Operation",
+			ops: func(id string) []BatchOperationRequest {
+				return []BatchOperationRequest{
+					{Op: "upsert", ID: id},
+				}
+			},
+			wantStatus: http.StatusUnprocessableEntity,
+			wantName:   "Kept",
+			wantField:  "operations[0].op",
+		},
+		{
+			name: "Missing Version",
+			ops: func(id string) []BatchOperationRequest {
+				return []BatchOperationRequest{
+					{Op: "delete", ID: id},
+				}
+			},
+			wantStatus: http.StatusUnprocessableEntity,
+			wantName:   "Kept",
+			wantField:  "operations[0].version",
+		},
+		{
+			name: "Too Many Operations",
+			ops: func(id string) []BatchOperationRequest {
+				return []BatchOperationRequest{
+					{Op: "create", Name: "A"},
+					{Op: "create", Name: "B"},
+					{Op: "create", Name: "C"},
+				}
+			},
+			wantStatus: http.StatusUnprocessableEntity,
+			wantName:   "Kept",
+			wantField:  "operations",
+		},
+		{
+			name: "No Operations",
+			ops: func(id string) []BatchOperationRequest {
+				return nil
+			},
+			wantStatus: http.StatusUnprocessableEntity,
+			wantName:   "Kept",
+			wantField:  "operations",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := setupTestDB(t)
+			svc := service.New{{cookiecutter.entity_name}}Service(repo, event.NewBus(), nil)
+			h := New{{cookiecutter.entity_name}}Handler(svc)
+			kept := entity.New{{cookiecutter.entity_name}}("Kept")
+			if err := repo.Create(context.Background(), kept); err != nil {
+				t.Fatalf("failed to create {{cookiecutter.entity_name_lower}}: %v", err)
+			}
+
+			body, _ := json.Marshal(Batch{{cookiecutter.entity_name}}Request{Operations: tt.ops(kept.ID.String())})
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/{{cookiecutter.entity_name_lower}}:batch"+tt.query, bytes.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			h.HandleBatch{{cookiecutter.entity_name}}(2).ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+
+			if tt.wantField != "" {
+				var resp problem.Problem
+				if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+					t.Fatalf("failed to decode response: %v", err)
+				}
+				if len(resp.Errors) != 1 || resp.Errors[0].Field != tt.wantField {
+					t.Errorf("expected a field error for %q, got %+v", tt.wantField, resp.Errors)
+				}
+			} else {
+				var resp Batch{{cookiecutter.entity_name}}Response
+				if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+					t.Fatalf("failed to decode response: %v", err)
+				}
+				if len(resp.Results) != len(tt.wantStatuses) {
+					t.Fatalf("expected %d results, got %+v", len(tt.wantStatuses), resp.Results)
+				}
+				for i, result := range resp.Results {
+					if result.Status != tt.wantStatuses[i] {
+						t.Errorf("result %d status = %d, want %d: %+v", i, result.Status, tt.wantStatuses[i], result.Error)
+					}
+					if (result.Error != nil) != (result.Status >= 400) {
+						t.Errorf("result %d = %+v, want an error exactly when it failed", i, result)
+					}
+				}
+			}
+
+			got, err := repo.GetByID(context.Background(), kept.ID)
+			if tt.wantName == "" {
+				if err == nil {
+					t.Errorf("expected {{cookiecutter.entity_name_lower}} to be deleted, got %+v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed to get {{cookiecutter.entity_name_lower}}: %v", err)
+			}
+			if got.Name != tt.wantName {
+				t.Errorf("expected name %q, got %q", tt.wantName, got.Name)
+			}
+		})
+	}
+}
+
+func Test{{cookiecutter.entity_name}}Handler_Forbidden(t *testing.T) {
+	repo := setupTestDB(t)
+	denyAll := func(ctx context.Context, action service.Action, {{cookiecutter.entity_name_lower}} *entity.{{cookiecutter.entity_name}}) error {
+		return service.ErrForbidden
+	}
+	svc := service.New{{cookiecutter.entity_name}}Service(repo, event.NewBus(), denyAll)
+	h := New{{cookiecutter.entity_name}}Handler(svc)
+	ctx := context.Background()
+
+	{{cookiecutter.entity_name_lower}} := entity.New{{cookiecutter.entity_name}}("Guarded")
+	if err := repo.Create(ctx, {{cookiecutter.entity_name_lower}}); err != nil {
+		t.Fatalf("failed to create {{cookiecutter.entity_name_lower}}: %v", err)
+	}
+	deleted := entity.New{{cookiecutter.entity_name}}("Deleted")
+	if err := repo.Create(ctx, deleted); err != nil {
+		t.Fatalf("failed to create {{cookiecutter.entity_name_lower}}: %v", err)
+	}
+	if err := repo.DeleteIfVersion(ctx, deleted.ID, deleted.Version); err != nil {
+		t.Fatalf("failed to delete {{cookiecutter.entity_name_lower}}: %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		method      string
+		id          string
+		body        string
+		contentType string
+		handler     http.Handler
+	}{
+		{
+			name:        "Update",
+			method:      http.MethodPut,
+			id:          {{cookiecutter.entity_name_lower}}.ID.String(),
+			body:        `{"name":"Renamed"}`,
+			contentType: "application/json",
+			handler:     h.HandleUpdate{{cookiecutter.entity_name}}(),
+		},
+		{
+			name:        "Patch",
+			method:      http.MethodPatch,
+			id:          {{cookiecutter.entity_name_lower}}.ID.String(),
+			body:        `{"name":"Patched"}`,
+			contentType: "application/merge-patch+json",
+			handler:     h.HandlePatch{{cookiecutter.entity_name}}(),
+		},
+		{
+			name:    "Delete",
+			method:  http.MethodDelete,
+			id:      {{cookiecutter.entity_name_lower}}.ID.String(),
+			handler: h.HandleDelete{{cookiecutter.entity_name}}(),
+		},
+		{
+			name:    "Restore",
+			method:  http.MethodPost,
+			id:      deleted.ID.String(),
+			handler: h.HandleRestore{{cookiecutter.entity_name}}(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/api/v1/{{cookiecutter.entity_name_lower}}/"+tt.id, strings.NewReader(tt.body))
+			req.SetPathValue("id", tt.id)
+			req.Header.Set("If-Match", `"1"`)
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			w := httptest.NewRecorder()
+
+			tt.handler.ServeHTTP(w, req)
+
+			if w.Code != http.StatusForbidden {
+				t.Errorf("expected status %d, got %d", http.StatusForbidden, w.Code)
+			}
+			var resp problem.Problem
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil || resp.Detail != "access denied" {
+				t.Errorf("expected error body %q, got %q (%v)", "access denied", resp.Detail, err)
+			}
+		})
+	}
+}
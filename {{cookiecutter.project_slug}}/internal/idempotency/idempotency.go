@@ -0,0 +1,47 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+)
+
+// DefaultTTL is how long responses are kept for replay when no other TTL is
+// configured.
+const DefaultTTL = 24 * time.Hour
+
+// lockTimeout is how long a key stays claimed by a request that has not
+// completed. A request still running after that is presumed dead, so a
+// crashed instance does not block the key until the TTL is over.
+const lockTimeout = 5 * time.Minute
+
+var (
+	// ErrInProgress is returned by Begin while another request holds the key.
+	ErrInProgress = errors.New("a request with this idempotency key is in progress")
+	// ErrMismatch is returned by Begin when the key was used for a request
+	// with another fingerprint.
+	ErrMismatch = errors.New("idempotency key was used for a different request")
+)
+
+// Response is a response kept for replay.
+type Response struct {
+	Status int
+	Header http.Header
+	Body   []byte
+}
+
+// Store keeps the responses of requests by their idempotency key.
+type Store interface {
+	// Begin claims key for a request with fingerprint. It returns nil when
+	// the key is new, the stored response when a request with the same
+	// fingerprint completed already, ErrInProgress when one is still running
+	// and ErrMismatch when the fingerprint differs.
+	Begin(ctx context.Context, key, fingerprint string) (*Response, error)
+	// Complete stores the response of the request that claimed key and keeps
+	// it for ttl.
+	Complete(ctx context.Context, key string, resp Response, ttl time.Duration) error
+	// Release gives up the claim on key without storing a response, so the
+	// request can be retried.
+	Release(ctx context.Context, key string) error
+}
@@ -0,0 +1,138 @@
+package idempotency
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// maxRetries is how often Begin retries when the key expired and was claimed
+// or deleted by another request in between.
+const maxRetries = 3
+
+// sweepInterval is how often each instance deletes expired keys.
+const sweepInterval = time.Minute
+
+// Record is an idempotency key stored in the idempotency_key table. Status
+// is zero while the request that claimed the key is in progress, Header
+// holds the headers of the response as JSON.
+type Record struct {
+	ID          string `gorm:"primaryKey"`
+	Fingerprint string `gorm:"not null"`
+	Status      int    `gorm:"not null"`
+	Header      []byte
+	Body        []byte
+	CreatedAt   time.Time `gorm:"not null"`
+	ExpiresAt   time.Time `gorm:"not null;index"`
+}
+
+func (Record) TableName() string {
+	return "idempotency_key"
+}
+
+// PostgresStore keeps idempotency keys in the database, so a retry is
+// recognized whichever instance serves it. The primary key on ID decides
+// which of two concurrent requests claims a key.
+type PostgresStore struct {
+	db    *gorm.DB
+	mu    sync.Mutex
+	swept time.Time
+	now   func() time.Time
+}
+
+func NewPostgresStore(db *gorm.DB) *PostgresStore {
+	return &PostgresStore{db: db, now: time.Now}
+}
+
+func (s *PostgresStore) Begin(ctx context.Context, key, fingerprint string) (*Response, error) {
+	s.sweep(ctx)
+
+	for range maxRetries {
+		now := s.now()
+
+		// an expired key is claimed like a new one
+		err := s.db.WithContext(ctx).Where("id = ? AND expires_at <= ?", key, now).Delete(&Record{}).Error
+		if err != nil {
+			return nil, fmt.Errorf("delete expired idempotency key: %w", err)
+		}
+		row := Record{ID: key, Fingerprint: fingerprint, CreatedAt: now, ExpiresAt: now.Add(lockTimeout)}
+		tx := s.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&row)
+		if tx.Error != nil {
+			return nil, fmt.Errorf("claim idempotency key: %w", tx.Error)
+		}
+		if tx.RowsAffected == 1 {
+			return nil, nil
+		}
+
+		var existing Record
+		err = s.db.WithContext(ctx).Where("id = ?", key).Take(&existing).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("get idempotency key: %w", err)
+		}
+		if existing.Fingerprint != fingerprint {
+			return nil, ErrMismatch
+		}
+		if existing.Status == 0 {
+			return nil, ErrInProgress
+		}
+
+		resp := &Response{Status: existing.Status, Body: existing.Body}
+		if err := json.Unmarshal(existing.Header, &resp.Header); err != nil {
+			return nil, fmt.Errorf("decode stored header: %w", err)
+		}
+		return resp, nil
+	}
+	return nil, ErrInProgress
+}
+
+func (s *PostgresStore) Complete(ctx context.Context, key string, resp Response, ttl time.Duration) error {
+	header, err := json.Marshal(resp.Header)
+	if err != nil {
+		return fmt.Errorf("encode header: %w", err)
+	}
+	err = s.db.WithContext(ctx).Model(&Record{}).Where("id = ?", key).Updates(map[string]any{
+		"status":     resp.Status,
+		"header":     header,
+		"body":       resp.Body,
+		"expires_at": s.now().Add(ttl),
+	}).Error
+	if err != nil {
+		return fmt.Errorf("store idempotent response: %w", err)
+	}
+	return nil
+}
+
+func (s *PostgresStore) Release(ctx context.Context, key string) error {
+	err := s.db.WithContext(ctx).Where("id = ? AND status = 0", key).Delete(&Record{}).Error
+	if err != nil {
+		return fmt.Errorf("release idempotency key: %w", err)
+	}
+	return nil
+}
+
+// sweep deletes expired keys. Each instance sweeps at most once per
+// sweepInterval.
+func (s *PostgresStore) sweep(ctx context.Context) {
+	s.mu.Lock()
+	now := s.now()
+	if now.Sub(s.swept) < sweepInterval {
+		s.mu.Unlock()
+		return
+	}
+	s.swept = now
+	s.mu.Unlock()
+
+	// best effort, a failed sweep is retried next interval
+	s.db.WithContext(ctx).Where("expires_at <= ?", now).Delete(&Record{})
+}
+
+var _ Store = (*PostgresStore)(nil)
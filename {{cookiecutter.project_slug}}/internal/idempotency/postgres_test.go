@@ -0,0 +1,130 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+
+	"{{cookiecutter.module_name}}/internal/db"
+)
+
+func setupTestDB(t *testing.T) *gorm.DB {
+	db, err := db.MakeDbSqlite()
+	if err != nil {
+		t.Fatalf("failed to connect database: %v", err)
+	}
+
+	if err := db.AutoMigrate(&Record{}); err != nil {
+		t.Fatalf("failed to migrate database: %v", err)
+	}
+
+	return db
+}
+
+func TestPostgresStore(t *testing.T) {
+	store := NewPostgresStore(setupTestDB(t))
+	now := time.Now()
+	store.now = func() time.Time { return now }
+	ctx := context.Background()
+	resp := Response{Status: http.StatusCreated, Header: http.Header{"Location": {"/a/1"}}, Body: []byte(`{"id":"1"}`)}
+
+	if got, err := store.Begin(ctx, "a", "fp"); err != nil || got != nil {
+		t.Fatalf("Begin() of a new key = %v, %v, want nil, nil", got, err)
+	}
+	if _, err := store.Begin(ctx, "a", "fp"); !errors.Is(err, ErrInProgress) {
+		t.Errorf("Begin() while in progress error = %v, want ErrInProgress", err)
+	}
+	if _, err := store.Begin(ctx, "a", "other"); !errors.Is(err, ErrMismatch) {
+		t.Errorf("Begin() with another fingerprint error = %v, want ErrMismatch", err)
+	}
+
+	if err := store.Complete(ctx, "a", resp, time.Hour); err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+	got, err := store.Begin(ctx, "a", "fp")
+	if err != nil {
+		t.Fatalf("Begin() of a completed key error = %v", err)
+	}
+	if !reflect.DeepEqual(*got, resp) {
+		t.Errorf("Begin() of a completed key = %+v, want %+v", *got, resp)
+	}
+	if _, err := store.Begin(ctx, "a", "other"); !errors.Is(err, ErrMismatch) {
+		t.Errorf("Begin() of a completed key with another fingerprint error = %v, want ErrMismatch", err)
+	}
+
+	// the response expires with its ttl, the key can then be used again
+	now = now.Add(time.Hour)
+	if got, err := store.Begin(ctx, "a", "other"); err != nil || got != nil {
+		t.Errorf("Begin() of an expired key = %v, %v, want nil, nil", got, err)
+	}
+}
+
+func TestPostgresStore_Release(t *testing.T) {
+	store := NewPostgresStore(setupTestDB(t))
+	ctx := context.Background()
+
+	if _, err := store.Begin(ctx, "a", "fp"); err != nil {
+		t.Fatalf("Begin() error = %v", err)
+	}
+	if err := store.Release(ctx, "a"); err != nil {
+		t.Fatalf("Release() error = %v", err)
+	}
+	if got, err := store.Begin(ctx, "a", "other"); err != nil || got != nil {
+		t.Errorf("Begin() of a released key = %v, %v, want nil, nil", got, err)
+	}
+
+	// a completed key is not released
+	if err := store.Complete(ctx, "a", Response{Status: http.StatusOK}, time.Hour); err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+	if err := store.Release(ctx, "a"); err != nil {
+		t.Fatalf("Release() error = %v", err)
+	}
+	if got, err := store.Begin(ctx, "a", "fp"); got != nil || !errors.Is(err, ErrMismatch) {
+		t.Errorf("Begin() after releasing a completed key = %v, %v, want ErrMismatch", got, err)
+	}
+}
+
+func TestPostgresStore_LockTimeout(t *testing.T) {
+	store := NewPostgresStore(setupTestDB(t))
+	now := time.Now()
+	store.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	if _, err := store.Begin(ctx, "a", "fp"); err != nil {
+		t.Fatalf("Begin() error = %v", err)
+	}
+	now = now.Add(lockTimeout)
+	if got, err := store.Begin(ctx, "a", "fp"); err != nil || got != nil {
+		t.Errorf("Begin() after the lock timeout = %v, %v, want nil, nil", got, err)
+	}
+}
+
+func TestPostgresStore_Sweep(t *testing.T) {
+	db := setupTestDB(t)
+	store := NewPostgresStore(db)
+	now := time.Now()
+	store.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	if _, err := store.Begin(ctx, "a", "fp"); err != nil {
+		t.Fatalf("Begin() error = %v", err)
+	}
+	now = now.Add(lockTimeout)
+	if _, err := store.Begin(ctx, "b", "fp"); err != nil {
+		t.Fatalf("Begin() error = %v", err)
+	}
+
+	var ids []string
+	if err := db.Model(&Record{}).Pluck("id", &ids).Error; err != nil {
+		t.Fatalf("failed to list keys: %v", err)
+	}
+	if len(ids) != 1 || ids[0] != "b" {
+		t.Errorf("keys = %v, want only b after sweep", ids)
+	}
+}
@@ -7,6 +7,8 @@ import (
 	"os"
 	"strconv"
 
+	"go.opentelemetry.io/otel/trace"
+
 	"{{cookiecutter.module_name}}/internal/version"
 )
 
@@ -16,14 +18,32 @@ type contextKey string
 const (
 	buildKey         string     = "build"
 	loggerKey        contextKey = "logger"
+	correlationKey   contextKey = "correlation_id"
 	correlationIDKey string     = "correlation_id"
 	branchKey        string     = "branch"
 	pathKey          string     = "path"
 	methodKey        string     = "method"
 	statusCodeKey    string     = "status_code"
 	portKey          string     = "port"
+	subjectKey       string     = "subject"
+
+	// the fields Cloud Logging links log entries to traces by
+	traceKey        string = "logging.googleapis.com/trace"
+	spanIDKey       string = "logging.googleapis.com/spanId"
+	traceSampledKey string = "logging.googleapis.com/trace_sampled"
 )
 
+// traceProject is the Google Cloud project traces are stored in, see
+// SetTraceProject.
+var traceProject string
+
+// SetTraceProject sets the project trace IDs are logged for, so Cloud
+// Logging can link entries to their trace. It should be called once at
+// startup, before anything is logged with WithTrace.
+func SetTraceProject(projectID string) {
+	traceProject = projectID
+}
+
 // Init initializes the global logger with JSON output.
 // This should be called once at application startup.
 func Init(version version.Version) {
@@ -55,6 +75,36 @@ func WithRequestInfo(ctx context.Context, r *http.Request) *slog.Logger {
 	return logger.With(slog.String(pathKey, r.URL.Path), slog.String(methodKey, r.Method))
 }
 
+// WithSubject creates a new logger with the authenticated subject attached.
+func WithSubject(ctx context.Context, subject string) *slog.Logger {
+	logger := FromContext(ctx)
+	return logger.With(slog.String(subjectKey, subject))
+}
+
+// WithTrace creates a new logger with the trace and span IDs of the span in
+// ctx attached. The logger in ctx is returned as is outside of a trace.
+func WithTrace(ctx context.Context) *slog.Logger {
+	return FromContext(ctx).With(TraceAttrs(ctx)...)
+}
+
+// TraceAttrs returns the trace fields of the span in ctx in the form Cloud
+// Logging expects, none outside of a trace.
+func TraceAttrs(ctx context.Context) []any {
+	spanContext := trace.SpanContextFromContext(ctx)
+	if !spanContext.IsValid() {
+		return nil
+	}
+	traceID := spanContext.TraceID().String()
+	if traceProject != "" {
+		traceID = "projects/" + traceProject + "/traces/" + traceID
+	}
+	return []any{
+		slog.String(traceKey, traceID),
+		slog.String(spanIDKey, spanContext.SpanID().String()),
+		slog.Bool(traceSampledKey, spanContext.IsSampled()),
+	}
+}
+
 func WithResponseInfo(ctx context.Context, statusCode int) *slog.Logger {
 	logger := FromContext(ctx)
 	return logger.With(slog.String(statusCodeKey, strconv.Itoa(statusCode)))
@@ -73,3 +123,16 @@ func FromContext(ctx context.Context) *slog.Logger {
 	}
 	return slog.Default()
 }
+
+// CorrelationIDToContext adds the request's correlation ID to the context so
+// work started by the request, such as events, can carry it along.
+func CorrelationIDToContext(ctx context.Context, correlationID string) context.Context {
+	return context.WithValue(ctx, correlationKey, correlationID)
+}
+
+// CorrelationIDFromContext retrieves the correlation ID from the context.
+// It returns an empty string outside of a request.
+func CorrelationIDFromContext(ctx context.Context) string {
+	correlationID, _ := ctx.Value(correlationKey).(string)
+	return correlationID
+}
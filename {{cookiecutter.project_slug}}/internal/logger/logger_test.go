@@ -3,12 +3,15 @@ package logger
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"log/slog"
 	"net/http"
 	"net/url"
 	"strconv"
 	"testing"
 
+	"go.opentelemetry.io/otel/trace"
+
 	"{{cookiecutter.module_name}}/internal/version"
 )
 
@@ -98,6 +101,69 @@ func TestWithRequestInfo(t *testing.T) {
 	}
 }
 
+func TestWithTrace(t *testing.T) {
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	spanContext := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: trace.FlagsSampled,
+	})
+
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		project string
+		want    map[string]any
+	}{
+		{
+			name: "outside of a trace",
+			ctx:  context.Background(),
+			want: map[string]any{},
+		},
+		{
+			name: "without project",
+			ctx:  trace.ContextWithSpanContext(context.Background(), spanContext),
+			want: map[string]any{
+				traceKey:        "4bf92f3577b34da6a3ce929d0e0e4736",
+				spanIDKey:       "00f067aa0ba902b7",
+				traceSampledKey: true,
+			},
+		},
+		{
+			name:    "with project",
+			ctx:     trace.ContextWithSpanContext(context.Background(), spanContext),
+			project: "my-project",
+			want: map[string]any{
+				traceKey:        "projects/my-project/traces/4bf92f3577b34da6a3ce929d0e0e4736",
+				spanIDKey:       "00f067aa0ba902b7",
+				traceSampledKey: true,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetTraceProject(tt.project)
+			defer SetTraceProject("")
+
+			var buf bytes.Buffer
+			ctx := ToContext(tt.ctx, slog.New(slog.NewJSONHandler(&buf, nil)))
+			WithTrace(ctx).Info("test message")
+
+			var record map[string]any
+			if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+				t.Fatalf("failed to parse log output: %v", err)
+			}
+			for _, key := range []string{traceKey, spanIDKey, traceSampledKey} {
+				if record[key] != tt.want[key] {
+					t.Errorf("expected %s %v; got %v", key, tt.want[key], record[key])
+				}
+			}
+		})
+	}
+}
+
 func TestWithResponseInfo(t *testing.T) {
 	statusCode := 200
 
@@ -172,3 +238,15 @@ func TestFromContext_WithCorrelationID(t *testing.T) {
 		t.Errorf("Expected correlation ID %q in log output, got: %s", correlationID, output)
 	}
 }
+
+func TestCorrelationIDToContext_FromContext(t *testing.T) {
+	ctx := context.Background()
+	if got := CorrelationIDFromContext(ctx); got != "" {
+		t.Errorf("CorrelationIDFromContext() = %q, want empty without a correlation ID", got)
+	}
+
+	ctx = CorrelationIDToContext(ctx, "test-correlation-id-789")
+	if got := CorrelationIDFromContext(ctx); got != "test-correlation-id-789" {
+		t.Errorf("CorrelationIDFromContext() = %q, want %q", got, "test-correlation-id-789")
+	}
+}
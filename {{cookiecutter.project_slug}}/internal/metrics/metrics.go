@@ -0,0 +1,120 @@
+package metrics
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"gorm.io/gorm"
+
+	"{{cookiecutter.module_name}}/internal/version"
+)
+
+// methods are the request methods counted by name, others are counted as
+// OTHER so clients cannot add labels at will.
+var methods = map[string]bool{
+	http.MethodGet:     true,
+	http.MethodHead:    true,
+	http.MethodPost:    true,
+	http.MethodPut:     true,
+	http.MethodPatch:   true,
+	http.MethodDelete:  true,
+	http.MethodOptions: true,
+}
+
+// Metrics holds the Prometheus collectors of the server. Requests are
+// labelled by the route pattern they matched, not their path, so an ID in
+// the path does not add a series per ID.
+type Metrics struct {
+	registry *prometheus.Registry
+	requests *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+	inFlight *prometheus.GaugeVec
+	panics   prometheus.Counter
+}
+
+// New creates the collectors of the HTTP routes, the Go runtime and the
+// process, and publishes v as build_info.
+func New(v version.Version) *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "http_requests_total",
+			Help: "Requests served, by method, route and status code.",
+		}, []string{"method", "route", "status"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "http_request_duration_seconds",
+			Help:    "Time until the handler returned, by method and route.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"method", "route"}),
+		inFlight: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "http_requests_in_flight",
+			Help: "Requests being served, by method and route.",
+		}, []string{"method", "route"}),
+		panics: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "http_panics_total",
+			Help: "Panics recovered from while serving requests.",
+		}),
+	}
+	buildInfo := prometheus.NewGauge(prometheus.GaugeOpts{
+		Name:        "build_info",
+		Help:        "Always 1, labelled by the build and branch the server was built from.",
+		ConstLabels: prometheus.Labels{"build": v.Build, "branch": v.Branch},
+	})
+	buildInfo.Set(1)
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		buildInfo,
+		m.requests,
+		m.duration,
+		m.inFlight,
+		m.panics,
+	)
+	return m
+}
+
+// RegisterDB publishes the connection pool stats of db, such as open and
+// idle connections and the time spent waiting for one, labelled with name.
+func (m *Metrics) RegisterDB(db *gorm.DB, name string) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return fmt.Errorf("get connection pool: %w", err)
+	}
+	if err := m.registry.Register(collectors.NewDBStatsCollector(sqlDB, name)); err != nil {
+		return fmt.Errorf("register connection pool stats: %w", err)
+	}
+	return nil
+}
+
+// Handler serves the metrics in the Prometheus text format.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
+
+// StartRequest counts a request to route as in flight. The returned function
+// ends it with the status code of its response.
+func (m *Metrics) StartRequest(method, route string) func(status int) {
+	if !methods[method] {
+		method = "OTHER"
+	}
+	inFlight := m.inFlight.WithLabelValues(method, route)
+	inFlight.Inc()
+	start := time.Now()
+
+	return func(status int) {
+		inFlight.Dec()
+		m.duration.WithLabelValues(method, route).Observe(time.Since(start).Seconds())
+		m.requests.WithLabelValues(method, route, strconv.Itoa(status)).Inc()
+	}
+}
+
+// Panic counts a panic recovered from while serving a request.
+func (m *Metrics) Panic() {
+	m.panics.Inc()
+}
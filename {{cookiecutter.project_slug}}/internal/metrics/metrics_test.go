@@ -0,0 +1,111 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"{{cookiecutter.module_name}}/internal/db"
+	"{{cookiecutter.module_name}}/internal/version"
+)
+
+// scrape returns the metrics m serves in the text format.
+func scrape(t *testing.T, m *Metrics) string {
+	t.Helper()
+	w := httptest.NewRecorder()
+	m.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d; got %d", http.StatusOK, w.Code)
+	}
+	return w.Body.String()
+}
+
+func TestMetrics(t *testing.T) {
+	m := New(version.Version{Build: "abc123", Branch: "main"})
+
+	end := m.StartRequest(http.MethodGet, "GET /a/{id}")
+	inFlight := scrape(t, m)
+	end(http.StatusOK)
+	m.StartRequest("BREW", "/")(http.StatusNotFound)
+	m.Panic()
+	body := scrape(t, m)
+
+	tests := []struct {
+		name    string
+		body    string
+		want    string
+		wantNot string
+	}{
+		{
+			name: "build info",
+			body: body,
+			want: `build_info{branch="main",build="abc123"} 1`,
+		},
+		{
+			name: "in flight",
+			body: inFlight,
+			want: `http_requests_in_flight{method="GET",route="GET /a/{id}"} 1`,
+		},
+		{
+			name: "done",
+			body: body,
+			want: `http_requests_in_flight{method="GET",route="GET /a/{id}"} 0`,
+		},
+		{
+			name: "requests",
+			body: body,
+			want: `http_requests_total{method="GET",route="GET /a/{id}",status="200"} 1`,
+		},
+		{
+			name: "duration",
+			body: body,
+			want: `http_request_duration_seconds_count{method="GET",route="GET /a/{id}"} 1`,
+		},
+		{
+			name:    "unknown method",
+			body:    body,
+			want:    `http_requests_total{method="OTHER",route="/",status="404"} 1`,
+			wantNot: `method="BREW"`,
+		},
+		{
+			name: "panics",
+			body: body,
+			want: "http_panics_total 1",
+		},
+		{
+			name: "runtime",
+			body: body,
+			want: "go_goroutines",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !strings.Contains(tt.body, tt.want) {
+				t.Errorf("expected metrics to contain %q", tt.want)
+			}
+			if tt.wantNot != "" && strings.Contains(tt.body, tt.wantNot) {
+				t.Errorf("expected metrics not to contain %q", tt.wantNot)
+			}
+		})
+	}
+}
+
+func TestMetrics_RegisterDB(t *testing.T) {
+	conn, err := db.MakeDbSqlite()
+	if err != nil {
+		t.Fatalf("failed to connect database: %v", err)
+	}
+	m := New(version.Version{})
+
+	if err := m.RegisterDB(conn, "main"); err != nil {
+		t.Fatalf("RegisterDB() error = %v", err)
+	}
+	if body := scrape(t, m); !strings.Contains(body, `go_sql_open_connections{db_name="main"}`) {
+		t.Error("expected connection pool stats")
+	}
+	if err := m.RegisterDB(conn, "main"); err == nil {
+		t.Error("expected an error registering the same database twice")
+	}
+}
@@ -1,9 +1,17 @@
 package middleware
 
 import (
+	"context"
+	"expvar"
+	"fmt"
+	"log/slog"
 	"net/http"
+	"runtime/debug"
+	"time"
 
+	"{{cookiecutter.module_name}}/internal/errorreport"
 	"{{cookiecutter.module_name}}/internal/logger"
+	"{{cookiecutter.module_name}}/internal/metrics"
 )
 
 // middleware for post processing (after the handler has completed)
@@ -23,6 +31,12 @@ func (lrw *loggingResponseWriter) WriteHeader(code int) {
 	lrw.ResponseWriter.WriteHeader(code)
 }
 
+// Unwrap lets http.ResponseController reach the writer underneath, handlers
+// that stream flush through it.
+func (lrw *loggingResponseWriter) Unwrap() http.ResponseWriter {
+	return lrw.ResponseWriter
+}
+
 func LoggingMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		lrw := NewLoggingResponseWriter(w)
@@ -32,3 +46,130 @@ func LoggingMiddleware(next http.Handler) http.Handler {
 		reqLogger.Info("loggingMiddleware completed")
 	})
 }
+
+// statusResponseWriter remembers the status code of the response, zero
+// until it was started.
+type statusResponseWriter struct {
+	http.ResponseWriter
+	statusCode int
+}
+
+func (rw *statusResponseWriter) WriteHeader(code int) {
+	if rw.statusCode == 0 {
+		rw.statusCode = code
+	}
+	rw.ResponseWriter.WriteHeader(code)
+}
+
+func (rw *statusResponseWriter) Write(b []byte) (int, error) {
+	if rw.statusCode == 0 {
+		rw.statusCode = http.StatusOK
+	}
+	return rw.ResponseWriter.Write(b)
+}
+
+func (rw *statusResponseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
+// MetricsMiddleware records the requests mux serves in m, labelled by the
+// pattern of the route they matched. A handler that panics before starting
+// its response is counted as a 500, which is what RecoveryMiddleware answers.
+func MetricsMiddleware(mux *http.ServeMux, m *metrics.Metrics) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// the pattern is only set on the request once mux serves it, the in
+		// flight gauge needs it before
+		_, route := mux.Handler(r)
+		end := m.StartRequest(r.Method, route)
+		rw := &statusResponseWriter{ResponseWriter: w}
+		served := false
+		defer func() {
+			status := rw.statusCode
+			if status == 0 && served {
+				// nothing was written, net/http answers 200
+				status = http.StatusOK
+			} else if status == 0 {
+				status = http.StatusInternalServerError
+			}
+			end(status)
+		}()
+
+		mux.ServeHTTP(rw, r)
+		served = true
+	})
+}
+
+// Panics counts the panics RecoveryMiddleware recovered from. It is
+// published as "panics" by expvar.
+var Panics = expvar.NewInt("panics")
+
+// recoveryResponseWriter remembers whether the response was started.
+type recoveryResponseWriter struct {
+	http.ResponseWriter
+	wroteHeader bool
+}
+
+func (rw *recoveryResponseWriter) WriteHeader(code int) {
+	rw.wroteHeader = true
+	rw.ResponseWriter.WriteHeader(code)
+}
+
+func (rw *recoveryResponseWriter) Write(b []byte) (int, error) {
+	rw.wroteHeader = true
+	return rw.ResponseWriter.Write(b)
+}
+
+func (rw *recoveryResponseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
+// RecoveryMiddleware turns a panic in next into a 500 response. The panic
+// and its stack are logged on the request logger, counted in Panics and in
+// m, and sent to reporter. m and reporter may be nil. When next already started the response
+// the connection is aborted instead, so the client does not mistake a
+// truncated response for a complete one.
+func RecoveryMiddleware(next http.Handler, reporter errorreport.Reporter, m *metrics.Metrics) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rw := &recoveryResponseWriter{ResponseWriter: w}
+		defer func() {
+			recovered := recover()
+			if recovered == nil {
+				return
+			}
+			if recovered == http.ErrAbortHandler {
+				panic(recovered)
+			}
+			stack := string(debug.Stack())
+			Panics.Add(1)
+			if m != nil {
+				m.Panic()
+			}
+
+			reqLogger := logger.FromContext(r.Context())
+			reqLogger.Error("panic while handling request",
+				slog.String("panic", fmt.Sprint(recovered)),
+				slog.String("stack", stack),
+			)
+			if reporter != nil {
+				event := errorreport.Event{
+					Time:          time.Now(),
+					Message:       fmt.Sprint(recovered),
+					Stack:         stack,
+					CorrelationID: logger.CorrelationIDFromContext(r.Context()),
+					Method:        r.Method,
+					Path:          r.URL.Path,
+				}
+				if err := reporter.Report(context.WithoutCancel(r.Context()), event); err != nil {
+					reqLogger.Error("failed to report panic", slog.String("error", err.Error()))
+				}
+			}
+
+			if rw.wroteHeader {
+				panic(http.ErrAbortHandler)
+			}
+			writeError(rw, r, http.StatusInternalServerError, "internal server error")
+		}()
+
+		next.ServeHTTP(rw, r)
+	})
+}
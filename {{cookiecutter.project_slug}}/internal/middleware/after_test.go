@@ -2,11 +2,18 @@ package middleware
 
 import (
 	"bytes"
+	"context"
 	"log/slog"
 	"net/http"
 	"net/http/httptest"
 	"strconv"
+	"strings"
 	"testing"
+
+	"{{cookiecutter.module_name}}/internal/errorreport"
+	"{{cookiecutter.module_name}}/internal/logger"
+	"{{cookiecutter.module_name}}/internal/metrics"
+	"{{cookiecutter.module_name}}/internal/version"
 )
 
 // tests to make sure the response writer captures status codes correctly
@@ -165,3 +172,151 @@ func TestLoggingMiddleware_ImplicitStatusCode(t *testing.T) {
 		t.Errorf("Expected status code 200 in log output, got: %s", output)
 	}
 }
+
+type recordingReporter struct {
+	events []errorreport.Event
+}
+
+func (r *recordingReporter) Report(ctx context.Context, event errorreport.Event) error {
+	r.events = append(r.events, event)
+	return nil
+}
+
+func TestRecoveryMiddleware(t *testing.T) {
+	tests := []struct {
+		name           string
+		handler        http.HandlerFunc
+		expectedStatus int
+		expectedPanics int64
+		expectAbort    bool
+	}{
+		{
+			name: "no panic",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "panic",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				panic("boom")
+			},
+			expectedStatus: http.StatusInternalServerError,
+			expectedPanics: 1,
+		},
+		{
+			name: "panic after response started",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+				panic("boom")
+			},
+			expectedStatus: http.StatusOK,
+			expectedPanics: 1,
+			expectAbort:    true,
+		},
+		{
+			name: "abort handler",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				panic(http.ErrAbortHandler)
+			},
+			expectedStatus: http.StatusOK,
+			expectAbort:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			log := slog.New(slog.NewJSONHandler(&buf, nil))
+			reporter := &recordingReporter{}
+			handler := RecoveryMiddleware(tt.handler, reporter, nil)
+
+			req := httptest.NewRequest(http.MethodGet, "/test", nil)
+			ctx := logger.CorrelationIDToContext(logger.ToContext(req.Context(), log), "correlation-1")
+			req = req.WithContext(ctx)
+			w := httptest.NewRecorder()
+			before := Panics.Value()
+
+			aborted := func() (aborted bool) {
+				defer func() {
+					aborted = recover() == http.ErrAbortHandler
+				}()
+				handler.ServeHTTP(w, req)
+				return false
+			}()
+
+			if aborted != tt.expectAbort {
+				t.Errorf("expected abort %v; got %v", tt.expectAbort, aborted)
+			}
+			if w.Code != tt.expectedStatus {
+				t.Errorf("expected status code %d; got %d", tt.expectedStatus, w.Code)
+			}
+			if got := Panics.Value() - before; got != tt.expectedPanics {
+				t.Errorf("expected %d panics counted; got %d", tt.expectedPanics, got)
+			}
+			if int64(len(reporter.events)) != tt.expectedPanics {
+				t.Fatalf("expected %d reported events; got %d", tt.expectedPanics, len(reporter.events))
+			}
+			if tt.expectedPanics == 0 {
+				return
+			}
+
+			event := reporter.events[0]
+			if event.Message != "boom" || event.CorrelationID != "correlation-1" || event.Path != "/test" || event.Stack == "" {
+				t.Errorf("unexpected event %+v", event)
+			}
+			if !bytes.Contains(buf.Bytes(), []byte("panic while handling request")) || !bytes.Contains(buf.Bytes(), []byte("goroutine")) {
+				t.Errorf("expected the panic and its stack in the log, got: %s", buf.String())
+			}
+			if tt.expectedStatus == http.StatusInternalServerError && !bytes.Contains(w.Body.Bytes(), []byte(`"detail":"internal server error"`)) {
+				t.Errorf("expected an error body, got: %s", w.Body.String())
+			}
+		})
+	}
+}
+
+func TestMetricsMiddleware(t *testing.T) {
+	m := metrics.New(version.Version{})
+	mux := http.NewServeMux()
+	mux.Handle("GET /a/{id}", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	mux.Handle("POST /a", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	}))
+	mux.Handle("DELETE /a/{id}", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	mux.Handle("GET /panic", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}))
+	handler := RecoveryMiddleware(MetricsMiddleware(mux, m), nil, m)
+
+	for _, request := range []struct{ method, path string }{
+		{http.MethodGet, "/a/1"},
+		{http.MethodGet, "/a/2"},
+		{http.MethodPost, "/a"},
+		{http.MethodDelete, "/a/1"},
+		{http.MethodGet, "/panic"},
+		{http.MethodGet, "/missing"},
+	} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(request.method, request.path, nil))
+	}
+
+	w := httptest.NewRecorder()
+	m.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	for _, want := range []string{
+		`http_requests_total{method="GET",route="GET /a/{id}",status="200"} 2`,
+		`http_requests_total{method="POST",route="POST /a",status="201"} 1`,
+		`http_requests_total{method="DELETE",route="DELETE /a/{id}",status="200"} 1`,
+		`http_requests_total{method="GET",route="GET /panic",status="500"} 1`,
+		`http_requests_total{method="GET",route="",status="404"} 1`,
+		`http_panics_total 1`,
+	} {
+		if !strings.Contains(w.Body.String(), want) {
+			t.Errorf("expected metrics to contain %q", want)
+		}
+	}
+	if strings.Contains(w.Body.String(), `route="/a/1"`) {
+		t.Error("expected requests to be labelled by pattern, not path")
+	}
+}
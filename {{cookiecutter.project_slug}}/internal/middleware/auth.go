@@ -0,0 +1,151 @@
+package middleware
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"{{cookiecutter.module_name}}/internal/auth"
+	"{{cookiecutter.module_name}}/internal/logger"
+)
+
+const APIKeyHeader = "X-Api-Key"
+
+// AdminMiddleware only lets requests through that carry the admin token as
+// "Authorization: Bearer <token>". When no token is configured every request
+// is forbidden, so admin routes are disabled by default.
+func AdminMiddleware(next http.Handler, token string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reqLogger := logger.FromContext(r.Context())
+
+		scheme, presented := authorization(r)
+		if scheme != "Bearer" || presented == "" {
+			reqLogger.Info("admin request without credentials")
+			writeError(w, r, http.StatusUnauthorized, "admin credentials required")
+			return
+		}
+		if token == "" || subtle.ConstantTimeCompare([]byte(presented), []byte(token)) != 1 {
+			reqLogger.Info("admin request with invalid credentials")
+			writeError(w, r, http.StatusForbidden, "admin access denied")
+			return
+		}
+
+		reqLogger.Debug("AdminMiddleware completed")
+		next.ServeHTTP(w, r)
+	})
+}
+
+// TokenVerifier checks a credential and returns who it was issued to.
+// auth.Verifier checks JWTs, service.APIKeyService API keys.
+type TokenVerifier interface {
+	Verify(ctx context.Context, token string) (*auth.Principal, error)
+}
+
+// AuthMiddleware only lets requests through that carry a credential one of
+// the verifiers accepts: bearer checks "Authorization: Bearer <token>",
+// apiKeys checks "Authorization: ApiKey <key>" and the X-Api-Key header. A
+// nil verifier turns its scheme off, with both nil every request goes
+// through. It puts the principal into the request context and its subject
+// on the request logger.
+func AuthMiddleware(next http.Handler, bearer, apiKeys TokenVerifier) http.Handler {
+	if bearer == nil && apiKeys == nil {
+		return next
+	}
+	verifiers := map[string]TokenVerifier{"Bearer": bearer, "ApiKey": apiKeys}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reqLogger := logger.FromContext(r.Context())
+
+		scheme, token := credentials(r)
+		verifier := verifiers[scheme]
+		if verifier == nil || token == "" {
+			reqLogger.Info("request without credentials")
+			// offer the schemes that are on
+			for _, scheme := range []string{"Bearer", "ApiKey"} {
+				if verifiers[scheme] != nil {
+					w.Header().Add("WWW-Authenticate", scheme)
+				}
+			}
+			writeError(w, r, http.StatusUnauthorized, "authentication required")
+			return
+		}
+
+		principal, err := verifier.Verify(r.Context(), token)
+		if errors.Is(err, auth.ErrInvalidToken) {
+			reqLogger.Info("request with invalid credentials", slog.String("scheme", scheme), slog.String("error", err.Error()))
+			w.Header().Set("WWW-Authenticate", scheme+` error="invalid_token"`)
+			writeError(w, r, http.StatusUnauthorized, "invalid token")
+			return
+		}
+		if err != nil {
+			reqLogger.Error("failed to verify credentials", slog.String("scheme", scheme), slog.String("error", err.Error()))
+			writeError(w, r, http.StatusServiceUnavailable, "authentication unavailable")
+			return
+		}
+
+		reqLogger = logger.WithSubject(r.Context(), principal.Subject)
+		ctx := logger.ToContext(r.Context(), reqLogger)
+		ctx = auth.ToContext(ctx, principal)
+		r = r.WithContext(ctx)
+
+		reqLogger.Debug("AuthMiddleware completed")
+		next.ServeHTTP(w, r)
+	})
+}
+
+// credentials returns the scheme and credential of a request. An X-Api-Key
+// header is the same as "Authorization: ApiKey".
+func credentials(r *http.Request) (scheme, token string) {
+	if key := strings.TrimSpace(r.Header.Get(APIKeyHeader)); key != "" {
+		return "ApiKey", key
+	}
+	return authorization(r)
+}
+
+// authorization returns the scheme and credential of the Authorization
+// header. Schemes are case-insensitive (RFC 7235), the known ones are
+// returned as "Bearer" and "ApiKey" however they were written.
+func authorization(r *http.Request) (scheme, token string) {
+	scheme, token, _ = strings.Cut(strings.TrimSpace(r.Header.Get("Authorization")), " ")
+	for _, known := range []string{"Bearer", "ApiKey"} {
+		if strings.EqualFold(scheme, known) {
+			scheme = known
+		}
+	}
+	return scheme, strings.TrimSpace(token)
+}
+
+// RequireScope only lets requests through whose principal was granted
+// scope. It must run after AuthMiddleware, requests without a principal are
+// rejected. Every decision is logged on the request logger, which carries
+// the correlation ID and the subject.
+func RequireScope(next http.Handler, scope string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reqLogger := logger.FromContext(r.Context()).With(slog.String("scope", scope))
+
+		principal, ok := auth.FromContext(r.Context())
+		if !ok {
+			reqLogger.Info("authorization denied, request is not authenticated")
+			w.Header().Set("WWW-Authenticate", `Bearer`)
+			writeError(w, r, http.StatusUnauthorized, "authentication required")
+			return
+		}
+		if !principal.HasScope(scope) {
+			reqLogger.Info("authorization denied, missing scope")
+			// the challenge names the scheme the principal authenticated with
+			scheme, _ := credentials(r)
+			if scheme == "" {
+				scheme = "Bearer"
+			}
+			w.Header().Set("WWW-Authenticate", scheme+` error="insufficient_scope", scope="`+scope+`"`)
+			writeError(w, r, http.StatusForbidden, "access denied")
+			return
+		}
+
+		reqLogger.Info("authorization granted")
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,293 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"{{cookiecutter.module_name}}/internal/auth"
+)
+
+// tests to make sure only requests with the admin token reach the handler
+func TestAdminMiddleware(t *testing.T) {
+	tests := []struct {
+		name           string
+		token          string
+		authorization  string
+		expectedStatus int
+	}{
+		{
+			name:           "valid token",
+			token:          "admin-token",
+			authorization:  "Bearer admin-token",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "missing header",
+			token:          "admin-token",
+			authorization:  "",
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "wrong scheme",
+			token:          "admin-token",
+			authorization:  "Basic admin-token",
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "lowercase scheme",
+			token:          "admin-token",
+			authorization:  "bearer admin-token",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "wrong token",
+			token:          "admin-token",
+			authorization:  "Bearer guess",
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "no token configured",
+			token:          "",
+			authorization:  "Bearer anything",
+			expectedStatus: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			})
+
+			handler := AdminMiddleware(testHandler, tt.token)
+
+			req := httptest.NewRequest(http.MethodPost, "/admin", nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("expected status code %d; got %d", tt.expectedStatus, w.Code)
+			}
+		})
+	}
+}
+
+// stubVerifier accepts "good" and fails "down" as if the key set were unavailable
+type stubVerifier struct{}
+
+func (stubVerifier) Verify(ctx context.Context, token string) (*auth.Principal, error) {
+	switch token {
+	case "good":
+		return &auth.Principal{Subject: "user-1"}, nil
+	case "down":
+		return nil, errors.New("fetch jwks: connection refused")
+	default:
+		return nil, auth.ErrInvalidToken
+	}
+}
+
+func TestAuthMiddleware(t *testing.T) {
+	tests := []struct {
+		name            string
+		verifier        TokenVerifier
+		apiKeys         TokenVerifier
+		authorization   string
+		apiKey          string
+		expectedStatus  int
+		expectedSubject string
+		expectedHeader  string
+	}{
+		{
+			name:            "valid token",
+			verifier:        stubVerifier{},
+			authorization:   "Bearer good",
+			expectedStatus:  http.StatusOK,
+			expectedSubject: "user-1",
+		},
+		{
+			name:            "lowercase scheme",
+			verifier:        stubVerifier{},
+			authorization:   "bearer good",
+			expectedStatus:  http.StatusOK,
+			expectedSubject: "user-1",
+		},
+		{
+			name:            "extra whitespace",
+			verifier:        stubVerifier{},
+			authorization:   " BEARER   good ",
+			expectedStatus:  http.StatusOK,
+			expectedSubject: "user-1",
+		},
+		{
+			name:           "missing header",
+			verifier:       stubVerifier{},
+			expectedStatus: http.StatusUnauthorized,
+			expectedHeader: "Bearer",
+		},
+		{
+			name:           "wrong scheme",
+			verifier:       stubVerifier{},
+			authorization:  "Basic good",
+			expectedStatus: http.StatusUnauthorized,
+			expectedHeader: "Bearer",
+		},
+		{
+			name:           "invalid token",
+			verifier:       stubVerifier{},
+			authorization:  "Bearer bad",
+			expectedStatus: http.StatusUnauthorized,
+			expectedHeader: `Bearer error="invalid_token"`,
+		},
+		{
+			name:           "verifier unavailable",
+			verifier:       stubVerifier{},
+			authorization:  "Bearer down",
+			expectedStatus: http.StatusServiceUnavailable,
+		},
+		{
+			name:           "authentication disabled",
+			verifier:       nil,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:            "api key in authorization header",
+			verifier:        stubVerifier{},
+			apiKeys:         stubVerifier{},
+			authorization:   "ApiKey good",
+			expectedStatus:  http.StatusOK,
+			expectedSubject: "user-1",
+		},
+		{
+			name:            "api key header",
+			apiKeys:         stubVerifier{},
+			apiKey:          "good",
+			expectedStatus:  http.StatusOK,
+			expectedSubject: "user-1",
+		},
+		{
+			name:           "invalid api key",
+			apiKeys:        stubVerifier{},
+			apiKey:         "bad",
+			expectedStatus: http.StatusUnauthorized,
+			expectedHeader: `ApiKey error="invalid_token"`,
+		},
+		{
+			name:           "api keys disabled",
+			verifier:       stubVerifier{},
+			authorization:  "ApiKey good",
+			expectedStatus: http.StatusUnauthorized,
+			expectedHeader: "Bearer",
+		},
+		{
+			name:           "bearer disabled",
+			apiKeys:        stubVerifier{},
+			authorization:  "Bearer good",
+			expectedStatus: http.StatusUnauthorized,
+			expectedHeader: "ApiKey",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var subject string
+			testHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if principal, ok := auth.FromContext(r.Context()); ok {
+					subject = principal.Subject
+				}
+				w.WriteHeader(http.StatusOK)
+			})
+
+			handler := AuthMiddleware(testHandler, tt.verifier, tt.apiKeys)
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/resource", nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			if tt.apiKey != "" {
+				req.Header.Set(APIKeyHeader, tt.apiKey)
+			}
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("expected status code %d; got %d", tt.expectedStatus, w.Code)
+			}
+			if subject != tt.expectedSubject {
+				t.Errorf("expected subject %q in context; got %q", tt.expectedSubject, subject)
+			}
+			if got := w.Header().Get("WWW-Authenticate"); got != tt.expectedHeader {
+				t.Errorf("expected WWW-Authenticate %q; got %q", tt.expectedHeader, got)
+			}
+		})
+	}
+}
+
+func TestRequireScope(t *testing.T) {
+	tests := []struct {
+		name           string
+		principal      *auth.Principal
+		apiKey         string
+		expectedStatus int
+		expectedHeader string
+	}{
+		{
+			name:           "scope granted",
+			principal:      &auth.Principal{Subject: "user-1", Scopes: []string{"resource:read", "resource:write"}},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "scope missing",
+			principal:      &auth.Principal{Subject: "user-1", Scopes: []string{"resource:read"}},
+			expectedStatus: http.StatusForbidden,
+			expectedHeader: `Bearer error="insufficient_scope", scope="resource:write"`,
+		},
+		{
+			name:           "scope missing on api key",
+			principal:      &auth.Principal{Subject: "api-key:1", Scopes: []string{"resource:read"}},
+			apiKey:         "key",
+			expectedStatus: http.StatusForbidden,
+			expectedHeader: `ApiKey error="insufficient_scope", scope="resource:write"`,
+		},
+		{
+			name:           "not authenticated",
+			expectedStatus: http.StatusUnauthorized,
+			expectedHeader: "Bearer",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			})
+
+			handler := RequireScope(testHandler, "resource:write")
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/resource", nil)
+			if tt.principal != nil {
+				req = req.WithContext(auth.ToContext(req.Context(), tt.principal))
+			}
+			if tt.apiKey != "" {
+				req.Header.Set(APIKeyHeader, tt.apiKey)
+			}
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("expected status code %d; got %d", tt.expectedStatus, w.Code)
+			}
+			if got := w.Header().Get("WWW-Authenticate"); got != tt.expectedHeader {
+				t.Errorf("expected WWW-Authenticate %q; got %q", tt.expectedHeader, got)
+			}
+		})
+	}
+}
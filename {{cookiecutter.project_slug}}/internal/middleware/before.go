@@ -2,11 +2,18 @@ package middleware
 
 import (
 	"net/http"
+	"strings"
 
 	"{{cookiecutter.module_name}}/internal/logger"
+	"{{cookiecutter.module_name}}/internal/tracing"
 	"{{cookiecutter.module_name}}/internal/version"
 
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
 const CorrelationIDHeader = "X-Correlation-Id"
@@ -18,7 +25,7 @@ const BranchHeader = "X-Branch"
 // headerMiddleware ensures that every request has a correlation-id header.
 // If the header is not present in the incoming request, it generates a new UUID
 // and adds it to both the request and response headers.
-// It also creates a logger with the correlation ID and adds it to the request context.
+// It also creates a logger with the correlation ID and adds both to the request context.
 func HeaderMiddleware(next http.Handler, version version.Version) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		correlationID := r.Header.Get(CorrelationIDHeader)
@@ -33,6 +40,7 @@ func HeaderMiddleware(next http.Handler, version version.Version) http.Handler {
 		// Create a logger with the correlation ID and add it to the context
 		reqLogger := logger.WithCorrelationID(r.Context(), correlationID)
 		ctx := logger.ToContext(r.Context(), reqLogger)
+		ctx = logger.CorrelationIDToContext(ctx, correlationID)
 		r = r.WithContext(ctx)
 
 		// Set the build and branch headers
@@ -44,6 +52,46 @@ func HeaderMiddleware(next http.Handler, version version.Version) http.Handler {
 	})
 }
 
+// TracingMiddleware continues the trace of the traceparent header, or starts
+// a new one, with a server span per request named after the route of mux it
+// matches. The span goes into the request context, where the GORM queries
+// and events of the handler pick it up, and its IDs onto the request logger,
+// so it must run after HeaderMiddleware.
+func TracingMiddleware(next http.Handler, mux *http.ServeMux) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+
+		// patterns such as "GET /api/v1/x/{id}" start with their method
+		_, route := mux.Handler(r)
+		if _, path, ok := strings.Cut(route, " "); ok {
+			route = path
+		}
+		name := strings.TrimSpace(r.Method + " " + route)
+		ctx, span := tracing.Tracer().Start(ctx, name,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(r.Method),
+				semconv.HTTPRoute(route),
+				semconv.URLPath(r.URL.Path),
+			),
+		)
+		defer span.End()
+		ctx = logger.ToContext(ctx, logger.WithTrace(ctx))
+
+		rw := &statusResponseWriter{ResponseWriter: w}
+		next.ServeHTTP(rw, r.WithContext(ctx))
+
+		status := rw.statusCode
+		if status == 0 {
+			status = http.StatusOK
+		}
+		span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+	})
+}
+
 func RequestLoggingMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		reqLogger := logger.WithRequestInfo(r.Context(), r)
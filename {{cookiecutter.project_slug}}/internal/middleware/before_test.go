@@ -1,12 +1,21 @@
 package middleware
 
 import (
+	"bytes"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"{{cookiecutter.module_name}}/internal/logger"
 	"{{cookiecutter.module_name}}/internal/version"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 )
 
 // tests to make sure headers are added to response
@@ -39,6 +48,9 @@ func TestHeaderMiddleware(t *testing.T) {
 				if requestCorrelationID == "" {
 					t.Error("expected correlation-id to be present in request header")
 				}
+				if got := logger.CorrelationIDFromContext(r.Context()); got != requestCorrelationID {
+					t.Errorf("expected correlation-id %q in request context; got %q", requestCorrelationID, got)
+				}
 				w.WriteHeader(http.StatusOK)
 			})
 
@@ -115,6 +127,95 @@ func TestHeaderMiddleware_LoggerInContext(t *testing.T) {
 }
 
 // tests to make sure logger is added to context
+func TestTracingMiddleware(t *testing.T) {
+	provider, propagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	defer func() {
+		otel.SetTracerProvider(provider)
+		otel.SetTextMapPropagator(propagator)
+	}()
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	mux := http.NewServeMux()
+	mux.Handle("GET /a/{id}", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		logger.FromContext(r.Context()).Info("handled")
+	}))
+	mux.Handle("POST /fail", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		logger.FromContext(r.Context()).Info("handled")
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	handler := TracingMiddleware(mux, mux)
+
+	tests := []struct {
+		name        string
+		method      string
+		path        string
+		traceparent string
+		wantName    string
+		wantRoute   string
+		wantTraceID string
+		wantStatus  codes.Code
+	}{
+		{
+			name:        "continues the trace of the caller",
+			method:      http.MethodGet,
+			path:        "/a/1",
+			traceparent: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+			wantName:    "GET /a/{id}",
+			wantRoute:   "/a/{id}",
+			wantTraceID: "4bf92f3577b34da6a3ce929d0e0e4736",
+			wantStatus:  codes.Unset,
+		},
+		{
+			name:       "starts a trace",
+			method:     http.MethodPost,
+			path:       "/fail",
+			wantName:   "POST /fail",
+			wantRoute:  "/fail",
+			wantStatus: codes.Error,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := tracetest.NewSpanRecorder()
+			otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+
+			var buf bytes.Buffer
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			req = req.WithContext(logger.ToContext(req.Context(), slog.New(slog.NewJSONHandler(&buf, nil))))
+			if tt.traceparent != "" {
+				req.Header.Set("traceparent", tt.traceparent)
+			}
+
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			spans := recorder.Ended()
+			if len(spans) != 1 {
+				t.Fatalf("expected 1 span; got %d", len(spans))
+			}
+			span := spans[0]
+			if span.Name() != tt.wantName {
+				t.Errorf("expected span name %q; got %q", tt.wantName, span.Name())
+			}
+			traceID := span.SpanContext().TraceID().String()
+			if tt.wantTraceID != "" && traceID != tt.wantTraceID {
+				t.Errorf("expected trace ID %s; got %s", tt.wantTraceID, traceID)
+			}
+			if span.Status().Code != tt.wantStatus {
+				t.Errorf("expected span status %s; got %s", tt.wantStatus, span.Status().Code)
+			}
+			for _, kv := range span.Attributes() {
+				if kv.Key == "http.route" && kv.Value.AsString() != tt.wantRoute {
+					t.Errorf("expected route %q; got %q", tt.wantRoute, kv.Value.AsString())
+				}
+			}
+			if !strings.Contains(buf.String(), `"logging.googleapis.com/trace":"`+traceID+`"`) {
+				t.Errorf("expected the trace ID on the request logger; got %s", buf.String())
+			}
+		})
+	}
+}
+
 func TestRequestLoggingMiddleware(t *testing.T) {
 	tests := []struct {
 		name   string
@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+)
+
+// BodyLimits cap request bodies. Handlers look them up with
+// BodyLimitsFromContext when they decode a body.
+type BodyLimits struct {
+	MaxBytes int64 // size of a request body
+	MaxDepth int   // nesting of arrays and objects in a JSON body
+}
+
+// DefaultBodyLimits apply to requests no BodyLimitMiddleware has set limits
+// for.
+var DefaultBodyLimits = BodyLimits{MaxBytes: 1 << 20, MaxDepth: 32}
+
+type bodyLimitsKey struct{}
+
+// BodyLimitMiddleware sets the body limits of the requests it wraps. Zero
+// fields keep the limits set further out, so the server sets its defaults
+// once and a route only overrides what it needs, larger or smaller.
+func BodyLimitMiddleware(next http.Handler, limits BodyLimits) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		current := BodyLimitsFromContext(r.Context())
+		if limits.MaxBytes > 0 {
+			current.MaxBytes = limits.MaxBytes
+		}
+		if limits.MaxDepth > 0 {
+			current.MaxDepth = limits.MaxDepth
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), bodyLimitsKey{}, current)))
+	})
+}
+
+// BodyLimitsFromContext returns the body limits of a request.
+func BodyLimitsFromContext(ctx context.Context) BodyLimits {
+	if limits, ok := ctx.Value(bodyLimitsKey{}).(BodyLimits); ok {
+		return limits
+	}
+	return DefaultBodyLimits
+}
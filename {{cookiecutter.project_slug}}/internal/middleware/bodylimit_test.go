@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestBodyLimitMiddleware(t *testing.T) {
+	var got BodyLimits
+	inner := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = BodyLimitsFromContext(r.Context())
+	})
+
+	tests := []struct {
+		name    string
+		handler http.Handler
+		want    BodyLimits
+	}{
+		{
+			name:    "Defaults",
+			handler: BodyLimitMiddleware(inner, BodyLimits{}),
+			want:    DefaultBodyLimits,
+		},
+		{
+			name:    "Server Limits",
+			handler: BodyLimitMiddleware(inner, BodyLimits{MaxBytes: 2048, MaxDepth: 4}),
+			want:    BodyLimits{MaxBytes: 2048, MaxDepth: 4},
+		},
+		{
+			name:    "Route Override",
+			handler: BodyLimitMiddleware(BodyLimitMiddleware(inner, BodyLimits{MaxBytes: 1 << 30}), BodyLimits{MaxBytes: 2048, MaxDepth: 4}),
+			want:    BodyLimits{MaxBytes: 1 << 30, MaxDepth: 4},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/", nil))
+			if got != tt.want {
+				t.Errorf("limits = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+)
+
+type clientIPKey struct{}
+
+// ClientIPMiddleware puts the IP of the client into the request context.
+// Every proxy in front of the server appends the address it was called from
+// to X-Forwarded-For, so with trustedProxies of them the client is that many
+// entries from the end. Entries further left are sent by the client and can
+// be forged. With no trusted proxies, such as when clients reach the server
+// directly, the header is ignored and the address of the peer is used.
+// Cloud Run is one proxy.
+func ClientIPMiddleware(next http.Handler, trustedProxies int) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ip := remoteIP(r)
+		if forwarded := r.Header.Get("X-Forwarded-For"); trustedProxies > 0 && forwarded != "" {
+			entries := strings.Split(forwarded, ",")
+			// fewer entries than proxies means the first proxy was skipped,
+			// its entry is still one a proxy added
+			ip = strings.TrimSpace(entries[max(len(entries)-trustedProxies, 0)])
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), clientIPKey{}, ip)))
+	})
+}
+
+// clientIP returns the IP ClientIPMiddleware found for a request, or the
+// address of the peer when it did not run.
+func clientIP(r *http.Request) string {
+	if ip, ok := r.Context().Value(clientIPKey{}).(string); ok {
+		return ip
+	}
+	return remoteIP(r)
+}
+
+func remoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientIPMiddleware(t *testing.T) {
+	tests := []struct {
+		name           string
+		trustedProxies int
+		forwardedFor   string
+		expected       string
+	}{
+		{name: "no proxies", forwardedFor: "203.0.113.1", expected: "192.0.2.1"},
+		{name: "no header", trustedProxies: 1, expected: "192.0.2.1"},
+		{name: "one proxy", trustedProxies: 1, forwardedFor: "203.0.113.1", expected: "203.0.113.1"},
+		{name: "one proxy forged entry", trustedProxies: 1, forwardedFor: "198.51.100.1, 203.0.113.1", expected: "203.0.113.1"},
+		{name: "two proxies", trustedProxies: 2, forwardedFor: "198.51.100.1, 203.0.113.1, 10.0.0.1", expected: "203.0.113.1"},
+		{name: "fewer entries than proxies", trustedProxies: 2, forwardedFor: "203.0.113.1", expected: "203.0.113.1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			handler := ClientIPMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = clientIP(r)
+			}), tt.trustedProxies)
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.forwardedFor != "" {
+				req.Header.Set("X-Forwarded-For", tt.forwardedFor)
+			}
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			if got != tt.expected {
+				t.Errorf("expected client IP %q; got %q", tt.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,12 @@
+package middleware
+
+import (
+	"net/http"
+
+	"{{cookiecutter.module_name}}/internal/problem"
+)
+
+// writeError answers with the same problem details the handlers use.
+func writeError(w http.ResponseWriter, r *http.Request, status int, detail string) {
+	problem.Write(w, problem.New(r, status, detail))
+}
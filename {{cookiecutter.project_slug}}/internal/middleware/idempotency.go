@@ -0,0 +1,169 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	"time"
+
+	"{{cookiecutter.module_name}}/internal/idempotency"
+	"{{cookiecutter.module_name}}/internal/logger"
+)
+
+const IdempotencyKeyHeader = "Idempotency-Key"
+const IdempotentReplayedHeader = "Idempotent-Replayed"
+
+// maxIdempotencyKeyLength is the longest Idempotency-Key accepted, UUIDs
+// and similar random keys are far shorter.
+const maxIdempotencyKeyLength = 255
+
+// IdempotencyMiddleware makes POST requests that carry an Idempotency-Key
+// safe to retry. The first request with a key runs and its response is kept
+// in store for ttl, idempotency.DefaultTTL when zero. A retry with the same
+// key and body gets that response again, marked with Idempotent-Replayed,
+// instead of running twice. Reusing a key for another body, or while the
+// first request is still running, is a 409. Keys are scoped to the client
+// and route, so it must run after AuthMiddleware. Responses with a 5xx status
+// are not kept, the request can be retried with the same key. When the store
+// fails the request is let through rather than taking the API down with it.
+func IdempotencyMiddleware(next http.Handler, store idempotency.Store, ttl time.Duration) http.Handler {
+	if ttl <= 0 {
+		ttl = idempotency.DefaultTTL
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(IdempotencyKeyHeader)
+		if r.Method != http.MethodPost || key == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		reqLogger := logger.FromContext(r.Context())
+		if len(key) > maxIdempotencyKeyLength {
+			writeError(w, r, http.StatusBadRequest, fmt.Sprintf("%s must be at most %d characters", IdempotencyKeyHeader, maxIdempotencyKeyLength))
+			return
+		}
+
+		maxBytes := BodyLimitsFromContext(r.Context()).MaxBytes
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxBytes+1))
+		if err != nil {
+			reqLogger.Info("failed to read request body", slog.String("error", err.Error()))
+			writeError(w, r, http.StatusBadRequest, "failed to read request body")
+			return
+		}
+		if int64(len(body)) > maxBytes {
+			// the handler rejects the body as too large, there is nothing to
+			// keep
+			r.Body = struct {
+				io.Reader
+				io.Closer
+			}{io.MultiReader(bytes.NewReader(body), r.Body), r.Body}
+			next.ServeHTTP(w, r)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		storeKey := clientKey(r) + " " + r.Pattern + " " + key
+		stored, err := store.Begin(r.Context(), storeKey, fingerprint(r, body))
+		switch {
+		case errors.Is(err, idempotency.ErrInProgress):
+			reqLogger.Info("idempotency key in use", slog.String("key", key))
+			writeError(w, r, http.StatusConflict, "a request with this "+IdempotencyKeyHeader+" is still in progress")
+			return
+		case errors.Is(err, idempotency.ErrMismatch):
+			reqLogger.Info("idempotency key reused for another request", slog.String("key", key))
+			writeError(w, r, http.StatusConflict, IdempotencyKeyHeader+" was already used for a different request")
+			return
+		case err != nil:
+			reqLogger.Error("failed to check idempotency key", slog.String("error", err.Error()))
+			next.ServeHTTP(w, r)
+			return
+		case stored != nil:
+			reqLogger.Info("replaying response of idempotency key", slog.String("key", key))
+			for name, values := range stored.Header {
+				w.Header()[name] = values
+			}
+			w.Header().Set(IdempotentReplayedHeader, "true")
+			w.WriteHeader(stored.Status)
+			w.Write(stored.Body)
+			return
+		}
+
+		// the key stays claimed when the client goes away, so the store is
+		// updated without the request context
+		ctx := context.WithoutCancel(r.Context())
+		before := w.Header().Clone()
+		rw := &idempotencyResponseWriter{ResponseWriter: w, status: http.StatusOK}
+		completed := false
+		defer func() {
+			// also runs when next panics
+			if completed {
+				return
+			}
+			if err := store.Release(ctx, storeKey); err != nil {
+				reqLogger.Error("failed to release idempotency key", slog.String("error", err.Error()))
+			}
+		}()
+
+		next.ServeHTTP(rw, r)
+		if rw.status >= http.StatusInternalServerError {
+			return
+		}
+
+		// keep the headers the handler set, the ones set further out are set
+		// again on the replay
+		header := http.Header{}
+		for name, values := range w.Header() {
+			if !slices.Equal(before[name], values) {
+				header[name] = values
+			}
+		}
+		resp := idempotency.Response{Status: rw.status, Header: header, Body: rw.body.Bytes()}
+		if err := store.Complete(ctx, storeKey, resp, ttl); err != nil {
+			reqLogger.Error("failed to store idempotent response", slog.String("error", err.Error()))
+			return
+		}
+		completed = true
+	})
+}
+
+// fingerprint identifies a request by its method, URL and body, so a key
+// reused for another request is told apart from a retry.
+func fingerprint(r *http.Request, body []byte) string {
+	h := sha256.New()
+	io.WriteString(h, r.Method+" "+r.URL.RequestURI()+"\n")
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// idempotencyResponseWriter keeps a copy of the response it writes.
+type idempotencyResponseWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (rw *idempotencyResponseWriter) WriteHeader(code int) {
+	if !rw.wroteHeader {
+		rw.status = code
+		rw.wroteHeader = true
+	}
+	rw.ResponseWriter.WriteHeader(code)
+}
+
+func (rw *idempotencyResponseWriter) Write(b []byte) (int, error) {
+	rw.wroteHeader = true
+	rw.body.Write(b)
+	return rw.ResponseWriter.Write(b)
+}
+
+func (rw *idempotencyResponseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
@@ -0,0 +1,169 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"{{cookiecutter.module_name}}/internal/auth"
+	"{{cookiecutter.module_name}}/internal/db"
+	"{{cookiecutter.module_name}}/internal/idempotency"
+)
+
+func newIdempotencyStore(t *testing.T) *idempotency.PostgresStore {
+	db, err := db.MakeDbSqlite()
+	if err != nil {
+		t.Fatalf("failed to connect database: %v", err)
+	}
+	if err := db.AutoMigrate(&idempotency.Record{}); err != nil {
+		t.Fatalf("failed to migrate database: %v", err)
+	}
+	return idempotency.NewPostgresStore(db)
+}
+
+func TestIdempotencyMiddleware(t *testing.T) {
+	user1 := &auth.Principal{Subject: "user-1"}
+	user2 := &auth.Principal{Subject: "user-2"}
+
+	type request struct {
+		path           string
+		key            string
+		body           string
+		principal      *auth.Principal
+		expectedStatus int
+		expectedBody   string
+		replayed       bool
+	}
+	tests := []struct {
+		name     string
+		requests []request
+	}{
+		{
+			name: "replay",
+			requests: []request{
+				{path: "/a", key: "k1", body: "a", expectedStatus: http.StatusCreated, expectedBody: "created 1"},
+				{path: "/a", key: "k1", body: "a", expectedStatus: http.StatusCreated, expectedBody: "created 1", replayed: true},
+				{path: "/a", key: "k2", body: "a", expectedStatus: http.StatusCreated, expectedBody: "created 2"},
+			},
+		},
+		{
+			name: "key reused for another body",
+			requests: []request{
+				{path: "/a", key: "k1", body: "a", expectedStatus: http.StatusCreated, expectedBody: "created 1"},
+				{path: "/a", key: "k1", body: "b", expectedStatus: http.StatusConflict},
+			},
+		},
+		{
+			name: "keys per client",
+			requests: []request{
+				{path: "/a", key: "k1", body: "a", principal: user1, expectedStatus: http.StatusCreated, expectedBody: "created 1"},
+				{path: "/a", key: "k1", body: "a", principal: user2, expectedStatus: http.StatusCreated, expectedBody: "created 2"},
+			},
+		},
+		{
+			name: "without key",
+			requests: []request{
+				{path: "/a", body: "a", expectedStatus: http.StatusCreated, expectedBody: "created 1"},
+				{path: "/a", body: "a", expectedStatus: http.StatusCreated, expectedBody: "created 2"},
+			},
+		},
+		{
+			name: "server error is not kept",
+			requests: []request{
+				{path: "/flaky", key: "k1", body: "a", expectedStatus: http.StatusInternalServerError},
+				{path: "/flaky", key: "k1", body: "a", expectedStatus: http.StatusCreated, expectedBody: "created 2"},
+				{path: "/flaky", key: "k1", body: "a", expectedStatus: http.StatusCreated, expectedBody: "created 2", replayed: true},
+			},
+		},
+		{
+			name: "key too long",
+			requests: []request{
+				{path: "/a", key: strings.Repeat("k", 256), body: "a", expectedStatus: http.StatusBadRequest},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			created := 0
+			create := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				created++
+				if r.URL.Path == "/flaky" && created == 1 {
+					w.WriteHeader(http.StatusInternalServerError)
+					return
+				}
+				w.Header().Set("Location", fmt.Sprintf("/a/%d", created))
+				w.WriteHeader(http.StatusCreated)
+				fmt.Fprintf(w, "created %d", created)
+			})
+			store := newIdempotencyStore(t)
+			mux := http.NewServeMux()
+			mux.Handle("POST /a", IdempotencyMiddleware(create, store, time.Hour))
+			mux.Handle("POST /flaky", IdempotencyMiddleware(create, store, time.Hour))
+
+			for i, request := range tt.requests {
+				req := httptest.NewRequest(http.MethodPost, request.path, strings.NewReader(request.body))
+				if request.key != "" {
+					req.Header.Set(IdempotencyKeyHeader, request.key)
+				}
+				if request.principal != nil {
+					req = req.WithContext(auth.ToContext(req.Context(), request.principal))
+				}
+				w := httptest.NewRecorder()
+
+				mux.ServeHTTP(w, req)
+
+				if w.Code != request.expectedStatus {
+					t.Errorf("request %d: expected status code %d; got %d", i, request.expectedStatus, w.Code)
+				}
+				if request.expectedBody != "" && w.Body.String() != request.expectedBody {
+					t.Errorf("request %d: expected body %q; got %q", i, request.expectedBody, w.Body.String())
+				}
+				if replayed := w.Header().Get(IdempotentReplayedHeader) == "true"; replayed != request.replayed {
+					t.Errorf("request %d: expected replayed %v; got %v", i, request.replayed, replayed)
+				}
+				if request.replayed && w.Header().Get("Location") == "" {
+					t.Errorf("request %d: expected the Location header to be replayed", i)
+				}
+			}
+		})
+	}
+}
+
+func TestIdempotencyMiddleware_InProgress(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	slow := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+		w.WriteHeader(http.StatusCreated)
+	})
+	handler := IdempotencyMiddleware(slow, newIdempotencyStore(t), time.Hour)
+	newRequest := func() *http.Request {
+		req := httptest.NewRequest(http.MethodPost, "/a", strings.NewReader("a"))
+		req.Header.Set(IdempotencyKeyHeader, "k1")
+		return req
+	}
+
+	first := httptest.NewRecorder()
+	done := make(chan struct{})
+	go func() {
+		handler.ServeHTTP(first, newRequest())
+		close(done)
+	}()
+	<-started
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, newRequest())
+	if w.Code != http.StatusConflict {
+		t.Errorf("expected status code %d while in progress; got %d", http.StatusConflict, w.Code)
+	}
+
+	close(release)
+	<-done
+	if first.Code != http.StatusCreated {
+		t.Errorf("expected status code %d for the first request; got %d", http.StatusCreated, first.Code)
+	}
+}
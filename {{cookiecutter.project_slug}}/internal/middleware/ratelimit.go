@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"{{cookiecutter.module_name}}/internal/auth"
+	"{{cookiecutter.module_name}}/internal/logger"
+	"{{cookiecutter.module_name}}/internal/ratelimit"
+)
+
+// RateLimitMiddleware allows every client limit requests on the route it
+// wraps. Clients are identified by their principal, which covers API keys,
+// and by their IP when the request is not authenticated, so it must run after
+// AuthMiddleware. Responses carry the RateLimit-* headers, requests over the
+// limit get 429 with Retry-After. When the store fails the request is let
+// through rather than taking the API down with it.
+func RateLimitMiddleware(next http.Handler, store ratelimit.Store, limit ratelimit.Limit) http.Handler {
+	policy := fmt.Sprintf("%d;w=%d", limit.Requests, int(limit.Period.Seconds()))
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reqLogger := logger.FromContext(r.Context())
+
+		client := clientKey(r)
+		result, err := store.Take(r.Context(), r.Pattern+" "+client, limit)
+		if err != nil {
+			reqLogger.Error("failed to check rate limit", slog.String("error", err.Error()))
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Set("RateLimit-Policy", policy)
+		w.Header().Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+		w.Header().Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		w.Header().Set("RateLimit-Reset", ceilSeconds(result.Reset))
+		if !result.Allowed {
+			reqLogger.Info("rate limit exceeded", slog.String("client", client))
+			w.Header().Set("Retry-After", ceilSeconds(result.RetryAfter))
+			writeError(w, r, http.StatusTooManyRequests, "too many requests")
+			return
+		}
+
+		reqLogger.Debug("RateLimitMiddleware completed")
+		next.ServeHTTP(w, r)
+	})
+}
+
+// clientKey identifies who sent a request for rate limiting and idempotency
+// keys.
+func clientKey(r *http.Request) string {
+	if principal, ok := auth.FromContext(r.Context()); ok {
+		return "subject:" + principal.Subject
+	}
+	return "ip:" + clientIP(r)
+}
+
+func ceilSeconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
@@ -0,0 +1,117 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"{{cookiecutter.module_name}}/internal/auth"
+	"{{cookiecutter.module_name}}/internal/ratelimit"
+)
+
+type failingStore struct{}
+
+func (failingStore) Take(ctx context.Context, key string, limit ratelimit.Limit) (ratelimit.Result, error) {
+	return ratelimit.Result{}, errors.New("database unavailable")
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	user1 := &auth.Principal{Subject: "user-1"}
+	user2 := &auth.Principal{Subject: "user-2"}
+
+	type request struct {
+		path           string
+		principal      *auth.Principal
+		forwardedFor   string
+		expectedStatus int
+		expectedHeader map[string]string
+	}
+	tests := []struct {
+		name     string
+		store    ratelimit.Store
+		requests []request
+	}{
+		{
+			name:  "limit per principal",
+			store: ratelimit.NewMemoryStore(),
+			requests: []request{
+				{
+					path: "/a", principal: user1, expectedStatus: http.StatusOK,
+					expectedHeader: map[string]string{"RateLimit-Policy": "2;w=60", "RateLimit-Limit": "2", "RateLimit-Remaining": "1", "RateLimit-Reset": "30"},
+				},
+				{path: "/a", principal: user1, expectedStatus: http.StatusOK, expectedHeader: map[string]string{"RateLimit-Remaining": "0"}},
+				{
+					path: "/a", principal: user1, expectedStatus: http.StatusTooManyRequests,
+					expectedHeader: map[string]string{"RateLimit-Remaining": "0", "Retry-After": "30"},
+				},
+				{path: "/a", principal: user2, expectedStatus: http.StatusOK},
+			},
+		},
+		{
+			name:  "limit per route",
+			store: ratelimit.NewMemoryStore(),
+			requests: []request{
+				{path: "/a", principal: user1, expectedStatus: http.StatusOK},
+				{path: "/a", principal: user1, expectedStatus: http.StatusOK},
+				{path: "/b", principal: user1, expectedStatus: http.StatusOK},
+			},
+		},
+		{
+			name:  "limit per ip",
+			store: ratelimit.NewMemoryStore(),
+			requests: []request{
+				{path: "/a", forwardedFor: "203.0.113.1", expectedStatus: http.StatusOK},
+				{path: "/a", forwardedFor: "198.51.100.1, 203.0.113.1", expectedStatus: http.StatusOK},
+				{path: "/a", forwardedFor: "198.51.100.2, 203.0.113.1", expectedStatus: http.StatusTooManyRequests},
+				{path: "/a", forwardedFor: "203.0.113.2", expectedStatus: http.StatusOK},
+			},
+		},
+		{
+			name:  "store unavailable",
+			store: failingStore{},
+			requests: []request{
+				{path: "/a", principal: user1, expectedStatus: http.StatusOK},
+				{path: "/a", principal: user1, expectedStatus: http.StatusOK},
+				{path: "/a", principal: user1, expectedStatus: http.StatusOK},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			})
+			limit := ratelimit.Limit{Requests: 2, Period: time.Minute}
+			mux := http.NewServeMux()
+			mux.Handle("GET /a", RateLimitMiddleware(testHandler, tt.store, limit))
+			mux.Handle("GET /b", RateLimitMiddleware(testHandler, tt.store, limit))
+			handler := ClientIPMiddleware(mux, 1)
+
+			for i, request := range tt.requests {
+				req := httptest.NewRequest(http.MethodGet, request.path, nil)
+				if request.principal != nil {
+					req = req.WithContext(auth.ToContext(req.Context(), request.principal))
+				}
+				if request.forwardedFor != "" {
+					req.Header.Set("X-Forwarded-For", request.forwardedFor)
+				}
+				w := httptest.NewRecorder()
+
+				handler.ServeHTTP(w, req)
+
+				if w.Code != request.expectedStatus {
+					t.Errorf("request %d: expected status code %d; got %d", i, request.expectedStatus, w.Code)
+				}
+				for header, want := range request.expectedHeader {
+					if got := w.Header().Get(header); got != want {
+						t.Errorf("request %d: expected %s %q; got %q", i, header, want, got)
+					}
+				}
+			}
+		})
+	}
+}
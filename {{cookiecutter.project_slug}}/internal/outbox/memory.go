@@ -0,0 +1,41 @@
+package outbox
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+)
+
+// Published is a message received by MemoryPublisher.
+type Published struct {
+	Event       string
+	OrderingKey string
+	Data        []byte
+}
+
+// MemoryPublisher keeps messages in memory instead of sending them anywhere.
+// It stands in for Pub/Sub when running locally and in tests.
+type MemoryPublisher struct {
+	log      *slog.Logger
+	mu       sync.Mutex
+	messages []Published
+}
+
+func NewMemoryPublisher(log *slog.Logger) *MemoryPublisher {
+	return &MemoryPublisher{log: log}
+}
+
+func (p *MemoryPublisher) PublishOrdered(ctx context.Context, event, orderingKey string, data []byte) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.messages = append(p.messages, Published{Event: event, OrderingKey: orderingKey, Data: data})
+	p.log.Info("published message", slog.String("event", event), slog.String("ordering_key", orderingKey), slog.String("data", string(data)))
+	return nil
+}
+
+// Messages returns the messages published so far, oldest first.
+func (p *MemoryPublisher) Messages() []Published {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]Published(nil), p.messages...)
+}
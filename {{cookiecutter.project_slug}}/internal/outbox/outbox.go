@@ -0,0 +1,87 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+
+	"{{cookiecutter.module_name}}/internal/repository"
+	"{{cookiecutter.module_name}}/internal/tracing"
+)
+
+// Status is where a message is in its delivery.
+type Status string
+
+const (
+	StatusPending   Status = "pending"
+	StatusPublished Status = "published"
+	StatusDead      Status = "dead" // gave up after RelayConfig.MaxAttempts, kept for inspection
+)
+
+// Message is an event waiting in the outbox table to be published.
+// Messages of the same aggregate are published in ID order. TraceContext
+// holds the W3C trace context of the change as JSON, so the relay can
+// publish the message in the trace of the request that made it.
+// ClaimedUntil is set while a relay is publishing the message, other relays
+// leave it alone until then.
+type Message struct {
+	ID            int64     `gorm:"primaryKey;autoIncrement"`
+	AggregateType string    `gorm:"not null"`
+	AggregateID   string    `gorm:"not null"`
+	Event         string    `gorm:"not null"`
+	Payload       []byte    `gorm:"not null"`
+	Status        Status    `gorm:"not null;index"`
+	Attempts      int       `gorm:"not null"`
+	NextAttemptAt time.Time `gorm:"not null"`
+	LastError     string    `gorm:"not null"`
+	CreatedAt     time.Time `gorm:"not null"`
+	PublishedAt   *time.Time
+	TraceContext  []byte
+	ClaimedUntil  *time.Time
+}
+
+func (Message) TableName() string {
+	return "outbox_message"
+}
+
+// OrderingKey identifies the aggregate of m, messages with the same key are
+// published in order.
+func (m *Message) OrderingKey() string {
+	return m.AggregateType + "/" + m.AggregateID
+}
+
+// Outbox records messages for the Relay to publish. Add joins the transaction
+// carried by ctx (see repository.UnitOfWork), so a message is stored if and
+// only if the change it describes is committed.
+type Outbox struct {
+	repo *repository.EntityRepository[Message]
+}
+
+func New(db *gorm.DB) *Outbox {
+	return &Outbox{repo: repository.NewEntityRepository[Message](db)}
+}
+
+// Add queues event for the aggregate identified by aggregateType and aggregateID.
+func (o *Outbox) Add(ctx context.Context, aggregateType, aggregateID, event string, payload []byte) error {
+	carrier := map[string]string{}
+	tracing.Inject(ctx, carrier)
+	traceContext, err := json.Marshal(carrier)
+	if err != nil {
+		return fmt.Errorf("marshal trace context: %w", err)
+	}
+
+	now := time.Now()
+	return o.repo.Create(ctx, &Message{
+		AggregateType: aggregateType,
+		AggregateID:   aggregateID,
+		Event:         event,
+		Payload:       payload,
+		Status:        StatusPending,
+		NextAttemptAt: now,
+		CreatedAt:     now,
+		TraceContext:  traceContext,
+	})
+}
@@ -0,0 +1,78 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"gorm.io/gorm"
+
+	"{{cookiecutter.module_name}}/internal/db"
+	"{{cookiecutter.module_name}}/internal/repository"
+)
+
+func setupTestDB(t *testing.T) *gorm.DB {
+	db, err := db.MakeDbSqlite()
+	if err != nil {
+		t.Fatalf("failed to connect database: %v", err)
+	}
+	if err := db.AutoMigrate(&Message{}); err != nil {
+		t.Fatalf("failed to migrate database: %v", err)
+	}
+	return db
+}
+
+func TestOutbox_Add(t *testing.T) {
+	db := setupTestDB(t)
+	outbox := New(db)
+	uow := repository.NewUnitOfWork(db)
+	ctx := context.Background()
+	errBoom := errors.New("boom")
+
+	tests := []struct {
+		name      string
+		fnErr     error
+		wantCount int64
+	}{
+		{
+			name:      "stored when the transaction commits",
+			fnErr:     nil,
+			wantCount: 1,
+		},
+		{
+			name:      "dropped when the transaction rolls back",
+			fnErr:     errBoom,
+			wantCount: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := uow.WithTx(ctx, func(ctx context.Context) error {
+				if err := outbox.Add(ctx, "test", "1", "test.created", []byte(`{}`)); err != nil {
+					return err
+				}
+				return tt.fnErr
+			})
+			if !errors.Is(err, tt.fnErr) {
+				t.Fatalf("WithTx() error = %v, want %v", err, tt.fnErr)
+			}
+
+			var count int64
+			if err := db.Model(&Message{}).Count(&count).Error; err != nil {
+				t.Fatalf("failed to count messages: %v", err)
+			}
+			if count != tt.wantCount {
+				t.Errorf("outbox holds %d messages, want %d", count, tt.wantCount)
+			}
+		})
+	}
+
+	var m Message
+	if err := db.First(&m).Error; err != nil {
+		t.Fatalf("failed to read message: %v", err)
+	}
+	if m.Status != StatusPending || m.Event != "test.created" || m.AggregateID != "1" {
+		t.Errorf("stored message = %+v, want a pending test.created for aggregate 1", m)
+	}
+}
@@ -0,0 +1,242 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+
+	"{{cookiecutter.module_name}}/internal/tracing"
+)
+
+// Publisher sends a message to subscribers. Messages with the same
+// orderingKey must be delivered in the order they are published.
+// gcp.MessageRepository is the Pub/Sub implementation, MemoryPublisher the
+// local one.
+type Publisher interface {
+	PublishOrdered(ctx context.Context, event, orderingKey string, data []byte) error
+}
+
+type RelayConfig struct {
+	Interval    time.Duration                    // time between polls, 1s when zero
+	BatchSize   int                              // messages per poll, 100 when zero
+	MaxAttempts int                              // attempts before a message is dead, 10 when zero
+	Backoff     func(attempts int) time.Duration // delay after a failed attempt, doubling from 1s up to 5m when nil
+	Lease       time.Duration                    // time a relay has to publish the batch it claimed, 5m when zero
+}
+
+// claimLockKey is the Postgres advisory lock taken by claims, "outbox" in ASCII.
+const claimLockKey = 0x6f7574626f78
+
+// Relay drains the outbox to a Publisher.
+//
+// Each drain claims a batch in a short transaction, publishes it without
+// one and records the results in a second short transaction, so no locks
+// are held while waiting on the publisher. A claim is a lease of Lease,
+// messages of a relay that stops before recording are claimed again when
+// it runs out.
+//
+// Delivery is at least once: a message can be published again when the
+// relay stops between publishing it and recording that it did, so consumers
+// must be idempotent. Messages of one aggregate are published in order with
+// the aggregate as ordering key, a failed message holds back the later ones
+// until it is retried. A message
+// that fails MaxAttempts times is marked dead and no longer holds anything
+// back.
+type Relay struct {
+	db        *gorm.DB
+	publisher Publisher
+	log       *slog.Logger
+	cfg       RelayConfig
+	now       func() time.Time
+}
+
+func NewRelay(db *gorm.DB, publisher Publisher, log *slog.Logger, cfg RelayConfig) *Relay {
+	if cfg.Interval <= 0 {
+		cfg.Interval = time.Second
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 100
+	}
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = 10
+	}
+	if cfg.Backoff == nil {
+		cfg.Backoff = exponentialBackoff
+	}
+	if cfg.Lease <= 0 {
+		cfg.Lease = 5 * time.Minute
+	}
+	return &Relay{db: db, publisher: publisher, log: log, cfg: cfg, now: time.Now}
+}
+
+// Run drains the outbox every Interval until ctx is done.
+func (r *Relay) Run(ctx context.Context) {
+	r.log.Info("starting outbox relay")
+	ticker := time.NewTicker(r.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := r.Drain(ctx); err != nil && ctx.Err() == nil {
+			r.log.Error("failed to drain outbox", slog.String("error", err.Error()))
+		}
+		select {
+		case <-ctx.Done():
+			r.log.Info("outbox relay stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Drain publishes one batch of due messages and reports how many were published.
+func (r *Relay) Drain(ctx context.Context) (int, error) {
+	now := r.now()
+	batch, err := r.claim(ctx, now)
+	if err != nil || len(batch) == 0 {
+		return 0, err
+	}
+
+	// publishing stops when the lease runs out, so another relay cannot
+	// claim a message while it is being published
+	publishCtx, cancel := context.WithTimeout(ctx, r.cfg.Lease)
+	defer cancel()
+
+	published := 0
+	// aggregates with a message that failed in this batch
+	held := map[string]bool{}
+	for i := range batch {
+		m := &batch[i]
+		aggregate := m.OrderingKey()
+		if publishCtx.Err() != nil || held[aggregate] {
+			// left as it was, the claim is released below
+			continue
+		}
+
+		if err := r.publish(publishCtx, m); err != nil {
+			// a publish cut short by shutdown or the lease is not an attempt
+			if publishCtx.Err() == nil {
+				r.failed(m, err, now)
+				held[aggregate] = m.Status == StatusPending
+			}
+			continue
+		}
+		m.Status = StatusPublished
+		m.PublishedAt = &now
+		published++
+	}
+
+	// recorded even when ctx is done, so what was published is not
+	// published again
+	err = r.db.WithContext(context.WithoutCancel(ctx)).Transaction(func(tx *gorm.DB) error {
+		for i := range batch {
+			batch[i].ClaimedUntil = nil
+			if err := tx.Save(&batch[i]).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return published, err
+}
+
+// claim leases a batch of due messages to r until now plus Lease. On
+// Postgres claims take turns on an advisory lock, SQLite serializes writes
+// anyway, so each claim sees the leases of the one before it and the
+// messages of an aggregate are never claimed by two relays at once.
+func (r *Relay) claim(ctx context.Context, now time.Time) ([]Message, error) {
+	var batch []Message
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if tx.Dialector.Name() == "postgres" {
+			if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", claimLockKey).Error; err != nil {
+				return err
+			}
+		}
+
+		// only due messages are read, so messages backing off cannot fill
+		// the batch, and a message waits while an earlier one of its
+		// aggregate backs off or is claimed.
+		err := tx.Where("status = ? AND next_attempt_at <= ?", StatusPending, now).
+			Where("(claimed_until IS NULL OR claimed_until <= ?)", now).
+			Where(`NOT EXISTS (
+				SELECT 1 FROM outbox_message earlier
+				WHERE earlier.aggregate_type = outbox_message.aggregate_type
+				AND earlier.aggregate_id = outbox_message.aggregate_id
+				AND earlier.id < outbox_message.id
+				AND earlier.status = ?
+				AND (earlier.next_attempt_at > ? OR earlier.claimed_until > ?))`, StatusPending, now, now).
+			Order("id").
+			Limit(r.cfg.BatchSize).
+			Find(&batch).Error
+		if err != nil || len(batch) == 0 {
+			return err
+		}
+
+		until := now.Add(r.cfg.Lease)
+		ids := make([]int64, len(batch))
+		for i := range batch {
+			ids[i] = batch[i].ID
+			batch[i].ClaimedUntil = &until
+		}
+		return tx.Model(&Message{}).Where("id IN ?", ids).Update("claimed_until", until).Error
+	})
+	return batch, err
+}
+
+// publish sends m in a span of the trace of the request that stored it, the
+// publisher passes the trace context on to subscribers.
+func (r *Relay) publish(ctx context.Context, m *Message) error {
+	carrier := map[string]string{}
+	// messages stored before trace contexts were have none
+	if len(m.TraceContext) > 0 {
+		if err := json.Unmarshal(m.TraceContext, &carrier); err != nil {
+			r.log.Warn("dropping invalid trace context of outbox message", slog.Int64("id", m.ID), slog.String("error", err.Error()))
+		}
+	}
+	ctx, span := tracing.Tracer().Start(tracing.Extract(ctx, carrier), "publish "+m.Event,
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			semconv.MessagingOperationName("publish"),
+			semconv.MessagingMessageID(strconv.FormatInt(m.ID, 10)),
+		),
+	)
+	defer span.End()
+
+	if err := r.publisher.PublishOrdered(ctx, m.Event, m.OrderingKey(), m.Payload); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return err
+	}
+	return nil
+}
+
+func (r *Relay) failed(m *Message, err error, now time.Time) {
+	m.Attempts++
+	m.LastError = err.Error()
+	attrs := []any{
+		slog.Int64("id", m.ID),
+		slog.String("event", m.Event),
+		slog.Int("attempts", m.Attempts),
+		slog.String("error", err.Error()),
+	}
+
+	if m.Attempts >= r.cfg.MaxAttempts {
+		m.Status = StatusDead
+		r.log.Error("outbox message is dead", attrs...)
+		return
+	}
+	m.NextAttemptAt = now.Add(r.cfg.Backoff(m.Attempts))
+	r.log.Warn("failed to publish outbox message", attrs...)
+}
+
+// exponentialBackoff waits 1s, 2s, 4s and so on up to 5m. The shift is
+// bounded so it cannot overflow.
+func exponentialBackoff(attempts int) time.Duration {
+	return min(time.Second<<min(attempts-1, 16), 5*time.Minute)
+}
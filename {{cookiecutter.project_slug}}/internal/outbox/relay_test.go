@@ -0,0 +1,290 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"{{cookiecutter.module_name}}/internal/tracing"
+)
+
+// flakyPublisher fails every message of the events in failing.
+type flakyPublisher struct {
+	*MemoryPublisher
+	failing map[string]bool
+}
+
+func (p *flakyPublisher) PublishOrdered(ctx context.Context, event, orderingKey string, data []byte) error {
+	if p.failing[event] {
+		return errors.New("unavailable")
+	}
+	return p.MemoryPublisher.PublishOrdered(ctx, event, orderingKey, data)
+}
+
+func newTestRelay(t *testing.T, failing ...string) (*Relay, *Outbox, *flakyPublisher, *time.Time) {
+	t.Helper()
+	db := setupTestDB(t)
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	publisher := &flakyPublisher{MemoryPublisher: NewMemoryPublisher(log), failing: map[string]bool{}}
+	for _, event := range failing {
+		publisher.failing[event] = true
+	}
+
+	relay := NewRelay(db, publisher, log, RelayConfig{
+		MaxAttempts: 3,
+		Backoff:     func(int) time.Duration { return time.Minute },
+	})
+	// the clock starts ahead of the messages the tests add, so they are due
+	now := time.Now().Add(time.Hour)
+	relay.now = func() time.Time { return now }
+	return relay, New(db), publisher, &now
+}
+
+func events(messages []Published) []string {
+	names := make([]string, len(messages))
+	for i, m := range messages {
+		names[i] = m.Event
+	}
+	return names
+}
+
+func drain(t *testing.T, relay *Relay, want int) {
+	t.Helper()
+	got, err := relay.Drain(context.Background())
+	if err != nil {
+		t.Fatalf("Drain() error = %v", err)
+	}
+	if got != want {
+		t.Errorf("Drain() published %d, want %d", got, want)
+	}
+}
+
+func add(t *testing.T, outbox *Outbox, aggregateID, event string) {
+	t.Helper()
+	if err := outbox.Add(context.Background(), "test", aggregateID, event, []byte(`{}`)); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+}
+
+func TestRelay_DrainPublishesInOrder(t *testing.T) {
+	relay, outbox, publisher, _ := newTestRelay(t)
+	add(t, outbox, "a", "a.1")
+	add(t, outbox, "b", "b.1")
+	add(t, outbox, "a", "a.2")
+
+	drain(t, relay, 3)
+	drain(t, relay, 0)
+
+	want := []string{"a.1", "b.1", "a.2"}
+	got := events(publisher.Messages())
+	if len(got) != len(want) {
+		t.Fatalf("published %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("published %v, want %v", got, want)
+			break
+		}
+	}
+}
+
+func TestRelay_FailureHoldsBackAggregate(t *testing.T) {
+	relay, outbox, publisher, now := newTestRelay(t, "a.1")
+	add(t, outbox, "a", "a.1")
+	add(t, outbox, "a", "a.2")
+	add(t, outbox, "b", "b.1")
+
+	// a.1 fails so a.2 waits, b is not affected
+	drain(t, relay, 1)
+	if got := events(publisher.Messages()); len(got) != 1 || got[0] != "b.1" {
+		t.Fatalf("published %v, want [b.1]", got)
+	}
+
+	// still backing off
+	drain(t, relay, 0)
+
+	// a.1 recovers after the backoff and a.2 follows it
+	delete(publisher.failing, "a.1")
+	*now = now.Add(time.Minute)
+	drain(t, relay, 2)
+	if got := events(publisher.Messages()); len(got) != 3 || got[1] != "a.1" || got[2] != "a.2" {
+		t.Errorf("published %v, want [b.1 a.1 a.2]", got)
+	}
+}
+
+func TestRelay_BackoffDoesNotStarveDueMessages(t *testing.T) {
+	relay, outbox, publisher, _ := newTestRelay(t, "a.1", "b.1")
+	relay.cfg.BatchSize = 2
+	add(t, outbox, "a", "a.1")
+	add(t, outbox, "b", "b.1")
+	add(t, outbox, "a", "a.2")
+	add(t, outbox, "c", "c.1")
+
+	// a.1 and b.1 fill the first batch and fail
+	drain(t, relay, 0)
+
+	// they back off and a.2 waits for a.1, so c.1 is next
+	drain(t, relay, 1)
+	got := publisher.Messages()
+	if len(got) != 1 || got[0].Event != "c.1" {
+		t.Fatalf("published %v, want [c.1]", events(got))
+	}
+	if got[0].OrderingKey != "test/c" {
+		t.Errorf("ordering key = %q, want %q", got[0].OrderingKey, "test/c")
+	}
+}
+
+func TestRelay_DeadLetter(t *testing.T) {
+	relay, outbox, publisher, now := newTestRelay(t, "a.1")
+	add(t, outbox, "a", "a.1")
+	add(t, outbox, "a", "a.2")
+
+	// a.2 waits for a.1 until its last attempt fails and it is marked dead
+	for _, want := range []int{0, 0, 1} {
+		drain(t, relay, want)
+		*now = now.Add(time.Minute)
+	}
+
+	var dead Message
+	if err := relay.db.Where("event = ?", "a.1").First(&dead).Error; err != nil {
+		t.Fatalf("failed to read message: %v", err)
+	}
+	if dead.Status != StatusDead || dead.Attempts != 3 || dead.LastError != "unavailable" {
+		t.Errorf("message = %+v, want dead after 3 attempts", dead)
+	}
+	if got := events(publisher.Messages()); len(got) != 1 || got[0] != "a.2" {
+		t.Errorf("published %v, want [a.2]", got)
+	}
+
+	// dead messages are not retried
+	drain(t, relay, 0)
+}
+
+// claimCheckingPublisher reads each message back while publishing it, which
+// only works when no transaction is open on the database.
+type claimCheckingPublisher struct {
+	*MemoryPublisher
+	t       *testing.T
+	relay   *Relay
+	claimed []bool
+}
+
+func (p *claimCheckingPublisher) PublishOrdered(ctx context.Context, event, orderingKey string, data []byte) error {
+	var m Message
+	if err := p.relay.db.Where("event = ?", event).First(&m).Error; err != nil {
+		p.t.Errorf("failed to read message while publishing: %v", err)
+	}
+	p.claimed = append(p.claimed, m.ClaimedUntil != nil)
+	return p.MemoryPublisher.PublishOrdered(ctx, event, orderingKey, data)
+}
+
+func TestRelay_PublishesOutsideTransaction(t *testing.T) {
+	relay, outbox, publisher, _ := newTestRelay(t)
+	checking := &claimCheckingPublisher{MemoryPublisher: publisher.MemoryPublisher, t: t, relay: relay}
+	relay.publisher = checking
+	add(t, outbox, "a", "a.1")
+	add(t, outbox, "b", "b.1")
+
+	drain(t, relay, 2)
+	if len(checking.claimed) != 2 || !checking.claimed[0] || !checking.claimed[1] {
+		t.Errorf("claimed while publishing = %v, want [true true]", checking.claimed)
+	}
+
+	var released int64
+	if err := relay.db.Model(&Message{}).Where("claimed_until IS NULL").Count(&released).Error; err != nil {
+		t.Fatalf("failed to count messages: %v", err)
+	}
+	if released != 2 {
+		t.Errorf("released %d messages, want 2", released)
+	}
+}
+
+func TestRelay_SkipsClaimedAggregates(t *testing.T) {
+	relay, outbox, publisher, now := newTestRelay(t)
+	add(t, outbox, "a", "a.1")
+	add(t, outbox, "a", "a.2")
+	add(t, outbox, "b", "b.1")
+
+	// another relay claimed a.1 and stopped before recording it
+	until := now.Add(relay.cfg.Lease)
+	if err := relay.db.Model(&Message{}).Where("event = ?", "a.1").Update("claimed_until", until).Error; err != nil {
+		t.Fatalf("failed to claim message: %v", err)
+	}
+
+	// a.2 waits for a.1 while it is claimed
+	drain(t, relay, 1)
+	if got := events(publisher.Messages()); len(got) != 1 || got[0] != "b.1" {
+		t.Fatalf("published %v, want [b.1]", got)
+	}
+
+	// the claim runs out and a.1 is published again in order
+	*now = until
+	drain(t, relay, 2)
+	if got := events(publisher.Messages()); len(got) != 3 || got[1] != "a.1" || got[2] != "a.2" {
+		t.Errorf("published %v, want [b.1 a.1 a.2]", got)
+	}
+}
+
+func TestRelay_ContinuesTrace(t *testing.T) {
+	provider, propagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	defer func() {
+		otel.SetTracerProvider(provider)
+		otel.SetTextMapPropagator(propagator)
+	}()
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	relay, outbox, _, _ := newTestRelay(t)
+	ctx, request := tracing.Tracer().Start(context.Background(), "request")
+	if err := outbox.Add(ctx, "test", "a", "a.1", []byte(`{}`)); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	request.End()
+	add(t, outbox, "b", "b.1")
+
+	drain(t, relay, 2)
+
+	parents := map[string]string{}
+	for _, span := range recorder.Ended() {
+		parents[span.Name()] = span.Parent().SpanID().String()
+		if span.Name() == "publish a.1" && span.SpanContext().TraceID() != request.SpanContext().TraceID() {
+			t.Errorf("publish a.1 trace = %s, want the trace of the request", span.SpanContext().TraceID())
+		}
+	}
+	if parents["publish a.1"] != request.SpanContext().SpanID().String() {
+		t.Errorf("publish a.1 parent = %s, want the request span", parents["publish a.1"])
+	}
+	// a message added outside of a trace is published in a trace of its own
+	if _, ok := parents["publish b.1"]; !ok || parents["publish b.1"] != "0000000000000000" {
+		t.Errorf("publish b.1 parent = %q, want none", parents["publish b.1"])
+	}
+}
+
+func TestExponentialBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{attempts: 1, want: time.Second},
+		{attempts: 2, want: 2 * time.Second},
+		{attempts: 5, want: 16 * time.Second},
+		{attempts: 9, want: 256 * time.Second},
+		{attempts: 10, want: 5 * time.Minute},
+		{attempts: 100, want: 5 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		if got := exponentialBackoff(tt.attempts); got != tt.want {
+			t.Errorf("exponentialBackoff(%d) = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package problem
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+
+	"{{cookiecutter.module_name}}/internal/logger"
+)
+
+// ContentType is the media type of problem details, RFC 9457.
+const ContentType = "application/problem+json"
+
+// typePrefix is the base of the type URI of registered problems, relative
+// to the API so it resolves against the request.
+const typePrefix = "/problems/"
+
+// Problem is an RFC 9457 problem details body.
+type Problem struct {
+	Type          string       `json:"type"`
+	Title         string       `json:"title"`
+	Status        int          `json:"status"`
+	Detail        string       `json:"detail,omitempty"`
+	Instance      string       `json:"instance,omitempty"`
+	CorrelationID string       `json:"correlation_id,omitempty"`
+	Errors        []FieldError `json:"errors,omitempty"`
+}
+
+// FieldError points at the part of a request that was rejected.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// fielder is implemented by errors that point at fields of a request, such
+// as query.ParamError. Their fields end up in Problem.Errors.
+type fielder interface {
+	Fields() map[string]string
+}
+
+// detailer is implemented by errors whose message is written for clients,
+// such as validation.Errors. Their detail replaces the one of their entry.
+type detailer interface {
+	Detail() string
+}
+
+// New returns a problem without a registered type for the request r, such as
+// an authentication failure. Its title is the status text.
+func New(r *http.Request, status int, detail string) Problem {
+	return Problem{
+		Type:          "about:blank",
+		Title:         http.StatusText(status),
+		Status:        status,
+		Detail:        detail,
+		Instance:      r.URL.Path,
+		CorrelationID: logger.CorrelationIDFromContext(r.Context()),
+	}
+}
+
+// Write writes p as the response.
+func Write(w http.ResponseWriter, p Problem) error {
+	w.Header().Set("Content-Type", ContentType)
+	w.WriteHeader(p.Status)
+	if err := json.NewEncoder(w).Encode(p); err != nil {
+		return fmt.Errorf("encode problem: %w", err)
+	}
+	return nil
+}
+
+// Match reports whether err is of a registered kind.
+type Match func(err error) bool
+
+// Is matches errors that wrap target.
+func Is(target error) Match {
+	return func(err error) bool {
+		return errors.Is(err, target)
+	}
+}
+
+// As matches errors that wrap an error of type T.
+func As[T error]() Match {
+	return func(err error) bool {
+		var target T
+		return errors.As(err, &target)
+	}
+}
+
+type entry struct {
+	match  Match
+	status int
+	slug   string
+	title  string
+	detail string
+}
+
+// Registry maps errors to problems. Entries are tried in the order they were
+// registered, errors that match none are internal errors.
+type Registry struct {
+	entries []entry
+}
+
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// Register answers errors that match with status. Every error of the entry
+// shares the type URI /problems/<slug>, title and detail. The message of the
+// error is never sent, it may wrap errors of the database or a decoder, only
+// an error in its chain that has a Detail replaces the detail of the entry.
+func (reg *Registry) Register(match Match, status int, slug, title, detail string) *Registry {
+	reg.entries = append(reg.entries, entry{match: match, status: status, slug: slug, title: title, detail: detail})
+	return reg
+}
+
+// Lookup returns the problem for err and whether err is registered. The
+// problem of an unregistered error is a 500 with fallback as its detail.
+func (reg *Registry) Lookup(r *http.Request, err error, fallback string) (Problem, bool) {
+	for _, e := range reg.entries {
+		if !e.match(err) {
+			continue
+		}
+		detail := e.detail
+		var d detailer
+		if errors.As(err, &d) {
+			detail = d.Detail()
+		}
+		p := New(r, e.status, detail)
+		p.Type = typePrefix + e.slug
+		p.Title = e.title
+		p.Errors = fieldErrors(err)
+		return p, true
+	}
+	return New(r, http.StatusInternalServerError, fallback), false
+}
+
+func fieldErrors(err error) []FieldError {
+	var f fielder
+	if !errors.As(err, &f) {
+		return nil
+	}
+	fields := f.Fields()
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	errs := make([]FieldError, len(names))
+	for i, name := range names {
+		errs[i] = FieldError{Field: name, Message: fields[name]}
+	}
+	return errs
+}
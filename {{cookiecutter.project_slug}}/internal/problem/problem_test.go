@@ -0,0 +1,146 @@
+package problem
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"{{cookiecutter.module_name}}/internal/logger"
+)
+
+var (
+	errMissing = errors.New("thing not found")
+	errBroken  = errors.New("thing is broken")
+)
+
+type fieldsError map[string]string
+
+func (e fieldsError) Error() string {
+	return "invalid fields"
+}
+
+func (e fieldsError) Fields() map[string]string {
+	return e
+}
+
+type detailError string
+
+func (e detailError) Error() string {
+	return "query thing: " + string(e)
+}
+
+func (e detailError) Detail() string {
+	return string(e)
+}
+
+func TestRegistry_Lookup(t *testing.T) {
+	registry := NewRegistry().
+		Register(Is(errMissing), http.StatusNotFound, "not-found", "Not Found", "thing not found").
+		Register(As[fieldsError](), http.StatusUnprocessableEntity, "validation", "Validation Failed", "invalid thing").
+		Register(As[detailError](), http.StatusBadRequest, "bad-query", "Bad Query", "invalid query")
+
+	tests := []struct {
+		name           string
+		err            error
+		wantRegistered bool
+		want           Problem
+	}{
+		{
+			name:           "Sentinel",
+			err:            errMissing,
+			wantRegistered: true,
+			want: Problem{
+				Type: "/problems/not-found", Title: "Not Found", Status: http.StatusNotFound,
+				Detail: "thing not found", Instance: "/things/1", CorrelationID: "correlation-1",
+			},
+		},
+		{
+			name:           "Wrapped Sentinel",
+			err:            fmt.Errorf("%w: select * from thing: %w", errMissing, errBroken),
+			wantRegistered: true,
+			want: Problem{
+				Type: "/problems/not-found", Title: "Not Found", Status: http.StatusNotFound,
+				Detail: "thing not found", Instance: "/things/1", CorrelationID: "correlation-1",
+			},
+		},
+		{
+			name:           "Error With Detail",
+			err:            fmt.Errorf("list things: %w", detailError("limit must be positive")),
+			wantRegistered: true,
+			want: Problem{
+				Type: "/problems/bad-query", Title: "Bad Query", Status: http.StatusBadRequest,
+				Detail: "limit must be positive", Instance: "/things/1", CorrelationID: "correlation-1",
+			},
+		},
+		{
+			name:           "Field Errors",
+			err:            fmt.Errorf("save: %w", fieldsError{"name": "is required", "age": "must be positive"}),
+			wantRegistered: true,
+			want: Problem{
+				Type: "/problems/validation", Title: "Validation Failed", Status: http.StatusUnprocessableEntity,
+				Detail: "invalid thing", Instance: "/things/1", CorrelationID: "correlation-1",
+				Errors: []FieldError{
+					{Field: "age", Message: "must be positive"},
+					{Field: "name", Message: "is required"},
+				},
+			},
+		},
+		{
+			name: "Unregistered",
+			err:  errBroken,
+			want: Problem{
+				Type: "about:blank", Title: "Internal Server Error", Status: http.StatusInternalServerError,
+				Detail: "failed to get thing", Instance: "/things/1", CorrelationID: "correlation-1",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/things/1", nil)
+			r = r.WithContext(logger.CorrelationIDToContext(r.Context(), "correlation-1"))
+
+			got, registered := registry.Lookup(r, tt.err, "failed to get thing")
+			if registered != tt.wantRegistered {
+				t.Errorf("Lookup() registered = %v, want %v", registered, tt.wantRegistered)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Lookup() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWrite(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/things", nil)
+	w := httptest.NewRecorder()
+
+	if err := Write(w, New(r, http.StatusTooManyRequests, "slow down")); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	if w.Code != http.StatusTooManyRequests {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusTooManyRequests)
+	}
+	if got := w.Header().Get("Content-Type"); got != ContentType {
+		t.Errorf("Content-Type = %q, want %q", got, ContentType)
+	}
+	var body map[string]any
+	if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+		t.Fatalf("failed to decode body: %v", err)
+	}
+	want := map[string]any{
+		"type":     "about:blank",
+		"title":    "Too Many Requests",
+		"status":   float64(http.StatusTooManyRequests),
+		"detail":   "slow down",
+		"instance": "/things",
+	}
+	if !reflect.DeepEqual(body, want) {
+		t.Errorf("body = %v, want %v", body, want)
+	}
+}
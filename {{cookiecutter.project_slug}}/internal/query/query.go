@@ -0,0 +1,199 @@
+package query
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Operator is a comparison a filter applies to a field.
+type Operator string
+
+const (
+	Eq    Operator = "eq"
+	Ne    Operator = "ne"
+	Lt    Operator = "lt"
+	Lte   Operator = "lte"
+	Gt    Operator = "gt"
+	Gte   Operator = "gte"
+	Like  Operator = "like"  // case sensitive substring match
+	ILike Operator = "ilike" // case insensitive substring match
+	In    Operator = "in"    // comma separated list of values
+)
+
+// Type tells the parser how to convert a filter value before it reaches the database.
+type Type int
+
+const (
+	String Type = iota
+	Time        // RFC 3339
+	UUID
+)
+
+// Field describes a field clients may filter or sort by.
+type Field struct {
+	Column   string
+	Type     Type
+	Ops      []Operator
+	Sortable bool
+}
+
+// Fields is the allowlist of an entity, keyed by the name used in the query string.
+type Fields map[string]Field
+
+// Filter is a validated filter ready to be turned into a WHERE clause.
+// Value holds the converted value, Values is used by In instead.
+type Filter struct {
+	Column string
+	Op     Operator
+	Value  any
+	Values []any
+}
+
+// Sort is a validated ORDER BY column.
+type Sort struct {
+	Column string
+	Desc   bool
+}
+
+// Params are the filters and sorts read from a query string.
+type Params struct {
+	Filters []Filter
+	Sorts   []Sort
+}
+
+// ParamError reports a query string parameter that could not be used.
+type ParamError struct {
+	Param  string
+	Reason string
+}
+
+func (e *ParamError) Error() string {
+	return fmt.Sprintf("invalid query parameter %q: %s", e.Param, e.Reason)
+}
+
+// Detail is the message of e, it names the parameter and what is wrong.
+func (e *ParamError) Detail() string {
+	return e.Error()
+}
+
+// Fields names the parameter, so it is reported as a field error.
+func (e *ParamError) Fields() map[string]string {
+	return map[string]string{e.Param: e.Reason}
+}
+
+// filter[field] or filter[field][op]
+var filterParam = regexp.MustCompile(`^filter\[([^\[\]]+)\](?:\[([^\[\]]+)\])?$`)
+
+// Parse reads filter[field][op]=value and sort=-field,field parameters from
+// values and checks them against fields. Parameters that are neither filters
+// nor sort, such as limit, are ignored.
+func Parse(values url.Values, fields Fields) (Params, error) {
+	var params Params
+
+	// read filters in a stable order so the generated SQL is deterministic
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if !strings.HasPrefix(key, "filter") {
+			continue
+		}
+		match := filterParam.FindStringSubmatch(key)
+		if match == nil {
+			return Params{}, &ParamError{Param: key, Reason: "expected filter[field] or filter[field][operator]"}
+		}
+
+		field, ok := fields[match[1]]
+		if !ok {
+			return Params{}, &ParamError{Param: key, Reason: fmt.Sprintf("unknown field %q", match[1])}
+		}
+		op := Eq
+		if match[2] != "" {
+			op = Operator(match[2])
+		}
+		if !field.allows(op) {
+			return Params{}, &ParamError{Param: key, Reason: fmt.Sprintf("operator %q is not supported for field %q", op, match[1])}
+		}
+
+		for _, raw := range values[key] {
+			filter, err := field.filter(op, raw)
+			if err != nil {
+				return Params{}, &ParamError{Param: key, Reason: err.Error()}
+			}
+			params.Filters = append(params.Filters, filter)
+		}
+	}
+
+	if raw := values.Get("sort"); raw != "" {
+		for _, name := range strings.Split(raw, ",") {
+			desc := strings.HasPrefix(name, "-")
+			name = strings.TrimPrefix(name, "-")
+
+			field, ok := fields[name]
+			if !ok || !field.Sortable {
+				return Params{}, &ParamError{Param: "sort", Reason: fmt.Sprintf("cannot sort by %q", name)}
+			}
+			params.Sorts = append(params.Sorts, Sort{Column: field.Column, Desc: desc})
+		}
+	}
+
+	return params, nil
+}
+
+func (f Field) allows(op Operator) bool {
+	for _, allowed := range f.Ops {
+		if allowed == op {
+			return true
+		}
+	}
+	return false
+}
+
+func (f Field) filter(op Operator, raw string) (Filter, error) {
+	filter := Filter{Column: f.Column, Op: op}
+	if op == In {
+		for _, part := range strings.Split(raw, ",") {
+			value, err := f.convert(part)
+			if err != nil {
+				return Filter{}, err
+			}
+			filter.Values = append(filter.Values, value)
+		}
+		return filter, nil
+	}
+
+	value, err := f.convert(raw)
+	if err != nil {
+		return Filter{}, err
+	}
+	filter.Value = value
+	return filter, nil
+}
+
+func (f Field) convert(raw string) (any, error) {
+	switch f.Type {
+	case Time:
+		t, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not an RFC 3339 time", raw)
+		}
+		return t, nil
+	case UUID:
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a UUID", raw)
+		}
+		return id, nil
+	default:
+		return raw, nil
+	}
+}
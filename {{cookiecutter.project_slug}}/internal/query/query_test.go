@@ -0,0 +1,143 @@
+package query
+
+import (
+	"errors"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var testFields = Fields{
+	"id":         {Column: "id", Type: UUID, Ops: []Operator{Eq, In}},
+	"name":       {Column: "name", Type: String, Ops: []Operator{Eq, ILike}, Sortable: true},
+	"created_at": {Column: "created_at", Type: Time, Ops: []Operator{Gt, Lt}, Sortable: true},
+}
+
+func TestParse(t *testing.T) {
+	id := uuid.New()
+	createdAt := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		query     string
+		want      Params
+		wantParam string
+	}{
+		{
+			name:  "no filters or sort",
+			query: "limit=10&offset=5",
+			want:  Params{},
+		},
+		{
+			name:  "default operator is eq",
+			query: "filter[name]=foo",
+			want: Params{
+				Filters: []Filter{
+					{Column: "name", Op: Eq, Value: "foo"},
+				},
+			},
+		},
+		{
+			name:  "explicit operator",
+			query: "filter[name][ilike]=foo",
+			want: Params{
+				Filters: []Filter{
+					{Column: "name", Op: ILike, Value: "foo"},
+				},
+			},
+		},
+		{
+			name:  "time values are parsed",
+			query: "filter[created_at][gt]=2025-01-02T03:04:05Z",
+			want: Params{
+				Filters: []Filter{
+					{Column: "created_at", Op: Gt, Value: createdAt},
+				},
+			},
+		},
+		{
+			name:  "in splits values",
+			query: "filter[id][in]=" + id.String() + "," + id.String(),
+			want: Params{
+				Filters: []Filter{
+					{Column: "id", Op: In, Values: []any{id, id}},
+				},
+			},
+		},
+		{
+			name:  "sort ascending and descending",
+			query: "sort=-created_at,name",
+			want: Params{
+				Sorts: []Sort{
+					{Column: "created_at", Desc: true},
+					{Column: "name"},
+				},
+			},
+		},
+		{
+			name:      "unknown field",
+			query:     "filter[password]=x",
+			wantParam: "filter[password]",
+		},
+		{
+			name:      "unsupported operator",
+			query:     "filter[name][gt]=x",
+			wantParam: "filter[name][gt]",
+		},
+		{
+			name:      "malformed filter",
+			query:     "filter[name=x",
+			wantParam: "filter[name",
+		},
+		{
+			name:      "invalid time",
+			query:     "filter[created_at][gt]=yesterday",
+			wantParam: "filter[created_at][gt]",
+		},
+		{
+			name:      "invalid uuid",
+			query:     "filter[id]=abc",
+			wantParam: "filter[id]",
+		},
+		{
+			name:      "unsortable field",
+			query:     "sort=id",
+			wantParam: "sort",
+		},
+		{
+			name:      "unknown sort field",
+			query:     "sort=-password",
+			wantParam: "sort",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, err := url.ParseQuery(tt.query)
+			if err != nil {
+				t.Fatalf("failed to parse query: %v", err)
+			}
+
+			got, err := Parse(values, testFields)
+			if tt.wantParam != "" {
+				var paramErr *ParamError
+				if !errors.As(err, &paramErr) {
+					t.Fatalf("Parse() error = %v, want *ParamError", err)
+				}
+				if paramErr.Param != tt.wantParam {
+					t.Errorf("Parse() param = %q, want %q", paramErr.Param, tt.wantParam)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
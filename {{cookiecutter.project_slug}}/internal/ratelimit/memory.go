@@ -0,0 +1,70 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// sweepInterval is how often stores drop buckets that are full again, a full
+// bucket is the same as no bucket.
+const sweepInterval = 10 * time.Minute
+
+type memoryBucket struct {
+	bucket
+	fullAt time.Time
+}
+
+// MemoryStore keeps buckets in memory. Limits are per instance, so a service
+// running several instances allows that many times the limit.
+type MemoryStore struct {
+	mu      sync.Mutex
+	buckets map[string]memoryBucket
+	swept   time.Time
+	now     func() time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return NewMemoryStoreWithClock(time.Now)
+}
+
+// NewMemoryStoreWithClock returns a MemoryStore that reads the time from now,
+// for tests that must not depend on the wall clock.
+func NewMemoryStoreWithClock(now func() time.Time) *MemoryStore {
+	return &MemoryStore{buckets: map[string]memoryBucket{}, now: now}
+}
+
+func (s *MemoryStore) Take(ctx context.Context, key string, limit Limit) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.sweep(now)
+
+	stored, ok := s.buckets[key]
+	if !ok {
+		stored.bucket = newBucket(limit, now)
+	}
+	b, result := stored.take(limit, now)
+	s.buckets[key] = memoryBucket{bucket: b, fullAt: now.Add(result.Reset)}
+	return result, nil
+}
+
+// Len returns the number of buckets held.
+func (s *MemoryStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.buckets)
+}
+
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Sub(s.swept) < sweepInterval {
+		return
+	}
+	s.swept = now
+	for key, b := range s.buckets {
+		if !b.fullAt.After(now) {
+			delete(s.buckets, key)
+		}
+	}
+}
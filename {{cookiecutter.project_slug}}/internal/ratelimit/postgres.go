@@ -0,0 +1,103 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// maxRetries is how often Take retries when another instance updated the
+// bucket in between.
+const maxRetries = 5
+
+// Bucket is a token bucket stored in the rate_limit_bucket table.
+type Bucket struct {
+	ID        string    `gorm:"primaryKey"`
+	Tokens    float64   `gorm:"not null"`
+	UpdatedAt time.Time `gorm:"not null;autoUpdateTime:false"`
+	FullAt    time.Time `gorm:"not null;index"`
+	Version   int64     `gorm:"not null"`
+}
+
+func (Bucket) TableName() string {
+	return "rate_limit_bucket"
+}
+
+// PostgresStore keeps buckets in the database, so every instance of the
+// service shares the same limits. Buckets are updated with optimistic
+// locking on Version, no row stays locked while a request is served.
+type PostgresStore struct {
+	db    *gorm.DB
+	mu    sync.Mutex
+	swept time.Time
+	now   func() time.Time
+}
+
+func NewPostgresStore(db *gorm.DB) *PostgresStore {
+	return &PostgresStore{db: db, now: time.Now}
+}
+
+func (s *PostgresStore) Take(ctx context.Context, key string, limit Limit) (Result, error) {
+	s.sweep(ctx)
+
+	for range maxRetries {
+		now := s.now()
+
+		var row Bucket
+		err := s.db.WithContext(ctx).Where("id = ?", key).Take(&row).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			b, result := newBucket(limit, now).take(limit, now)
+			row = Bucket{ID: key, Tokens: b.tokens, UpdatedAt: now, FullAt: now.Add(result.Reset), Version: 1}
+			// another instance may create the bucket first, then take from theirs
+			tx := s.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&row)
+			if tx.Error != nil {
+				return Result{}, fmt.Errorf("create rate limit bucket: %w", tx.Error)
+			}
+			if tx.RowsAffected == 1 {
+				return result, nil
+			}
+			continue
+		}
+		if err != nil {
+			return Result{}, fmt.Errorf("get rate limit bucket: %w", err)
+		}
+
+		b, result := bucket{tokens: row.Tokens, updated: row.UpdatedAt}.take(limit, now)
+		tx := s.db.WithContext(ctx).Model(&Bucket{}).
+			Where("id = ? AND version = ?", key, row.Version).
+			Updates(map[string]any{
+				"tokens":     b.tokens,
+				"updated_at": now,
+				"full_at":    now.Add(result.Reset),
+				"version":    row.Version + 1,
+			})
+		if tx.Error != nil {
+			return Result{}, fmt.Errorf("update rate limit bucket: %w", tx.Error)
+		}
+		if tx.RowsAffected == 1 {
+			return result, nil
+		}
+	}
+	return Result{}, ErrContention
+}
+
+// sweep deletes buckets that are full again. Each instance sweeps at most
+// once per sweepInterval.
+func (s *PostgresStore) sweep(ctx context.Context) {
+	s.mu.Lock()
+	now := s.now()
+	if now.Sub(s.swept) < sweepInterval {
+		s.mu.Unlock()
+		return
+	}
+	s.swept = now
+	s.mu.Unlock()
+
+	// best effort, a failed sweep is retried next interval
+	s.db.WithContext(ctx).Where("full_at <= ?", now).Delete(&Bucket{})
+}
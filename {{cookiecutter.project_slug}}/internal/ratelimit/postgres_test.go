@@ -0,0 +1,72 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+
+	"{{cookiecutter.module_name}}/internal/db"
+)
+
+func setupTestDB(t *testing.T) *gorm.DB {
+	db, err := db.MakeDbSqlite()
+	if err != nil {
+		t.Fatalf("failed to connect database: %v", err)
+	}
+
+	if err := db.AutoMigrate(&Bucket{}); err != nil {
+		t.Fatalf("failed to migrate database: %v", err)
+	}
+
+	return db
+}
+
+func TestPostgresStore(t *testing.T) {
+	store := NewPostgresStore(setupTestDB(t))
+	now := time.Now()
+	store.now = func() time.Time { return now }
+
+	testStore(t, store, &now)
+}
+
+func TestPostgresStore_SharedBetweenInstances(t *testing.T) {
+	db := setupTestDB(t)
+	ctx := context.Background()
+	limit := Limit{Requests: 2, Period: time.Minute}
+	first, second := NewPostgresStore(db), NewPostgresStore(db)
+
+	for i, store := range []*PostgresStore{first, second} {
+		if result, err := store.Take(ctx, "a", limit); err != nil || !result.Allowed {
+			t.Fatalf("request %d = %+v, %v, want allowed", i, result, err)
+		}
+	}
+	if result, err := first.Take(ctx, "a", limit); err != nil || result.Allowed {
+		t.Errorf("request over the shared limit = %+v, %v, want denied", result, err)
+	}
+}
+
+func TestPostgresStore_Sweep(t *testing.T) {
+	db := setupTestDB(t)
+	store := NewPostgresStore(db)
+	now := time.Now()
+	store.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	if _, err := store.Take(ctx, "a", Limit{Requests: 1, Period: time.Second}); err != nil {
+		t.Fatalf("Take() error = %v", err)
+	}
+	now = now.Add(sweepInterval)
+	if _, err := store.Take(ctx, "b", Limit{Requests: 1, Period: time.Second}); err != nil {
+		t.Fatalf("Take() error = %v", err)
+	}
+
+	var ids []string
+	if err := db.Model(&Bucket{}).Pluck("id", &ids).Error; err != nil {
+		t.Fatalf("failed to list buckets: %v", err)
+	}
+	if len(ids) != 1 || ids[0] != "b" {
+		t.Errorf("buckets = %v, want only b after sweep", ids)
+	}
+}
@@ -0,0 +1,76 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"math"
+	"time"
+)
+
+// ErrContention is returned when a bucket could not be updated because other
+// requests kept changing it.
+var ErrContention = errors.New("rate limit bucket contended")
+
+// Limit allows Requests requests per Period. Unused requests are saved up to
+// Requests, so a client that was idle can send a burst of that size.
+type Limit struct {
+	Requests int
+	Period   time.Duration
+}
+
+// perSecond is the rate tokens are added to a bucket at.
+func (l Limit) perSecond() float64 {
+	return float64(l.Requests) / l.Period.Seconds()
+}
+
+// Result is the outcome of taking a token.
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int           // requests that can be sent right away
+	Reset      time.Duration // time until the bucket is full again
+	RetryAfter time.Duration // time until the next request is allowed, zero when Allowed
+}
+
+// Store holds a token bucket per key. Take removes a token from the bucket
+// of key if there is one.
+type Store interface {
+	Take(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
+// bucket is the state of a token bucket. A bucket that was never used is full.
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+func newBucket(limit Limit, now time.Time) bucket {
+	return bucket{tokens: float64(limit.Requests), updated: now}
+}
+
+// take refills b for the time passed since it was last updated and takes a
+// token when there is one.
+func (b bucket) take(limit Limit, now time.Time) (bucket, Result) {
+	rate := limit.perSecond()
+	capacity := float64(limit.Requests)
+
+	// clocks of several instances may disagree, never refill backwards
+	elapsed := max(now.Sub(b.updated).Seconds(), 0)
+	tokens := min(capacity, b.tokens+elapsed*rate)
+
+	result := Result{Limit: limit.Requests}
+	if tokens >= 1 {
+		tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = seconds((1 - tokens) / rate)
+	}
+	result.Remaining = int(tokens)
+	result.Reset = seconds((capacity - tokens) / rate)
+
+	return bucket{tokens: tokens, updated: now}, result
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(math.Round(s * float64(time.Second)))
+}
@@ -0,0 +1,123 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestBucket_Take(t *testing.T) {
+	limit := Limit{Requests: 2, Period: 2 * time.Second}
+	start := time.Now()
+
+	tests := []struct {
+		name   string
+		bucket bucket
+		now    time.Time
+		want   Result
+	}{
+		{
+			name:   "Full",
+			bucket: newBucket(limit, start),
+			now:    start,
+			want:   Result{Allowed: true, Limit: 2, Remaining: 1, Reset: time.Second},
+		},
+		{
+			name:   "Last Token",
+			bucket: bucket{tokens: 1, updated: start},
+			now:    start,
+			want:   Result{Allowed: true, Limit: 2, Remaining: 0, Reset: 2 * time.Second},
+		},
+		{
+			name:   "Empty",
+			bucket: bucket{tokens: 0, updated: start},
+			now:    start,
+			want:   Result{Allowed: false, Limit: 2, Remaining: 0, Reset: 2 * time.Second, RetryAfter: time.Second},
+		},
+		{
+			name:   "Refilled",
+			bucket: bucket{tokens: 0, updated: start},
+			now:    start.Add(time.Second),
+			want:   Result{Allowed: true, Limit: 2, Remaining: 0, Reset: 2 * time.Second},
+		},
+		{
+			name:   "Refilled Up To Capacity",
+			bucket: bucket{tokens: 0, updated: start},
+			now:    start.Add(time.Hour),
+			want:   Result{Allowed: true, Limit: 2, Remaining: 1, Reset: time.Second},
+		},
+		{
+			name:   "Clock Behind",
+			bucket: bucket{tokens: 0, updated: start},
+			now:    start.Add(-time.Hour),
+			want:   Result{Allowed: false, Limit: 2, Remaining: 0, Reset: 2 * time.Second, RetryAfter: time.Second},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, got := tt.bucket.take(limit, tt.now)
+			if got != tt.want {
+				t.Errorf("take() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// testStore runs the behaviour every Store must have against store, which
+// must use *now as its clock.
+func testStore(t *testing.T, store Store, now *time.Time) {
+	t.Helper()
+	ctx := context.Background()
+	limit := Limit{Requests: 3, Period: time.Minute}
+
+	take := func(key string) Result {
+		t.Helper()
+		result, err := store.Take(ctx, key, limit)
+		if err != nil {
+			t.Fatalf("Take() error = %v", err)
+		}
+		return result
+	}
+
+	// a burst up to the limit is allowed
+	for i := range 3 {
+		if result := take("a"); !result.Allowed || result.Remaining != 2-i {
+			t.Fatalf("request %d = %+v, want allowed with %d remaining", i, result, 2-i)
+		}
+	}
+	result := take("a")
+	if result.Allowed || result.RetryAfter != 20*time.Second {
+		t.Fatalf("request over the limit = %+v, want denied for 20s", result)
+	}
+
+	// other keys have their own bucket
+	if result := take("b"); !result.Allowed {
+		t.Errorf("other key = %+v, want allowed", result)
+	}
+
+	// tokens come back over time
+	*now = now.Add(20 * time.Second)
+	if result := take("a"); !result.Allowed {
+		t.Errorf("request after refill = %+v, want allowed", result)
+	}
+	if result := take("a"); result.Allowed {
+		t.Errorf("second request after refill = %+v, want denied", result)
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	now := time.Now()
+	store := NewMemoryStoreWithClock(func() time.Time { return now })
+
+	testStore(t, store, &now)
+
+	// full buckets are dropped
+	now = now.Add(sweepInterval)
+	if _, err := store.Take(context.Background(), "c", Limit{Requests: 1, Period: time.Second}); err != nil {
+		t.Fatalf("Take() error = %v", err)
+	}
+	if got := store.Len(); got != 1 {
+		t.Errorf("Len() = %d, want 1 after sweep", got)
+	}
+}
@@ -0,0 +1,189 @@
+package repository
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// keyset pagination orders every listing by (created_at, id). id breaks ties
+// between rows created in the same instant so the order is total.
+const (
+	createdAtColumn = "created_at"
+	idColumn        = "id"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// CursorPage is a page of entities read with keyset pagination.
+// NextCursor and PrevCursor are opaque tokens, empty when there is no
+// page in that direction.
+type CursorPage[T any] struct {
+	Items      []T
+	NextCursor string
+	PrevCursor string
+}
+
+// cursor is the decoded form of an opaque pagination token. It points at the
+// row just outside the page, Backward tells which side of it to read.
+type cursor struct {
+	CreatedAt time.Time `json:"c"`
+	ID        string    `json:"i"`
+	Backward  bool      `json:"b,omitempty"`
+}
+
+func (c cursor) encode() (string, error) {
+	b, err := json.Marshal(c)
+	if err != nil {
+		return "", fmt.Errorf("encode cursor: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func decodeCursor(token string) (cursor, error) {
+	var c cursor
+	b, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return c, ErrInvalidCursor
+	}
+	if err := json.Unmarshal(b, &c); err != nil || c.ID == "" || c.CreatedAt.IsZero() {
+		return c, ErrInvalidCursor
+	}
+	return c, nil
+}
+
+// orderByKeyset orders a query by the keyset columns.
+func orderByKeyset(desc bool) Scope {
+	return func(db *gorm.DB) *gorm.DB {
+		if desc {
+			return db.Order(createdAtColumn + " DESC").Order(idColumn + " DESC")
+		}
+		return db.Order(createdAtColumn).Order(idColumn)
+	}
+}
+
+// ListByCursor retrieves a page of entities ordered by (created_at, id).
+// An empty token starts at the first row. T must have created_at and id columns.
+func (r *EntityRepository[T]) ListByCursor(ctx context.Context, limit int, token string, scopes ...Scope) (*CursorPage[T], error) {
+	var c cursor
+	if token != "" {
+		var err error
+		if c, err = decodeCursor(token); err != nil {
+			return nil, err
+		}
+	}
+
+	query := r.conn(ctx).Scopes(toGormScopes(scopes)...)
+	if token != "" {
+		op := ">"
+		if c.Backward {
+			op = "<"
+		}
+		query = query.Where(
+			fmt.Sprintf("(%[1]s %[3]s ? OR (%[1]s = ? AND %[2]s %[3]s ?))", createdAtColumn, idColumn, op),
+			c.CreatedAt, c.CreatedAt, c.ID,
+		)
+	}
+
+	// read one extra row to find out whether another page follows
+	var entities []T
+	if err := query.Scopes(orderByKeyset(c.Backward)).Limit(limit + 1).Find(&entities).Error; err != nil {
+		return nil, err
+	}
+
+	hasMore := len(entities) > limit
+	if hasMore {
+		entities = entities[:limit]
+	}
+	if c.Backward {
+		for i, j := 0, len(entities)-1; i < j; i, j = i+1, j-1 {
+			entities[i], entities[j] = entities[j], entities[i]
+		}
+	}
+
+	page := &CursorPage[T]{Items: entities}
+	if len(entities) == 0 {
+		return page, nil
+	}
+
+	// going forward there is a next page only if the extra row was found,
+	// going backward the page we came from always follows
+	if hasMore || c.Backward {
+		next, err := r.cursorAt(ctx, &entities[len(entities)-1], false)
+		if err != nil {
+			return nil, err
+		}
+		page.NextCursor = next
+	}
+	if (hasMore && c.Backward) || (token != "" && !c.Backward) {
+		prev, err := r.cursorAt(ctx, &entities[0], true)
+		if err != nil {
+			return nil, err
+		}
+		page.PrevCursor = prev
+	}
+	return page, nil
+}
+
+// Each reads every entity in (created_at, id) order, batchSize at a time,
+// and calls fn with each batch. Batches are keyset pages, so memory holds one
+// batch however many rows there are and rows are neither skipped nor repeated
+// while others are written. It stops at the first error of fn and when ctx is
+// done.
+func (r *EntityRepository[T]) Each(ctx context.Context, batchSize int, fn func(batch []T) error, scopes ...Scope) error {
+	token := ""
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		page, err := r.ListByCursor(ctx, batchSize, token, scopes...)
+		if err != nil {
+			return err
+		}
+		if len(page.Items) > 0 {
+			if err := fn(page.Items); err != nil {
+				return err
+			}
+		}
+		if page.NextCursor == "" {
+			return nil
+		}
+		token = page.NextCursor
+	}
+}
+
+// cursorAt builds a token pointing at entity, reading the keyset columns
+// through the GORM schema so any entity type can be paginated.
+func (r *EntityRepository[T]) cursorAt(ctx context.Context, entity *T, backward bool) (string, error) {
+	stmt := &gorm.Statement{DB: r.db}
+	if err := stmt.Parse(entity); err != nil {
+		return "", fmt.Errorf("parse schema: %w", err)
+	}
+
+	createdAtField := stmt.Schema.LookUpField(createdAtColumn)
+	idField := stmt.Schema.LookUpField(idColumn)
+	if createdAtField == nil || idField == nil {
+		return "", fmt.Errorf("%s has no %s or %s column", stmt.Schema.Name, createdAtColumn, idColumn)
+	}
+
+	value := reflect.ValueOf(entity).Elem()
+	createdAt, _ := createdAtField.ValueOf(ctx, value)
+	id, _ := idField.ValueOf(ctx, value)
+
+	createdAtTime, ok := createdAt.(time.Time)
+	if !ok {
+		return "", fmt.Errorf("%s.%s is not a time.Time", stmt.Schema.Name, createdAtColumn)
+	}
+
+	return cursor{
+		CreatedAt: createdAtTime,
+		ID:        fmt.Sprint(id),
+		Backward:  backward,
+	}.encode()
+}
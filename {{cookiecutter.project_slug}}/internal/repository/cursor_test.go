@@ -0,0 +1,179 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"{{cookiecutter.module_name}}/internal/entity"
+)
+
+func TestEntityRepository_ListByCursor(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewEntityRepository[entity.{{cookiecutter.entity_name}}](db)
+	ctx := context.Background()
+
+	// Seed data with distinct, increasing creation times
+	start := time.Now().Add(-time.Hour)
+	var seeded []*entity.{{cookiecutter.entity_name}}
+	for i := 0; i < 7; i++ {
+		p := entity.New{{cookiecutter.entity_name}}("{{cookiecutter.entity_name}}")
+		p.CreatedAt = start.Add(time.Duration(i) * time.Minute)
+		if err := repo.Create(ctx, p); err != nil {
+			t.Fatalf("failed to create {{cookiecutter.entity_name_lower}}: %v", err)
+		}
+		seeded = append(seeded, p)
+	}
+
+	assertPage := func(t *testing.T, page *CursorPage[entity.{{cookiecutter.entity_name}}], from, to int, wantNext, wantPrev bool) {
+		t.Helper()
+		if len(page.Items) != to-from {
+			t.Fatalf("got %d items, want %d", len(page.Items), to-from)
+		}
+		for i, item := range page.Items {
+			if item.ID != seeded[from+i].ID {
+				t.Errorf("item %d = %v, want %v", i, item.ID, seeded[from+i].ID)
+			}
+		}
+		if (page.NextCursor != "") != wantNext {
+			t.Errorf("NextCursor = %q, want present %v", page.NextCursor, wantNext)
+		}
+		if (page.PrevCursor != "") != wantPrev {
+			t.Errorf("PrevCursor = %q, want present %v", page.PrevCursor, wantPrev)
+		}
+	}
+
+	// walk forward through every page
+	first, err := repo.ListByCursor(ctx, 3, "")
+	if err != nil {
+		t.Fatalf("ListByCursor() error = %v", err)
+	}
+	assertPage(t, first, 0, 3, true, false)
+
+	second, err := repo.ListByCursor(ctx, 3, first.NextCursor)
+	if err != nil {
+		t.Fatalf("ListByCursor() error = %v", err)
+	}
+	assertPage(t, second, 3, 6, true, true)
+
+	last, err := repo.ListByCursor(ctx, 3, second.NextCursor)
+	if err != nil {
+		t.Fatalf("ListByCursor() error = %v", err)
+	}
+	assertPage(t, last, 6, 7, false, true)
+
+	// and back again
+	back, err := repo.ListByCursor(ctx, 3, last.PrevCursor)
+	if err != nil {
+		t.Fatalf("ListByCursor() error = %v", err)
+	}
+	assertPage(t, back, 3, 6, true, true)
+
+	backToStart, err := repo.ListByCursor(ctx, 3, back.PrevCursor)
+	if err != nil {
+		t.Fatalf("ListByCursor() error = %v", err)
+	}
+	assertPage(t, backToStart, 0, 3, true, false)
+
+	// rows inserted before the cursor do not shift the next page
+	inserted := entity.New{{cookiecutter.entity_name}}("Inserted")
+	inserted.CreatedAt = start.Add(-time.Minute)
+	if err := repo.Create(ctx, inserted); err != nil {
+		t.Fatalf("failed to create {{cookiecutter.entity_name_lower}}: %v", err)
+	}
+	again, err := repo.ListByCursor(ctx, 3, first.NextCursor)
+	if err != nil {
+		t.Fatalf("ListByCursor() error = %v", err)
+	}
+	assertPage(t, again, 3, 6, true, true)
+}
+
+func TestEntityRepository_ListByCursor_InvalidCursor(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewEntityRepository[entity.{{cookiecutter.entity_name}}](db)
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{name: "not base64", token: "%%%"},
+		{name: "not json", token: "bm90LWpzb24"},
+		{name: "missing fields", token: "e30"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := repo.ListByCursor(context.Background(), 3, tt.token)
+			if err != ErrInvalidCursor {
+				t.Errorf("ListByCursor() error = %v, want %v", err, ErrInvalidCursor)
+			}
+		})
+	}
+}
+
+func TestEntityRepository_Each(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewEntityRepository[entity.{{cookiecutter.entity_name}}](db)
+	ctx := context.Background()
+
+	start := time.Now().Add(-time.Hour)
+	var seeded []*entity.{{cookiecutter.entity_name}}
+	for i := 0; i < 7; i++ {
+		p := entity.New{{cookiecutter.entity_name}}("{{cookiecutter.entity_name}}")
+		p.CreatedAt = start.Add(time.Duration(i) * time.Minute)
+		if err := repo.Create(ctx, p); err != nil {
+			t.Fatalf("failed to create {{cookiecutter.entity_name_lower}}: %v", err)
+		}
+		seeded = append(seeded, p)
+	}
+
+	t.Run("Every Row In Batches", func(t *testing.T) {
+		var sizes []int
+		var seen []entity.{{cookiecutter.entity_name}}
+		err := repo.Each(ctx, 3, func(batch []entity.{{cookiecutter.entity_name}}) error {
+			sizes = append(sizes, len(batch))
+			seen = append(seen, batch...)
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("Each() error = %v", err)
+		}
+		if len(sizes) != 3 || sizes[0] != 3 || sizes[1] != 3 || sizes[2] != 1 {
+			t.Errorf("batch sizes = %v, want [3 3 1]", sizes)
+		}
+		if len(seen) != len(seeded) {
+			t.Fatalf("got %d rows, want %d", len(seen), len(seeded))
+		}
+		for i, p := range seen {
+			if p.ID != seeded[i].ID {
+				t.Errorf("row %d = %v, want %v", i, p.ID, seeded[i].ID)
+			}
+		}
+	})
+
+	t.Run("Stops At Error", func(t *testing.T) {
+		errStop := errors.New("stop")
+		calls := 0
+		err := repo.Each(ctx, 3, func(batch []entity.{{cookiecutter.entity_name}}) error {
+			calls++
+			return errStop
+		})
+		if !errors.Is(err, errStop) || calls != 1 {
+			t.Errorf("Each() error = %v after %d calls, want %v after 1", err, calls, errStop)
+		}
+	})
+
+	t.Run("Stops When Canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(ctx)
+		calls := 0
+		err := repo.Each(ctx, 3, func(batch []entity.{{cookiecutter.entity_name}}) error {
+			calls++
+			cancel()
+			return nil
+		})
+		if !errors.Is(err, context.Canceled) || calls != 1 {
+			t.Errorf("Each() error = %v after %d calls, want %v after 1", err, calls, context.Canceled)
+		}
+	})
+}
@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"{{cookiecutter.module_name}}/internal/query"
+)
+
+// likeEscaper escapes LIKE wildcards so filter values match literally.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// Where turns validated filters into WHERE clauses. Columns are quoted by GORM
+// and values are always bound as parameters.
+func Where(filters []query.Filter) Scope {
+	return func(db *gorm.DB) *gorm.DB {
+		for _, f := range filters {
+			column := clause.Column{Name: f.Column}
+			switch f.Op {
+			case query.Eq:
+				db = db.Where(clause.Eq{Column: column, Value: f.Value})
+			case query.Ne:
+				db = db.Where(clause.Neq{Column: column, Value: f.Value})
+			case query.Lt:
+				db = db.Where(clause.Lt{Column: column, Value: f.Value})
+			case query.Lte:
+				db = db.Where(clause.Lte{Column: column, Value: f.Value})
+			case query.Gt:
+				db = db.Where(clause.Gt{Column: column, Value: f.Value})
+			case query.Gte:
+				db = db.Where(clause.Gte{Column: column, Value: f.Value})
+			case query.In:
+				db = db.Where(clause.IN{Column: column, Values: f.Values})
+			case query.Like:
+				db = db.Where(clause.Expr{
+					SQL:  `? LIKE ? ESCAPE '\'`,
+					Vars: []any{column, contains(f.Value)},
+				})
+			case query.ILike:
+				// LOWER keeps this portable, SQLite has no ILIKE
+				db = db.Where(clause.Expr{
+					SQL:  `LOWER(?) LIKE LOWER(?) ESCAPE '\'`,
+					Vars: []any{column, contains(f.Value)},
+				})
+			default:
+				db.AddError(&query.ParamError{Param: f.Column, Reason: "unsupported operator " + string(f.Op)})
+			}
+		}
+		return db
+	}
+}
+
+// OrderBy turns validated sorts into ORDER BY columns.
+func OrderBy(sorts []query.Sort) Scope {
+	return func(db *gorm.DB) *gorm.DB {
+		for _, s := range sorts {
+			db = db.Order(clause.OrderByColumn{Column: clause.Column{Name: s.Column}, Desc: s.Desc})
+		}
+		return db
+	}
+}
+
+func contains(value any) string {
+	s, _ := value.(string)
+	return "%" + likeEscaper.Replace(s) + "%"
+}
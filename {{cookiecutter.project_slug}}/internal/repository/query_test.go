@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"{{cookiecutter.module_name}}/internal/entity"
+	"{{cookiecutter.module_name}}/internal/query"
+)
+
+func TestWhereAndOrderBy(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewEntityRepository[entity.{{cookiecutter.entity_name}}](db)
+	ctx := context.Background()
+
+	start := time.Now().Add(-time.Hour)
+	names := []string{"Alpha", "alphabet", "Beta", "100% done", "100 done"}
+	for i, name := range names {
+		p := entity.New{{cookiecutter.entity_name}}(name)
+		p.CreatedAt = start.Add(time.Duration(i) * time.Minute)
+		if err := repo.Create(ctx, p); err != nil {
+			t.Fatalf("failed to create {{cookiecutter.entity_name_lower}}: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name      string
+		filters   []query.Filter
+		sorts     []query.Sort
+		wantNames []string
+	}{
+		{
+			name: "eq",
+			filters: []query.Filter{
+				{Column: "name", Op: query.Eq, Value: "Beta"},
+			},
+			wantNames: []string{"Beta"},
+		},
+		{
+			name: "ilike is case insensitive",
+			filters: []query.Filter{
+				{Column: "name", Op: query.ILike, Value: "ALPHA"},
+			},
+			wantNames: []string{"Alpha", "alphabet"},
+		},
+		{
+			name: "like escapes wildcards",
+			filters: []query.Filter{
+				{Column: "name", Op: query.Like, Value: "100%"},
+			},
+			wantNames: []string{"100% done"},
+		},
+		{
+			name: "in",
+			filters: []query.Filter{
+				{Column: "name", Op: query.In, Values: []any{"Alpha", "Beta"}},
+			},
+			wantNames: []string{"Alpha", "Beta"},
+		},
+		{
+			name: "time range",
+			filters: []query.Filter{
+				{Column: "created_at", Op: query.Gte, Value: start.Add(3 * time.Minute)},
+			},
+			wantNames: []string{"100% done", "100 done"},
+		},
+		{
+			name: "sort descending",
+			filters: []query.Filter{
+				{Column: "name", Op: query.Ne, Value: "Beta"},
+			},
+			sorts: []query.Sort{
+				{Column: "created_at", Desc: true},
+			},
+			wantNames: []string{"100 done", "100% done", "alphabet", "Alpha"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, total, err := repo.ListWithCount(ctx, 10, 0, tt.sorts, Where(tt.filters))
+			if err != nil {
+				t.Fatalf("ListWithCount() error = %v", err)
+			}
+			if total != int64(len(tt.wantNames)) {
+				t.Errorf("ListWithCount() total = %d, want %d", total, len(tt.wantNames))
+			}
+			if len(got) != len(tt.wantNames) {
+				t.Fatalf("ListWithCount() got %d rows, want %d", len(got), len(tt.wantNames))
+			}
+			for i, p := range got {
+				if p.Name != tt.wantNames[i] {
+					t.Errorf("row %d name = %q, want %q", i, p.Name, tt.wantNames[i])
+				}
+			}
+		})
+	}
+}
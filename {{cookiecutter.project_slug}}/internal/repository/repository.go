@@ -2,18 +2,39 @@ package repository
 
 import (
 	"context"
+	"database/sql"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+
+	"{{cookiecutter.module_name}}/internal/entity"
+	"{{cookiecutter.module_name}}/internal/query"
 )
 
 // Repository defines the standard CRUD operations.
 type Repository[T any] interface {
 	Create(ctx context.Context, entity *T) error
-	GetByID(ctx context.Context, id uint) (*T, error)
+	CreateBatch(ctx context.Context, entities []*T, batchSize int) error
+	GetByID(ctx context.Context, id uuid.UUID) (*T, error)
 	Update(ctx context.Context, entity *T) error
-	Delete(ctx context.Context, id uint) error
+	Delete(ctx context.Context, id uuid.UUID) error
 	List(ctx context.Context, limit, offset int) ([]T, error)
+	ListWithCount(ctx context.Context, limit, offset int, sorts []query.Sort, scopes ...Scope) ([]T, int64, error)
+	ListByCursor(ctx context.Context, limit int, token string, scopes ...Scope) (*CursorPage[T], error)
+	Each(ctx context.Context, batchSize int, fn func(batch []T) error, scopes ...Scope) error
+}
+
+// Scope narrows a query, typically with a WHERE clause. Scopes passed to
+// ListWithCount are applied to both the count and the page so the total
+// always describes the same set of rows as the page.
+type Scope func(db *gorm.DB) *gorm.DB
+
+func toGormScopes(scopes []Scope) []func(*gorm.DB) *gorm.DB {
+	gormScopes := make([]func(*gorm.DB) *gorm.DB, len(scopes))
+	for i, scope := range scopes {
+		gormScopes[i] = scope
+	}
+	return gormScopes
 }
 
 // EntityRepository is a generic implementation of the Repository interface using GORM.
@@ -21,6 +42,8 @@ type EntityRepository[T any] struct {
 	db *gorm.DB
 }
 
+var _ Repository[entity.{{cookiecutter.entity_name}}] = (*EntityRepository[entity.{{cookiecutter.entity_name}}])(nil)
+
 // NewEntityRepository creates a new instance of EntityRepository.
 func NewEntityRepository[T any](db *gorm.DB) *EntityRepository[T] {
 	return &EntityRepository[T]{db: db}
@@ -28,13 +51,26 @@ func NewEntityRepository[T any](db *gorm.DB) *EntityRepository[T] {
 
 // Create inserts a new entity into the database.
 func (r *EntityRepository[T]) Create(ctx context.Context, entity *T) error {
-	return r.db.WithContext(ctx).Create(entity).Error
+	return r.conn(ctx).Create(entity).Error
+}
+
+// CreateBatch inserts entities with one INSERT per batchSize of them. The
+// batches run in a transaction, a failing batch leaves no entity inserted. A
+// batchSize of zero or less inserts them all with one INSERT.
+func (r *EntityRepository[T]) CreateBatch(ctx context.Context, entities []*T, batchSize int) error {
+	if len(entities) == 0 {
+		return nil
+	}
+	if batchSize <= 0 {
+		batchSize = len(entities)
+	}
+	return r.conn(ctx).CreateInBatches(entities, batchSize).Error
 }
 
 // GetByID retrieves an entity by its ID.
 func (r *EntityRepository[T]) GetByID(ctx context.Context, id uuid.UUID) (*T, error) {
 	var entity T
-	if err := r.db.WithContext(ctx).First(&entity, id).Error; err != nil {
+	if err := r.conn(ctx).First(&entity, id).Error; err != nil {
 		return nil, err
 	}
 	return &entity, nil
@@ -42,20 +78,72 @@ func (r *EntityRepository[T]) GetByID(ctx context.Context, id uuid.UUID) (*T, er
 
 // Update modifies an existing entity in the database.
 func (r *EntityRepository[T]) Update(ctx context.Context, entity *T) error {
-	return r.db.WithContext(ctx).Save(entity).Error
+	return r.conn(ctx).Save(entity).Error
+}
+
+// UpdateColumns writes only columns of the entity with the given ID and
+// leaves every other column untouched. It returns gorm.ErrRecordNotFound
+// when no row was updated.
+func (r *EntityRepository[T]) UpdateColumns(ctx context.Context, id uuid.UUID, columns map[string]any) error {
+	var entity T
+	result := r.conn(ctx).Model(&entity).Where("id = ?", id).Updates(columns)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
 }
 
-// Delete removes an entity from the database by its ID.
+// Delete removes an entity from the database by its ID. Entities with a
+// gorm.DeletedAt field are soft deleted, see Restore and Purge.
+// It returns gorm.ErrRecordNotFound when no row was deleted.
 func (r *EntityRepository[T]) Delete(ctx context.Context, id uuid.UUID) error {
 	var entity T
-	return r.db.WithContext(ctx).Delete(&entity, id).Error
+	result := r.conn(ctx).Delete(&entity, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
 }
 
-// List retrieves entities from the database with pagination.
+// List retrieves entities from the database with pagination, ordered by (created_at, id).
 func (r *EntityRepository[T]) List(ctx context.Context, limit int, offset int) ([]T, error) {
 	var entities []T
-	if err := r.db.WithContext(ctx).Limit(limit).Offset(offset).Find(&entities).Error; err != nil {
+	if err := r.conn(ctx).Scopes(orderByKeyset(false)).Limit(limit).Offset(offset).Find(&entities).Error; err != nil {
 		return nil, err
 	}
 	return entities, nil
 }
+
+// ListWithCount retrieves a page of entities together with the total number of
+// rows matching the scopes, ignoring limit and offset. The page is ordered by
+// sorts and then by (created_at, id) so pages are stable. The count and the
+// page are read in a REPEATABLE READ transaction so both see the same
+// snapshot. Within a transaction of UnitOfWork they are read at the
+// isolation level of that transaction instead.
+func (r *EntityRepository[T]) ListWithCount(ctx context.Context, limit int, offset int, sorts []query.Sort, scopes ...Scope) ([]T, int64, error) {
+	var entities []T
+	var total int64
+
+	gormScopes := toGormScopes(scopes)
+	err := r.conn(ctx).Transaction(func(tx *gorm.DB) error {
+		var entity T
+		if err := tx.Model(&entity).Scopes(gormScopes...).Count(&total).Error; err != nil {
+			return err
+		}
+		// skip the page query when the offset is past the last row
+		if total == 0 || int64(offset) >= total {
+			return nil
+		}
+		return tx.Scopes(gormScopes...).Scopes(OrderBy(sorts), orderByKeyset(false)).Limit(limit).Offset(offset).Find(&entities).Error
+	}, &sql.TxOptions{Isolation: sql.LevelRepeatableRead})
+	if err != nil {
+		return nil, 0, err
+	}
+	return entities, total, nil
+}
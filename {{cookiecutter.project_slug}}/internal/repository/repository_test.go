@@ -58,6 +58,64 @@ func TestEntityRepository_Create(t *testing.T) {
 	}
 }
 
+func TestEntityRepository_CreateBatch(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewEntityRepository[entity.{{cookiecutter.entity_name}}](db)
+	ctx := context.Background()
+
+	count := func(t *testing.T) int64 {
+		t.Helper()
+		var n int64
+		if err := db.Model(&entity.{{cookiecutter.entity_name}}{}).Count(&n).Error; err != nil {
+			t.Fatalf("failed to count {{cookiecutter.entity_name_lower}}s: %v", err)
+		}
+		return n
+	}
+
+	var {{cookiecutter.entity_name_lower}}s []*entity.{{cookiecutter.entity_name}}
+	for i := 0; i < 5; i++ {
+		{{cookiecutter.entity_name_lower}}s = append({{cookiecutter.entity_name_lower}}s, entity.New{{cookiecutter.entity_name}}("{{cookiecutter.entity_name}}"))
+	}
+	if err := repo.CreateBatch(ctx, {{cookiecutter.entity_name_lower}}s, 2); err != nil {
+		t.Fatalf("CreateBatch() error = %v", err)
+	}
+	if n := count(t); n != 5 {
+		t.Errorf("got %d {{cookiecutter.entity_name_lower}}s, want 5", n)
+	}
+
+	// the third batch repeats an ID, the first two are rolled back with it
+	duplicate := entity.New{{cookiecutter.entity_name}}("Duplicate")
+	duplicate.ID = {{cookiecutter.entity_name_lower}}s[0].ID
+	failing := []*entity.{{cookiecutter.entity_name}}{
+		entity.New{{cookiecutter.entity_name}}("A"),
+		entity.New{{cookiecutter.entity_name}}("B"),
+		entity.New{{cookiecutter.entity_name}}("C"),
+		entity.New{{cookiecutter.entity_name}}("D"),
+		duplicate,
+	}
+	if err := repo.CreateBatch(ctx, failing, 2); err == nil {
+		t.Fatal("expected an error for a duplicate ID")
+	}
+	if n := count(t); n != 5 {
+		t.Errorf("got %d {{cookiecutter.entity_name_lower}}s after a failed batch, want 5", n)
+	}
+
+	if err := repo.CreateBatch(ctx, nil, 2); err != nil {
+		t.Errorf("CreateBatch() of nothing error = %v", err)
+	}
+
+	unbatched := []*entity.{{cookiecutter.entity_name}}{
+		entity.New{{cookiecutter.entity_name}}("E"),
+		entity.New{{cookiecutter.entity_name}}("F"),
+	}
+	if err := repo.CreateBatch(ctx, unbatched, 0); err != nil {
+		t.Fatalf("CreateBatch() with batch size 0 error = %v", err)
+	}
+	if n := count(t); n != 7 {
+		t.Errorf("got %d {{cookiecutter.entity_name_lower}}s after an unbatched insert, want 7", n)
+	}
+}
+
 func TestEntityRepository_GetByID(t *testing.T) {
 	db := setupTestDB(t)
 	repo := NewEntityRepository[entity.{{cookiecutter.entity_name}}](db)
@@ -147,6 +205,32 @@ func TestEntityRepository_Update(t *testing.T) {
 	}
 }
 
+func TestEntityRepository_UpdateColumns(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewEntityRepository[entity.{{cookiecutter.entity_name}}](db)
+	ctx := context.Background()
+
+	p := entity.New{{cookiecutter.entity_name}}("Original")
+	if err := repo.Create(ctx, p); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	if err := repo.UpdateColumns(ctx, p.ID, map[string]any{"name": "Updated"}); err != nil {
+		t.Fatalf("UpdateColumns() error = %v", err)
+	}
+	updated, err := repo.GetByID(ctx, p.ID)
+	if err != nil {
+		t.Fatalf("GetByID() error = %v", err)
+	}
+	if updated.Name != "Updated" || updated.Version != p.Version {
+		t.Errorf("UpdateColumns() = %q at version %d, want %q at version %d", updated.Name, updated.Version, "Updated", p.Version)
+	}
+
+	if err := repo.UpdateColumns(ctx, uuid.New(), map[string]any{"name": "Missing"}); err != gorm.ErrRecordNotFound {
+		t.Errorf("UpdateColumns() error = %v, want gorm.ErrRecordNotFound", err)
+	}
+}
+
 func TestEntityRepository_Delete(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -168,7 +252,7 @@ func TestEntityRepository_Delete(t *testing.T) {
 			setup: func(ctx context.Context, repo *EntityRepository[entity.{{cookiecutter.entity_name}}]) uuid.UUID {
 				return uuid.New()
 			},
-			wantErr: false, // GORM delete usually doesn't return error if record not found unless configured otherwise
+			wantErr: true, // no rows affected is reported as gorm.ErrRecordNotFound
 		},
 	}
 
@@ -251,3 +335,76 @@ func TestEntityRepository_List(t *testing.T) {
 		})
 	}
 }
+
+func TestEntityRepository_ListWithCount(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewEntityRepository[entity.{{cookiecutter.entity_name}}](db)
+	ctx := context.Background()
+
+	// Seed data
+	for i := 0; i < 15; i++ {
+		name := "Even"
+		if i%2 == 1 {
+			name = "Odd"
+		}
+		repo.Create(ctx, entity.New{{cookiecutter.entity_name}}(name))
+	}
+
+	evenOnly := func(db *gorm.DB) *gorm.DB {
+		return db.Where("name = ?", "Even")
+	}
+
+	tests := []struct {
+		name      string
+		limit     int
+		offset    int
+		scopes    []Scope
+		wantCount int
+		wantTotal int64
+	}{
+		{
+			name:      "first page",
+			limit:     10,
+			offset:    0,
+			wantCount: 10,
+			wantTotal: 15,
+		},
+		{
+			name:      "last page",
+			limit:     10,
+			offset:    10,
+			wantCount: 5,
+			wantTotal: 15,
+		},
+		{
+			name:      "past the end",
+			limit:     10,
+			offset:    20,
+			wantCount: 0,
+			wantTotal: 15,
+		},
+		{
+			name:      "scope applies to page and total",
+			limit:     5,
+			offset:    0,
+			scopes:    []Scope{evenOnly},
+			wantCount: 5,
+			wantTotal: 8,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, total, err := repo.ListWithCount(ctx, tt.limit, tt.offset, nil, tt.scopes...)
+			if err != nil {
+				t.Fatalf("ListWithCount() error = %v", err)
+			}
+			if len(got) != tt.wantCount {
+				t.Errorf("ListWithCount() got count = %v, want %v", len(got), tt.wantCount)
+			}
+			if total != tt.wantTotal {
+				t.Errorf("ListWithCount() got total = %v, want %v", total, tt.wantTotal)
+			}
+		})
+	}
+}
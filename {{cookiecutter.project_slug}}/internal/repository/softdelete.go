@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Soft delete is opt-in: an entity enables it by declaring a gorm.DeletedAt
+// field. GORM then sets deleted_at on Delete and hides those rows from every
+// query made through the repository unless it is Unscoped.
+
+var ErrSoftDeleteUnsupported = errors.New("entity does not support soft delete")
+
+// ErrNotDeleted is returned by Restore for entities that are not deleted.
+var ErrNotDeleted = errors.New("entity is not deleted")
+
+// Restore clears deleted_at on a soft deleted entity. It returns ErrNotDeleted
+// when the entity is not deleted, so callers can tell a restore that changed
+// nothing, and gorm.ErrRecordNotFound when no row has the ID.
+func (r *EntityRepository[T]) Restore(ctx context.Context, id uuid.UUID) error {
+	var entity T
+	if err := r.requireSoftDelete(&entity); err != nil {
+		return err
+	}
+
+	result := r.conn(ctx).Unscoped().Model(&entity).Where("id = ? AND deleted_at IS NOT NULL", id).Update("deleted_at", nil)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected > 0 {
+		return nil
+	}
+	var count int64
+	if err := r.conn(ctx).Model(&entity).Where("id = ?", id).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrNotDeleted
+	}
+	return gorm.ErrRecordNotFound
+}
+
+// Purge permanently removes an entity, whether or not it was soft deleted.
+// It returns gorm.ErrRecordNotFound when no row was removed.
+func (r *EntityRepository[T]) Purge(ctx context.Context, id uuid.UUID) error {
+	var entity T
+	result := r.conn(ctx).Unscoped().Delete(&entity, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *EntityRepository[T]) requireSoftDelete(entity *T) error {
+	stmt := &gorm.Statement{DB: r.db}
+	if err := stmt.Parse(entity); err != nil {
+		return fmt.Errorf("parse schema: %w", err)
+	}
+	field := stmt.Schema.LookUpField("deleted_at")
+	if field == nil || field.FieldType != reflect.TypeOf(gorm.DeletedAt{}) {
+		return ErrSoftDeleteUnsupported
+	}
+	return nil
+}
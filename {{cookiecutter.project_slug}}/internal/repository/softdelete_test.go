@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"{{cookiecutter.module_name}}/internal/entity"
+)
+
+func TestEntityRepository_SoftDelete(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewEntityRepository[entity.{{cookiecutter.entity_name}}](db)
+	ctx := context.Background()
+
+	kept := entity.New{{cookiecutter.entity_name}}("Kept")
+	deleted := entity.New{{cookiecutter.entity_name}}("Deleted")
+	for _, p := range []*entity.{{cookiecutter.entity_name}}{kept, deleted} {
+		if err := repo.Create(ctx, p); err != nil {
+			t.Fatalf("failed to create {{cookiecutter.entity_name_lower}}: %v", err)
+		}
+	}
+
+	if err := repo.Delete(ctx, deleted.ID); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+
+	// hidden from Get and List but still in the table
+	if _, err := repo.GetByID(ctx, deleted.ID); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("GetByID() error = %v, want %v", err, gorm.ErrRecordNotFound)
+	}
+	got, total, err := repo.ListWithCount(ctx, 10, 0, nil)
+	if err != nil {
+		t.Fatalf("ListWithCount() error = %v", err)
+	}
+	if total != 1 || len(got) != 1 || got[0].ID != kept.ID {
+		t.Errorf("ListWithCount() = %v (total %d), want only %v", got, total, kept.ID)
+	}
+	var stored entity.{{cookiecutter.entity_name}}
+	if err := db.Unscoped().First(&stored, deleted.ID).Error; err != nil {
+		t.Fatalf("expected soft deleted row to remain: %v", err)
+	}
+	if !stored.DeletedAt.Valid {
+		t.Error("expected deleted_at to be set")
+	}
+
+	// deleting twice reports not found
+	if err := repo.Delete(ctx, deleted.ID); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("Delete() twice error = %v, want %v", err, gorm.ErrRecordNotFound)
+	}
+
+	if err := repo.Restore(ctx, deleted.ID); err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+	if _, err := repo.GetByID(ctx, deleted.ID); err != nil {
+		t.Errorf("GetByID() after Restore() error = %v", err)
+	}
+	if err := repo.Restore(ctx, deleted.ID); !errors.Is(err, ErrNotDeleted) {
+		t.Errorf("Restore() twice error = %v, want %v", err, ErrNotDeleted)
+	}
+	if err := repo.Restore(ctx, uuid.New()); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("Restore() unknown ID error = %v, want %v", err, gorm.ErrRecordNotFound)
+	}
+
+	if err := repo.Purge(ctx, deleted.ID); err != nil {
+		t.Fatalf("Purge() error = %v", err)
+	}
+	if err := db.Unscoped().First(&stored, deleted.ID).Error; !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("expected purged row to be gone, got %v", err)
+	}
+	if err := repo.Purge(ctx, deleted.ID); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("Purge() twice error = %v, want %v", err, gorm.ErrRecordNotFound)
+	}
+}
+
+// hardDeleted has no gorm.DeletedAt field so it does not opt into soft delete.
+type hardDeleted struct {
+	ID   uuid.UUID `gorm:"primaryKey"`
+	Name string
+}
+
+func TestEntityRepository_HardDelete(t *testing.T) {
+	db := setupTestDB(t)
+	if err := db.AutoMigrate(&hardDeleted{}); err != nil {
+		t.Fatalf("failed to migrate schema: %v", err)
+	}
+	repo := NewEntityRepository[hardDeleted](db)
+	ctx := context.Background()
+
+	row := &hardDeleted{ID: uuid.New(), Name: "Gone"}
+	if err := repo.Create(ctx, row); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if err := repo.Delete(ctx, row.ID); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+
+	var count int64
+	db.Unscoped().Model(&hardDeleted{}).Count(&count)
+	if count != 0 {
+		t.Errorf("expected row to be removed, %d left", count)
+	}
+	if err := repo.Restore(ctx, row.ID); !errors.Is(err, ErrSoftDeleteUnsupported) {
+		t.Errorf("Restore() error = %v, want %v", err, ErrSoftDeleteUnsupported)
+	}
+}
@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+// UnitOfWork runs fn in a database transaction. Every EntityRepository called
+// with the context fn receives joins that transaction, so changes to several
+// entities commit or roll back together. The transaction rolls back when fn
+// returns an error or panics, the panic is re-raised after the rollback.
+// Calling WithTx again inside fn opens a savepoint: an error from the inner
+// fn only undoes the inner work and the outer fn decides what happens next.
+type UnitOfWork interface {
+	WithTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+type txKey struct{}
+
+type unitOfWork struct {
+	db *gorm.DB
+}
+
+// NewUnitOfWork creates a UnitOfWork for repositories that share db.
+func NewUnitOfWork(db *gorm.DB) UnitOfWork {
+	return &unitOfWork{db: db}
+}
+
+func (u *unitOfWork) WithTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return withTx(ctx, u.db, fn)
+}
+
+// WithTx makes every repository a UnitOfWork for the *gorm.DB it was built with.
+func (r *EntityRepository[T]) WithTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return withTx(ctx, r.db, fn)
+}
+
+// withTx relies on GORM for the bookkeeping: Transaction on a *gorm.DB that
+// is already in a transaction creates a savepoint instead, and both forms
+// roll back on error or panic.
+func withTx(ctx context.Context, db *gorm.DB, fn func(ctx context.Context) error) error {
+	return conn(ctx, db).Transaction(func(tx *gorm.DB) error {
+		return fn(context.WithValue(ctx, txKey{}, tx))
+	})
+}
+
+// conn returns the transaction carried by ctx, or db outside of WithTx.
+func conn(ctx context.Context, db *gorm.DB) *gorm.DB {
+	if tx, ok := ctx.Value(txKey{}).(*gorm.DB); ok {
+		return tx.WithContext(ctx)
+	}
+	return db.WithContext(ctx)
+}
+
+// conn is the connection every query of the repository runs on.
+func (r *EntityRepository[T]) conn(ctx context.Context) *gorm.DB {
+	return conn(ctx, r.db)
+}
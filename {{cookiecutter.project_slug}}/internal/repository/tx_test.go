@@ -0,0 +1,127 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"{{cookiecutter.module_name}}/internal/entity"
+)
+
+func TestWithTx(t *testing.T) {
+	db := setupTestDB(t)
+	if err := db.AutoMigrate(&hardDeleted{}); err != nil {
+		t.Fatalf("failed to migrate schema: %v", err)
+	}
+	{{cookiecutter.entity_name_lower}}s := NewEntityRepository[entity.{{cookiecutter.entity_name}}](db)
+	others := NewEntityRepository[hardDeleted](db)
+	uow := NewUnitOfWork(db)
+	ctx := context.Background()
+	errBoom := errors.New("boom")
+
+	exists := func(t *testing.T, id uuid.UUID) bool {
+		t.Helper()
+		_, err := {{cookiecutter.entity_name_lower}}s.GetByID(ctx, id)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			t.Fatalf("GetByID() error = %v", err)
+		}
+		return err == nil
+	}
+	otherExists := func(t *testing.T, id uuid.UUID) bool {
+		t.Helper()
+		_, err := others.GetByID(ctx, id)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			t.Fatalf("GetByID() error = %v", err)
+		}
+		return err == nil
+	}
+
+	t.Run("commits every repository", func(t *testing.T) {
+		p := entity.New{{cookiecutter.entity_name}}("Committed")
+		other := &hardDeleted{ID: uuid.New(), Name: "Committed"}
+		err := uow.WithTx(ctx, func(ctx context.Context) error {
+			if err := {{cookiecutter.entity_name_lower}}s.Create(ctx, p); err != nil {
+				return err
+			}
+			return others.Create(ctx, other)
+		})
+		if err != nil {
+			t.Fatalf("WithTx() error = %v", err)
+		}
+		if !exists(t, p.ID) || !otherExists(t, other.ID) {
+			t.Error("expected both rows to be committed")
+		}
+	})
+
+	t.Run("rolls back every repository on error", func(t *testing.T) {
+		p := entity.New{{cookiecutter.entity_name}}("Rolled Back")
+		other := &hardDeleted{ID: uuid.New(), Name: "Rolled Back"}
+		err := uow.WithTx(ctx, func(ctx context.Context) error {
+			if err := {{cookiecutter.entity_name_lower}}s.Create(ctx, p); err != nil {
+				return err
+			}
+			if err := others.Create(ctx, other); err != nil {
+				return err
+			}
+			return errBoom
+		})
+		if !errors.Is(err, errBoom) {
+			t.Fatalf("WithTx() error = %v, want %v", err, errBoom)
+		}
+		if exists(t, p.ID) || otherExists(t, other.ID) {
+			t.Error("expected both rows to be rolled back")
+		}
+	})
+
+	t.Run("rolls back on panic", func(t *testing.T) {
+		p := entity.New{{cookiecutter.entity_name}}("Panicked")
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Error("expected the panic to be re-raised")
+				}
+			}()
+			{{cookiecutter.entity_name_lower}}s.WithTx(ctx, func(ctx context.Context) error {
+				if err := {{cookiecutter.entity_name_lower}}s.Create(ctx, p); err != nil {
+					return err
+				}
+				panic("boom")
+			})
+		}()
+		if exists(t, p.ID) {
+			t.Error("expected the row to be rolled back")
+		}
+	})
+
+	t.Run("nested call rolls back to its savepoint", func(t *testing.T) {
+		outer := entity.New{{cookiecutter.entity_name}}("Outer")
+		inner := entity.New{{cookiecutter.entity_name}}("Inner")
+		err := uow.WithTx(ctx, func(ctx context.Context) error {
+			if err := {{cookiecutter.entity_name_lower}}s.Create(ctx, outer); err != nil {
+				return err
+			}
+			err := uow.WithTx(ctx, func(ctx context.Context) error {
+				if err := {{cookiecutter.entity_name_lower}}s.Create(ctx, inner); err != nil {
+					return err
+				}
+				return errBoom
+			})
+			if !errors.Is(err, errBoom) {
+				t.Errorf("nested WithTx() error = %v, want %v", err, errBoom)
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("WithTx() error = %v", err)
+		}
+		if !exists(t, outer.ID) {
+			t.Error("expected the outer row to be committed")
+		}
+		if exists(t, inner.ID) {
+			t.Error("expected the inner row to be rolled back")
+		}
+	})
+}
@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"reflect"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// Optimistic concurrency is opt-in: an entity enables it by declaring an
+// integer version column. UpdateIfVersion and DeleteIfVersion only touch the
+// row when its version still matches, the check is part of the statement's
+// WHERE clause so nothing can change the row between the check and the write.
+
+const versionColumn = "version"
+
+var ErrVersionConflict = errors.New("version conflict")
+
+// UpdateIfVersion saves every field of entity when the stored version equals
+// expected and bumps the version on entity and in the database. It returns
+// ErrVersionConflict when the row has moved on and gorm.ErrRecordNotFound
+// when there is no row to update.
+func (r *EntityRepository[T]) UpdateIfVersion(ctx context.Context, entity *T, expected int64) error {
+	sch, field, err := r.versionField(entity)
+	if err != nil {
+		return err
+	}
+
+	value := reflect.ValueOf(entity).Elem()
+	if err := field.Set(ctx, value, expected+1); err != nil {
+		return fmt.Errorf("set version: %w", err)
+	}
+
+	// Select("*") writes zero values too, like Save, but never falls back to an INSERT
+	result := r.conn(ctx).Model(entity).Where(versionColumn+" = ?", expected).Select("*").Updates(entity)
+	if result.Error == nil && result.RowsAffected == 0 {
+		id, _ := sch.PrioritizedPrimaryField.ValueOf(ctx, value)
+		result.Error = r.conflictOrNotFound(ctx, id)
+	}
+	if result.Error != nil {
+		// leave the caller's copy at the version it read
+		field.Set(ctx, value, expected)
+		return result.Error
+	}
+	return nil
+}
+
+// UpdateColumnsIfVersion is the partial form of UpdateIfVersion: it writes
+// only columns, along with the bumped version and updated_at, and leaves
+// every other column untouched. Errors are the same as UpdateIfVersion.
+func (r *EntityRepository[T]) UpdateColumnsIfVersion(ctx context.Context, id uuid.UUID, expected int64, columns map[string]any) error {
+	var entity T
+	if _, _, err := r.versionField(&entity); err != nil {
+		return err
+	}
+
+	values := maps.Clone(columns)
+	if values == nil {
+		values = map[string]any{}
+	}
+	values[versionColumn] = gorm.Expr(versionColumn+" + ?", 1)
+
+	result := r.conn(ctx).Model(&entity).Where("id = ? AND "+versionColumn+" = ?", id, expected).Updates(values)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return r.conflictOrNotFound(ctx, id)
+	}
+	return nil
+}
+
+// DeleteIfVersion deletes an entity by its ID when the stored version equals
+// expected. Errors are the same as UpdateIfVersion.
+func (r *EntityRepository[T]) DeleteIfVersion(ctx context.Context, id uuid.UUID, expected int64) error {
+	var entity T
+	if _, _, err := r.versionField(&entity); err != nil {
+		return err
+	}
+
+	result := r.conn(ctx).Where(versionColumn+" = ?", expected).Delete(&entity, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return r.conflictOrNotFound(ctx, id)
+	}
+	return nil
+}
+
+// conflictOrNotFound explains why a versioned write affected no rows.
+func (r *EntityRepository[T]) conflictOrNotFound(ctx context.Context, id any) error {
+	var entity T
+	var count int64
+	if err := r.conn(ctx).Model(&entity).Where("id = ?", id).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return ErrVersionConflict
+}
+
+func (r *EntityRepository[T]) versionField(entity *T) (*schema.Schema, *schema.Field, error) {
+	stmt := &gorm.Statement{DB: r.db}
+	if err := stmt.Parse(entity); err != nil {
+		return nil, nil, fmt.Errorf("parse schema: %w", err)
+	}
+	field := stmt.Schema.LookUpField(versionColumn)
+	if field == nil {
+		return nil, nil, fmt.Errorf("%s has no %s column", stmt.Schema.Name, versionColumn)
+	}
+	return stmt.Schema, field, nil
+}
@@ -0,0 +1,157 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"{{cookiecutter.module_name}}/internal/entity"
+)
+
+func TestEntityRepository_UpdateIfVersion(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewEntityRepository[entity.{{cookiecutter.entity_name}}](db)
+	ctx := context.Background()
+
+	p := entity.New{{cookiecutter.entity_name}}("Original")
+	if err := repo.Create(ctx, p); err != nil {
+		t.Fatalf("failed to create {{cookiecutter.entity_name_lower}}: %v", err)
+	}
+
+	// two clients read the same version
+	first, err := repo.GetByID(ctx, p.ID)
+	if err != nil {
+		t.Fatalf("GetByID() error = %v", err)
+	}
+	second, err := repo.GetByID(ctx, p.ID)
+	if err != nil {
+		t.Fatalf("GetByID() error = %v", err)
+	}
+
+	first.Name = "First"
+	if err := repo.UpdateIfVersion(ctx, first, 1); err != nil {
+		t.Fatalf("UpdateIfVersion() error = %v", err)
+	}
+	if first.Version != 2 {
+		t.Errorf("UpdateIfVersion() version = %d, want 2", first.Version)
+	}
+
+	second.Name = "Second"
+	if err := repo.UpdateIfVersion(ctx, second, 1); !errors.Is(err, ErrVersionConflict) {
+		t.Fatalf("UpdateIfVersion() error = %v, want %v", err, ErrVersionConflict)
+	}
+	if second.Version != 1 {
+		t.Errorf("UpdateIfVersion() left version = %d, want 1", second.Version)
+	}
+
+	stored, err := repo.GetByID(ctx, p.ID)
+	if err != nil {
+		t.Fatalf("GetByID() error = %v", err)
+	}
+	if stored.Name != "First" || stored.Version != 2 {
+		t.Errorf("stored = %q at version %d, want %q at version 2", stored.Name, stored.Version, "First")
+	}
+
+	missing := entity.New{{cookiecutter.entity_name}}("Missing")
+	if err := repo.UpdateIfVersion(ctx, missing, 1); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("UpdateIfVersion() missing error = %v, want %v", err, gorm.ErrRecordNotFound)
+	}
+}
+
+func TestEntityRepository_UpdateColumnsIfVersion(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewEntityRepository[entity.{{cookiecutter.entity_name}}](db)
+	ctx := context.Background()
+
+	p := entity.New{{cookiecutter.entity_name}}("Original")
+	if err := repo.Create(ctx, p); err != nil {
+		t.Fatalf("failed to create {{cookiecutter.entity_name_lower}}: %v", err)
+	}
+
+	if err := repo.UpdateColumnsIfVersion(ctx, p.ID, 1, map[string]any{"name": "Patched"}); err != nil {
+		t.Fatalf("UpdateColumnsIfVersion() error = %v", err)
+	}
+	stored, err := repo.GetByID(ctx, p.ID)
+	if err != nil {
+		t.Fatalf("GetByID() error = %v", err)
+	}
+	if stored.Name != "Patched" || stored.Version != 2 {
+		t.Errorf("stored = %q at version %d, want %q at version 2", stored.Name, stored.Version, "Patched")
+	}
+	if !stored.CreatedAt.Equal(p.CreatedAt) {
+		t.Errorf("CreatedAt = %v, want it untouched at %v", stored.CreatedAt, p.CreatedAt)
+	}
+	if !stored.UpdatedAt.After(p.UpdatedAt) {
+		t.Errorf("UpdatedAt = %v, want it after %v", stored.UpdatedAt, p.UpdatedAt)
+	}
+
+	if err := repo.UpdateColumnsIfVersion(ctx, p.ID, 1, map[string]any{"name": "Lost Update"}); !errors.Is(err, ErrVersionConflict) {
+		t.Errorf("UpdateColumnsIfVersion() stale error = %v, want %v", err, ErrVersionConflict)
+	}
+	if err := repo.UpdateColumnsIfVersion(ctx, uuid.New(), 1, map[string]any{"name": "Missing"}); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("UpdateColumnsIfVersion() missing error = %v, want %v", err, gorm.ErrRecordNotFound)
+	}
+}
+
+func TestEntityRepository_DeleteIfVersion(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewEntityRepository[entity.{{cookiecutter.entity_name}}](db)
+	ctx := context.Background()
+
+	p := entity.New{{cookiecutter.entity_name}}("To Delete")
+	if err := repo.Create(ctx, p); err != nil {
+		t.Fatalf("failed to create {{cookiecutter.entity_name_lower}}: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		id      uuid.UUID
+		version int64
+		wantErr error
+	}{
+		{
+			name:    "stale version",
+			id:      p.ID,
+			version: 2,
+			wantErr: ErrVersionConflict,
+		},
+		{
+			name:    "current version",
+			id:      p.ID,
+			version: 1,
+		},
+		{
+			name:    "already deleted",
+			id:      p.ID,
+			version: 1,
+			wantErr: gorm.ErrRecordNotFound,
+		},
+		{
+			name:    "unknown id",
+			id:      uuid.New(),
+			version: 1,
+			wantErr: gorm.ErrRecordNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := repo.DeleteIfVersion(ctx, tt.id, tt.version)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("DeleteIfVersion() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestEntityRepository_VersionUnsupported(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewEntityRepository[hardDeleted](db)
+
+	if err := repo.DeleteIfVersion(context.Background(), uuid.New(), 1); err == nil {
+		t.Error("DeleteIfVersion() error = nil, want missing column error")
+	}
+}
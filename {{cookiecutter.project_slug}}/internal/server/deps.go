@@ -3,24 +3,90 @@ package server
 import (
 	"context"
 	"log/slog"
+	"net/http"
+	"time"
 
+	"{{cookiecutter.module_name}}/internal/auth"
 	"{{cookiecutter.module_name}}/internal/config"
 	"{{cookiecutter.module_name}}/internal/entity"
+	"{{cookiecutter.module_name}}/internal/errorreport"
+	"{{cookiecutter.module_name}}/internal/event"
+	"{{cookiecutter.module_name}}/internal/idempotency"
+	"{{cookiecutter.module_name}}/internal/metrics"
+	"{{cookiecutter.module_name}}/internal/middleware"
+	"{{cookiecutter.module_name}}/internal/outbox"
+	"{{cookiecutter.module_name}}/internal/ratelimit"
 	"{{cookiecutter.module_name}}/internal/repository"
 	"{{cookiecutter.module_name}}/internal/service"
+	"{{cookiecutter.module_name}}/internal/tracing"
 
 	"gorm.io/gorm"
 )
 
 type Dependencies struct {
-	{{cookiecutter.entity_name}}Service service.{{cookiecutter.entity_name}}Service
+	{{cookiecutter.entity_name}}Service     service.{{cookiecutter.entity_name}}Service
+	AdminToken         string
+	Verifier           middleware.TokenVerifier // checks bearer tokens on the API routes, they are open when nil
+	APIKeyService      service.APIKeyService    // mints API keys, the admin routes are not registered when nil
+	APIKeys            middleware.TokenVerifier // checks API keys on the API routes, only bearer tokens are accepted when nil
+	RateLimits         ratelimit.Store          // holds the rate limits of the API routes, they are not limited when nil
+	Reporter           errorreport.Reporter     // receives panics recovered from handlers, they are only logged when nil
+	BodyLimits         middleware.BodyLimits    // limits of request bodies, zero fields use middleware.DefaultBodyLimits
+	MaxBatchOperations int                      // operations a batch request may hold, handler.DefaultMaxBatchOperations when zero
+	MaxPageSize        int                      // {{cookiecutter.entity_name_lower}}s a list page may hold, handler.DefaultMaxPageSize when zero
+	Idempotency        idempotency.Store        // keeps responses for Idempotency-Key retries, the header is ignored when nil
+	IdempotencyTTL     time.Duration            // how long responses are kept for retries, idempotency.DefaultTTL when zero
+	Metrics            *metrics.Metrics         // records requests and serves /metrics, neither happens when nil
+	TrustedProxies     int                      // proxies in front of the server whose X-Forwarded-For entries are trusted, none when zero
 }
 
 func NewDeps(ctx context.Context, db *gorm.DB, cfg *config.AppConfig, log *slog.Logger) Dependencies {
 	{{cookiecutter.entity_name_lower}}Repo := repository.NewEntityRepository[entity.{{cookiecutter.entity_name}}](db)
-	{{cookiecutter.entity_name_lower}}Service := service.New{{cookiecutter.entity_name}}Service({{cookiecutter.entity_name_lower}}Repo)
+	events := event.NewOutboxPublisher(outbox.New(db))
+	{{cookiecutter.entity_name_lower}}Service := service.New{{cookiecutter.entity_name}}Service({{cookiecutter.entity_name_lower}}Repo, events, service.OwnerPolicy(service.AdminRole))
 
-	return Dependencies{
-		{{cookiecutter.entity_name}}Service: {{cookiecutter.entity_name_lower}}Service,
+	deps := Dependencies{
+		{{cookiecutter.entity_name}}Service:     {{cookiecutter.entity_name_lower}}Service,
+		AdminToken:         cfg.AdminToken,
+		BodyLimits:         middleware.BodyLimits{MaxBytes: cfg.BodyLimits.MaxBytes, MaxDepth: cfg.BodyLimits.MaxDepth},
+		MaxBatchOperations: cfg.MaxBatchOperations,
+		MaxPageSize:        cfg.MaxPageSize,
+		Idempotency:        idempotency.NewPostgresStore(db),
+		IdempotencyTTL:     cfg.IdempotencyTTL,
+		TrustedProxies:     cfg.TrustedProxies,
 	}
+	if cfg.Auth.Issuer == "" {
+		log.Warn("authentication is disabled, set OIDC_ISSUER to enable it")
+	} else {
+		// key set fetches show up in the trace of the request that needed them
+		client := &http.Client{Timeout: 10 * time.Second, Transport: tracing.Transport(nil)}
+		keys := auth.NewJWKS(cfg.Auth.JWKSURL, auth.JWKSConfig{Client: client})
+		deps.Verifier = auth.NewVerifier(keys, auth.Config{
+			Issuer:   cfg.Auth.Issuer,
+			Audience: cfg.Auth.Audience,
+		})
+	}
+
+	if cfg.APIKeySecret == "" {
+		log.Warn("API keys are disabled, set the API key secret to enable them")
+	} else {
+		apiKeyRepo := repository.NewEntityRepository[entity.APIKey](db)
+		apiKeys := service.NewAPIKeyService(apiKeyRepo, []byte(cfg.APIKeySecret))
+		deps.APIKeyService = apiKeys
+		deps.APIKeys = apiKeys
+	}
+
+	if cfg.ErrorReportFile != "" {
+		deps.Reporter = errorreport.NewFileReporter(cfg.ErrorReportFile)
+	}
+
+	switch cfg.RateLimitStore {
+	case config.RateLimitOff:
+		log.Warn("rate limiting is disabled")
+	case config.RateLimitPostgres:
+		deps.RateLimits = ratelimit.NewPostgresStore(db)
+	default:
+		deps.RateLimits = ratelimit.NewMemoryStore()
+	}
+	return deps
 }
@@ -1,21 +1,94 @@
 package server
 
 import (
+	"expvar"
 	"net/http"
+	"time"
 
 	"{{cookiecutter.module_name}}/internal/handler"
+	"{{cookiecutter.module_name}}/internal/middleware"
+	"{{cookiecutter.module_name}}/internal/ratelimit"
 	"{{cookiecutter.module_name}}/internal/version"
 )
 
+// Scopes a bearer token needs for the {{cookiecutter.entity_name_lower}} routes.
+const (
+	scope{{cookiecutter.entity_name}}Read  = "{{cookiecutter.entity_name_lower}}:read"
+	scope{{cookiecutter.entity_name}}Write = "{{cookiecutter.entity_name_lower}}:write"
+)
+
+// Requests a client may send per route. Reads are cheap, writes take a
+// transaction and an outbox message each.
+var (
+	readLimit  = ratelimit.Limit{Requests: 300, Period: time.Minute}
+	writeLimit = ratelimit.Limit{Requests: 60, Period: time.Minute}
+	// a batch holds many writes, an export reads the whole table and an
+	// import writes thousands of rows
+	batchLimit  = ratelimit.Limit{Requests: 20, Period: time.Minute}
+	exportLimit = ratelimit.Limit{Requests: 5, Period: time.Minute}
+	importLimit = ratelimit.Limit{Requests: 5, Period: time.Minute}
+)
+
+// API keys are minted from a name and a few scopes, larger bodies are not
+// worth reading.
+var apiKeyBodyLimits = middleware.BodyLimits{MaxBytes: 4 << 10}
+
+// Imports upload thousands of rows at once.
+var importBodyLimits = middleware.BodyLimits{MaxBytes: 32 << 20}
+
 func addRoutes(mux *http.ServeMux, version version.Version, deps Dependencies) {
 	mux.Handle("GET /healthz", handler.HandleHealthz(version))
+	// expvar counters such as middleware.Panics
+	mux.Handle("GET /debug/vars", middleware.AdminMiddleware(expvar.Handler(), deps.AdminToken))
+	if deps.Metrics != nil {
+		mux.Handle("GET /metrics", middleware.AdminMiddleware(deps.Metrics.Handler(), deps.AdminToken))
+	}
 	mux.Handle("/", http.NotFoundHandler())
 
-	// {{cookiecutter.entity_name_lower}} CRUD endpoints
+	// {{cookiecutter.entity_name_lower}} CRUD endpoints. When authentication is enabled every route needs a
+	// bearer token that was granted the scope it is registered with. Every client
+	// gets its own rate limit per route.
 	{{cookiecutter.entity_name_lower}}Handler := handler.New{{cookiecutter.entity_name}}Handler(deps.{{cookiecutter.entity_name}}Service)
-	mux.Handle("POST /api/v1/{{cookiecutter.entity_name_lower}}", {{cookiecutter.entity_name_lower}}Handler.HandleCreate{{cookiecutter.entity_name}}())
-	mux.Handle("GET /api/v1/{{cookiecutter.entity_name_lower}}", {{cookiecutter.entity_name_lower}}Handler.HandleList{{cookiecutter.entity_name}}())
-	mux.Handle("GET /api/v1/{{cookiecutter.entity_name_lower}}/{id}", {{cookiecutter.entity_name_lower}}Handler.HandleGet{{cookiecutter.entity_name}}())
-	mux.Handle("PUT /api/v1/{{cookiecutter.entity_name_lower}}/{id}", {{cookiecutter.entity_name_lower}}Handler.HandleUpdate{{cookiecutter.entity_name}}())
-	mux.Handle("DELETE /api/v1/{{cookiecutter.entity_name_lower}}/{id}", {{cookiecutter.entity_name_lower}}Handler.HandleDelete{{cookiecutter.entity_name}}())
+	mux.Handle("POST /api/v1/{{cookiecutter.entity_name_lower}}", deps.guard({{cookiecutter.entity_name_lower}}Handler.HandleCreate{{cookiecutter.entity_name}}(), scope{{cookiecutter.entity_name}}Write, writeLimit))
+	mux.Handle("GET /api/v1/{{cookiecutter.entity_name_lower}}", deps.guard({{cookiecutter.entity_name_lower}}Handler.HandleList{{cookiecutter.entity_name}}(deps.MaxPageSize), scope{{cookiecutter.entity_name}}Read, readLimit))
+	mux.Handle("POST /api/v1/{{cookiecutter.entity_name_lower}}:batch", deps.guard({{cookiecutter.entity_name_lower}}Handler.HandleBatch{{cookiecutter.entity_name}}(deps.MaxBatchOperations), scope{{cookiecutter.entity_name}}Write, batchLimit))
+	mux.Handle("POST /api/v1/{{cookiecutter.entity_name_lower}}/import", middleware.BodyLimitMiddleware(deps.guard({{cookiecutter.entity_name_lower}}Handler.HandleImport{{cookiecutter.entity_name}}(), scope{{cookiecutter.entity_name}}Write, importLimit), importBodyLimits))
+	mux.Handle("GET /api/v1/{{cookiecutter.entity_name_lower}}/export", deps.guard({{cookiecutter.entity_name_lower}}Handler.HandleExport{{cookiecutter.entity_name}}(), scope{{cookiecutter.entity_name}}Read, exportLimit))
+	mux.Handle("GET /api/v1/{{cookiecutter.entity_name_lower}}/{id}", deps.guard({{cookiecutter.entity_name_lower}}Handler.HandleGet{{cookiecutter.entity_name}}(), scope{{cookiecutter.entity_name}}Read, readLimit))
+	mux.Handle("PUT /api/v1/{{cookiecutter.entity_name_lower}}/{id}", deps.guard({{cookiecutter.entity_name_lower}}Handler.HandleUpdate{{cookiecutter.entity_name}}(), scope{{cookiecutter.entity_name}}Write, writeLimit))
+	mux.Handle("PATCH /api/v1/{{cookiecutter.entity_name_lower}}/{id}", deps.guard({{cookiecutter.entity_name_lower}}Handler.HandlePatch{{cookiecutter.entity_name}}(), scope{{cookiecutter.entity_name}}Write, writeLimit))
+	mux.Handle("DELETE /api/v1/{{cookiecutter.entity_name_lower}}/{id}", deps.guard({{cookiecutter.entity_name_lower}}Handler.HandleDelete{{cookiecutter.entity_name}}(), scope{{cookiecutter.entity_name}}Write, writeLimit))
+	mux.Handle("POST /api/v1/{{cookiecutter.entity_name_lower}}/{id}/restore", deps.guard({{cookiecutter.entity_name_lower}}Handler.HandleRestore{{cookiecutter.entity_name}}(), scope{{cookiecutter.entity_name}}Write, writeLimit))
+	mux.Handle("POST /api/v1/{{cookiecutter.entity_name_lower}}/{id}/purge", middleware.AdminMiddleware({{cookiecutter.entity_name_lower}}Handler.HandlePurge{{cookiecutter.entity_name}}(), deps.AdminToken))
+
+	// API key administration, only registered when API keys are configured
+	if deps.APIKeyService != nil {
+		apiKeyHandler := handler.NewAPIKeyHandler(deps.APIKeyService)
+		mux.Handle("POST /api/v1/admin/api-key", middleware.AdminMiddleware(middleware.BodyLimitMiddleware(apiKeyHandler.HandleMintAPIKey(), apiKeyBodyLimits), deps.AdminToken))
+		mux.Handle("DELETE /api/v1/admin/api-key/{id}", middleware.AdminMiddleware(apiKeyHandler.HandleRevokeAPIKey(), deps.AdminToken))
+		mux.Handle("POST /api/v1/admin/api-key/{id}/rotate", middleware.AdminMiddleware(middleware.BodyLimitMiddleware(apiKeyHandler.HandleRotateAPIKey(), apiKeyBodyLimits), deps.AdminToken))
+	}
+}
+
+// guard requires a bearer token or API key with scope on next, limits the
+// requests each client can send to it and replays retried POST requests that
+// carry an Idempotency-Key. Either credential turns authentication on, routes
+// only stay open when both are disabled, clients are then told apart by IP.
+func (d Dependencies) guard(next http.Handler, scope string, limit ratelimit.Limit) http.Handler {
+	authenticated := d.Verifier != nil || d.APIKeys != nil
+	// rejected and limited requests do not claim an idempotency key
+	if d.Idempotency != nil {
+		next = middleware.IdempotencyMiddleware(next, d.Idempotency, d.IdempotencyTTL)
+	}
+	if authenticated {
+		next = middleware.RequireScope(next, scope)
+	}
+	// the limit needs the principal, so it runs after authentication
+	if d.RateLimits != nil {
+		next = middleware.RateLimitMiddleware(next, d.RateLimits, limit)
+	}
+	if authenticated {
+		next = middleware.AuthMiddleware(next, d.Verifier, d.APIKeys)
+	}
+	return next
 }
@@ -26,11 +26,20 @@ func NewServer(version version.Version, deps Dependencies) http.Handler {
 	addRoutes(mux, version, deps)
 
 	var handlerWithRoutes http.Handler = mux
-
-	// handlerWithLoggingBeta := loggingMiddleware(handlerWithRoutes)
-
-	handlerWithLogging := middleware.RequestLoggingMiddleware(handlerWithRoutes)
-	handlerWithHeaders := middleware.HeaderMiddleware(handlerWithLogging, version)
+	// metrics wrap the mux itself, it sets the route pattern on the request
+	// they see
+	if deps.Metrics != nil {
+		handlerWithRoutes = middleware.MetricsMiddleware(mux, deps.Metrics)
+	}
+	handlerWithRoutes = middleware.BodyLimitMiddleware(handlerWithRoutes, deps.BodyLimits)
+	handlerWithRoutes = middleware.ClientIPMiddleware(handlerWithRoutes, deps.TrustedProxies)
+
+	// recovery runs inside the logging middleware so panics are logged with the request info
+	handlerWithRecovery := middleware.RecoveryMiddleware(handlerWithRoutes, deps.Reporter, deps.Metrics)
+	handlerWithResponseLogging := middleware.LoggingMiddleware(handlerWithRecovery)
+	handlerWithLogging := middleware.RequestLoggingMiddleware(handlerWithResponseLogging)
+	handlerWithTracing := middleware.TracingMiddleware(handlerWithLogging, mux)
+	handlerWithHeaders := middleware.HeaderMiddleware(handlerWithTracing, version)
 	handlerWithCompression := externalHandlers.CompressHandler(handlerWithHeaders)
 	// Apply middleware
 	return handlerWithCompression
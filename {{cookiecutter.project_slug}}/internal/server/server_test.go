@@ -6,10 +6,19 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
+	"{{cookiecutter.module_name}}/internal/auth"
+	"{{cookiecutter.module_name}}/internal/db"
+	"{{cookiecutter.module_name}}/internal/entity"
+	"{{cookiecutter.module_name}}/internal/event"
+	"{{cookiecutter.module_name}}/internal/metrics"
 	"{{cookiecutter.module_name}}/internal/middleware"
+	"{{cookiecutter.module_name}}/internal/ratelimit"
+	"{{cookiecutter.module_name}}/internal/repository"
+	"{{cookiecutter.module_name}}/internal/service"
 	"{{cookiecutter.module_name}}/internal/version"
 )
 
@@ -89,6 +98,243 @@ func TestServer_HeaderMiddleware_Integration(t *testing.T) {
 	}
 }
 
+// scopeVerifier accepts tokens that are a space separated list of scopes
+type scopeVerifier struct{}
+
+func (scopeVerifier) Verify(ctx context.Context, token string) (*auth.Principal, error) {
+	if token == "invalid" {
+		return nil, auth.ErrInvalidToken
+	}
+	return &auth.Principal{Subject: "user-1", Scopes: strings.Fields(token)}, nil
+}
+
+func TestServer_RouteScopes(t *testing.T) {
+	conn, err := db.MakeDbSqlite()
+	if err != nil {
+		t.Fatalf("failed to connect database: %v", err)
+	}
+	if err := conn.AutoMigrate(&entity.{{cookiecutter.entity_name}}{}); err != nil {
+		t.Fatalf("failed to migrate database: %v", err)
+	}
+	repo := repository.NewEntityRepository[entity.{{cookiecutter.entity_name}}](conn)
+	deps := Dependencies{
+		{{cookiecutter.entity_name}}Service: service.New{{cookiecutter.entity_name}}Service(repo, event.NewBus(), nil),
+		Verifier:       scopeVerifier{},
+	}
+	server := NewServer(version.Version{}, deps)
+
+	tests := []struct {
+		name           string
+		method         string
+		token          string
+		expectedStatus int
+	}{
+		{
+			name:           "no token",
+			method:         http.MethodGet,
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "invalid token",
+			method:         http.MethodGet,
+			token:          "invalid",
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "read with read scope",
+			method:         http.MethodGet,
+			token:          "{{cookiecutter.entity_name_lower}}:read",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "write with read scope",
+			method:         http.MethodPost,
+			token:          "{{cookiecutter.entity_name_lower}}:read",
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "write with write scope",
+			method:         http.MethodPost,
+			token:          "{{cookiecutter.entity_name_lower}}:read {{cookiecutter.entity_name_lower}}:write",
+			expectedStatus: http.StatusCreated,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/api/v1/{{cookiecutter.entity_name_lower}}", strings.NewReader(`{"name":"Scoped"}`))
+			req.Header.Set("Content-Type", "application/json")
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			w := httptest.NewRecorder()
+
+			server.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("expected status %d; got %d", tt.expectedStatus, w.Code)
+			}
+		})
+	}
+}
+
+func TestServer_RouteScopes_APIKeysOnly(t *testing.T) {
+	conn, err := db.MakeDbSqlite()
+	if err != nil {
+		t.Fatalf("failed to connect database: %v", err)
+	}
+	if err := conn.AutoMigrate(&entity.{{cookiecutter.entity_name}}{}); err != nil {
+		t.Fatalf("failed to migrate database: %v", err)
+	}
+	repo := repository.NewEntityRepository[entity.{{cookiecutter.entity_name}}](conn)
+	// an API key secret without OIDC_ISSUER still protects the routes
+	deps := Dependencies{
+		{{cookiecutter.entity_name}}Service: service.New{{cookiecutter.entity_name}}Service(repo, event.NewBus(), nil),
+		APIKeys:        scopeVerifier{},
+	}
+	server := NewServer(version.Version{}, deps)
+
+	tests := []struct {
+		name           string
+		method         string
+		authorization  string
+		apiKey         string
+		expectedStatus int
+		expectedHeader string
+	}{
+		{
+			name:           "no key",
+			method:         http.MethodGet,
+			expectedStatus: http.StatusUnauthorized,
+			expectedHeader: "ApiKey",
+		},
+		{
+			name:           "bearer token",
+			method:         http.MethodGet,
+			authorization:  "Bearer {{cookiecutter.entity_name_lower}}:read",
+			expectedStatus: http.StatusUnauthorized,
+			expectedHeader: "ApiKey",
+		},
+		{
+			name:           "read with read scope",
+			method:         http.MethodGet,
+			apiKey:         "{{cookiecutter.entity_name_lower}}:read",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "write with read scope",
+			method:         http.MethodPost,
+			authorization:  "ApiKey {{cookiecutter.entity_name_lower}}:read",
+			expectedStatus: http.StatusForbidden,
+			expectedHeader: `ApiKey error="insufficient_scope", scope="{{cookiecutter.entity_name_lower}}:write"`,
+		},
+		{
+			name:           "write with write scope",
+			method:         http.MethodPost,
+			apiKey:         "{{cookiecutter.entity_name_lower}}:write",
+			expectedStatus: http.StatusCreated,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/api/v1/{{cookiecutter.entity_name_lower}}", strings.NewReader(`{"name":"Scoped"}`))
+			req.Header.Set("Content-Type", "application/json")
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			if tt.apiKey != "" {
+				req.Header.Set(middleware.APIKeyHeader, tt.apiKey)
+			}
+			w := httptest.NewRecorder()
+
+			server.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("expected status %d; got %d", tt.expectedStatus, w.Code)
+			}
+			if got := w.Header().Get("WWW-Authenticate"); got != tt.expectedHeader {
+				t.Errorf("expected WWW-Authenticate %q; got %q", tt.expectedHeader, got)
+			}
+		})
+	}
+}
+
+func TestServer_RateLimits(t *testing.T) {
+	conn, err := db.MakeDbSqlite()
+	if err != nil {
+		t.Fatalf("failed to connect database: %v", err)
+	}
+	if err := conn.AutoMigrate(&entity.{{cookiecutter.entity_name}}{}); err != nil {
+		t.Fatalf("failed to migrate database: %v", err)
+	}
+	repo := repository.NewEntityRepository[entity.{{cookiecutter.entity_name}}](conn)
+	// the clock stands still, no token is refilled however slow the requests are
+	now := time.Now()
+	deps := Dependencies{
+		{{cookiecutter.entity_name}}Service: service.New{{cookiecutter.entity_name}}Service(repo, event.NewBus(), nil),
+		RateLimits:     ratelimit.NewMemoryStoreWithClock(func() time.Time { return now }),
+		TrustedProxies: 1,
+	}
+	server := NewServer(version.Version{}, deps)
+
+	list := func(ip string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/{{cookiecutter.entity_name_lower}}", nil)
+		req.Header.Set("X-Forwarded-For", ip)
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, req)
+		return w
+	}
+
+	for i := range readLimit.Requests {
+		if w := list("203.0.113.1"); w.Code != http.StatusOK {
+			t.Fatalf("request %d: expected status %d; got %d", i, http.StatusOK, w.Code)
+		}
+	}
+	w := list("203.0.113.1")
+	if w.Code != http.StatusTooManyRequests {
+		t.Errorf("expected status %d over the limit; got %d", http.StatusTooManyRequests, w.Code)
+	}
+	if w.Header().Get("Retry-After") == "" {
+		t.Error("expected Retry-After over the limit")
+	}
+	if w := list("203.0.113.2"); w.Code != http.StatusOK {
+		t.Errorf("expected status %d for another client; got %d", http.StatusOK, w.Code)
+	}
+}
+
+func TestServer_Metrics(t *testing.T) {
+	deps := Dependencies{
+		AdminToken: "admin-token",
+		Metrics:    metrics.New(version.Version{Build: "test-build", Branch: "test-branch"}),
+	}
+	server := NewServer(version.Version{}, deps)
+
+	server.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, req)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("expected status %d without the admin token; got %d", http.StatusUnauthorized, w.Code)
+	}
+
+	req.Header.Set("Authorization", "Bearer admin-token")
+	w = httptest.NewRecorder()
+	server.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d; got %d", http.StatusOK, w.Code)
+	}
+	for _, want := range []string{
+		`http_requests_total{method="GET",route="GET /healthz",status="200"} 1`,
+		`build_info{branch="test-branch",build="test-build"} 1`,
+	} {
+		if !strings.Contains(w.Body.String(), want) {
+			t.Errorf("expected metrics to contain %q", want)
+		}
+	}
+}
+
 func TestServer_StartServer(t *testing.T) {
 	t.Parallel()
 
@@ -0,0 +1,183 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"{{cookiecutter.module_name}}/internal/auth"
+	"{{cookiecutter.module_name}}/internal/entity"
+	"{{cookiecutter.module_name}}/internal/repository"
+)
+
+var (
+	ErrAPIKeyNotFound     = errors.New("api key not found")
+	ErrAPIKeyNameRequired = errors.New("api key name is required")
+	ErrInvalidExpiry      = errors.New("expiry must be in the future")
+)
+
+// APIKeyIssuer is the Issuer of principals authenticated with an API key.
+const APIKeyIssuer = "api-key"
+
+// lastUsedInterval limits how often a key's last_used_at is written, so a
+// busy key does not cost a write per request.
+const lastUsedInterval = time.Minute
+
+// APIKeyService mints and checks API keys. Keys have the form
+// "<id>.<secret>", only an HMAC-SHA256 of the secret is stored.
+type APIKeyService interface {
+	// Mint creates a key and returns it along with the key string, which
+	// cannot be recovered later. A nil expiresAt never expires.
+	Mint(ctx context.Context, name string, scopes []string, expiresAt *time.Time) (*entity.APIKey, string, error)
+	// Revoke disables a key immediately.
+	Revoke(ctx context.Context, id string) error
+	// Rotate mints a replacement for a key with the same name, scopes and
+	// expiry. The old key keeps working for overlap so callers can switch.
+	// Both keys authenticate as the same subject.
+	Rotate(ctx context.Context, id string, overlap time.Duration) (*entity.APIKey, string, error)
+	// Verify returns the principal of an active key, errors for keys that
+	// must be rejected wrap auth.ErrInvalidToken.
+	Verify(ctx context.Context, key string) (*auth.Principal, error)
+}
+
+type apiKeyService struct {
+	repo   *repository.EntityRepository[entity.APIKey]
+	secret []byte
+	now    func() time.Time
+}
+
+// NewAPIKeyService creates an APIKeyService that hashes keys with secret.
+// Changing the secret invalidates every key.
+func NewAPIKeyService(repo *repository.EntityRepository[entity.APIKey], secret []byte) APIKeyService {
+	return &apiKeyService{repo: repo, secret: secret, now: time.Now}
+}
+
+func (s *apiKeyService) Mint(ctx context.Context, name string, scopes []string, expiresAt *time.Time) (*entity.APIKey, string, error) {
+	if name == "" {
+		return nil, "", ErrAPIKeyNameRequired
+	}
+	if expiresAt != nil && !expiresAt.After(s.now()) {
+		return nil, "", ErrInvalidExpiry
+	}
+	return s.mint(ctx, entity.NewAPIKey(name, scopes, expiresAt))
+}
+
+func (s *apiKeyService) mint(ctx context.Context, key *entity.APIKey) (*entity.APIKey, string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, "", fmt.Errorf("generate api key: %w", err)
+	}
+	secret := base64.RawURLEncoding.EncodeToString(raw)
+	key.Hash = s.hash(secret)
+
+	if err := s.repo.Create(ctx, key); err != nil {
+		return nil, "", err
+	}
+	return key, key.ID.String() + "." + secret, nil
+}
+
+func (s *apiKeyService) Revoke(ctx context.Context, id string) error {
+	uuidID, err := uuid.Parse(id)
+	if err != nil {
+		return ErrAPIKeyNotFound
+	}
+	err = s.repo.UpdateColumns(ctx, uuidID, map[string]any{"revoked_at": s.now()})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrAPIKeyNotFound
+	}
+	return err
+}
+
+func (s *apiKeyService) Rotate(ctx context.Context, id string, overlap time.Duration) (*entity.APIKey, string, error) {
+	uuidID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, "", ErrAPIKeyNotFound
+	}
+
+	var replacement *entity.APIKey
+	var token string
+	err = s.repo.WithTx(ctx, func(ctx context.Context) error {
+		old, err := s.repo.GetByID(ctx, uuidID)
+		if err != nil {
+			return err
+		}
+		now := s.now()
+		if !old.Active(now) {
+			return ErrAPIKeyNotFound
+		}
+
+		replacement, token, err = s.mint(ctx, old.Replace())
+		if err != nil {
+			return err
+		}
+
+		// the old key only ever expires sooner
+		retire := now.Add(overlap)
+		if old.ExpiresAt != nil && old.ExpiresAt.Before(retire) {
+			return nil
+		}
+		return s.repo.UpdateColumns(ctx, uuidID, map[string]any{"expires_at": retire})
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, "", ErrAPIKeyNotFound
+		}
+		return nil, "", err
+	}
+	return replacement, token, nil
+}
+
+func (s *apiKeyService) Verify(ctx context.Context, key string) (*auth.Principal, error) {
+	idPart, secret, ok := strings.Cut(key, ".")
+	if !ok {
+		return nil, fmt.Errorf("%w: malformed api key", auth.ErrInvalidToken)
+	}
+	id, err := uuid.Parse(idPart)
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed api key", auth.ErrInvalidToken)
+	}
+
+	stored, err := s.repo.GetByID(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("%w: unknown api key", auth.ErrInvalidToken)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !hmac.Equal([]byte(stored.Hash), []byte(s.hash(secret))) {
+		return nil, fmt.Errorf("%w: unknown api key", auth.ErrInvalidToken)
+	}
+	now := s.now()
+	if !stored.Active(now) {
+		return nil, fmt.Errorf("%w: api key expired or revoked", auth.ErrInvalidToken)
+	}
+
+	if stored.LastUsedAt == nil || now.Sub(*stored.LastUsedAt) >= lastUsedInterval {
+		if err := s.repo.UpdateColumns(ctx, id, map[string]any{"last_used_at": now}); err != nil {
+			return nil, err
+		}
+	}
+
+	// the subject outlives rotations, so the caller keeps what it owns
+	return &auth.Principal{
+		Subject: APIKeyIssuer + ":" + stored.LineageID.String(),
+		Issuer:  APIKeyIssuer,
+		Scopes:  stored.ScopeList(),
+	}, nil
+}
+
+func (s *apiKeyService) hash(secret string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(secret))
+	return hex.EncodeToString(mac.Sum(nil))
+}
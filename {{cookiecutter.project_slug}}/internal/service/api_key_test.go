@@ -0,0 +1,326 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"{{cookiecutter.module_name}}/internal/auth"
+	"{{cookiecutter.module_name}}/internal/db"
+	"{{cookiecutter.module_name}}/internal/entity"
+	"{{cookiecutter.module_name}}/internal/repository"
+)
+
+func setupAPIKeyService(t *testing.T) (*apiKeyService, *repository.EntityRepository[entity.APIKey]) {
+	db, err := db.MakeDbSqlite()
+	if err != nil {
+		t.Fatalf("failed to connect database: %v", err)
+	}
+
+	if err := db.AutoMigrate(&entity.APIKey{}); err != nil {
+		t.Fatalf("failed to migrate database: %v", err)
+	}
+
+	repo := repository.NewEntityRepository[entity.APIKey](db)
+	return NewAPIKeyService(repo, []byte("test-secret")).(*apiKeyService), repo
+}
+
+func TestAPIKeyService_Mint(t *testing.T) {
+	future := time.Now().Add(time.Hour)
+	past := time.Now().Add(-time.Hour)
+
+	tests := []struct {
+		name      string
+		keyName   string
+		expiresAt *time.Time
+		wantErr   error
+	}{
+		{
+			name:    "Success",
+			keyName: "ci",
+		},
+		{
+			name:      "With Expiry",
+			keyName:   "ci",
+			expiresAt: &future,
+		},
+		{
+			name:    "Empty Name",
+			wantErr: ErrAPIKeyNameRequired,
+		},
+		{
+			name:      "Expiry In The Past",
+			keyName:   "ci",
+			expiresAt: &past,
+			wantErr:   ErrInvalidExpiry,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, repo := setupAPIKeyService(t)
+			ctx := context.Background()
+
+			apiKey, key, err := svc.Mint(ctx, tt.keyName, []string{"project:read"}, tt.expiresAt)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Mint() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Mint() error = %v", err)
+			}
+
+			stored, err := repo.GetByID(ctx, apiKey.ID)
+			if err != nil {
+				t.Fatalf("GetByID() error = %v", err)
+			}
+			if stored.Hash == "" || strings.Contains(key, stored.Hash) {
+				t.Error("expected only a hash of the key to be stored")
+			}
+
+			principal, err := svc.Verify(ctx, key)
+			if err != nil {
+				t.Fatalf("Verify() error = %v", err)
+			}
+			if principal.Subject != "api-key:"+apiKey.ID.String() || principal.Issuer != APIKeyIssuer {
+				t.Errorf("principal = %+v, want api key %s", principal, apiKey.ID)
+			}
+			if !principal.HasScope("project:read") {
+				t.Errorf("principal scopes = %v, want project:read", principal.Scopes)
+			}
+		})
+	}
+}
+
+func TestAPIKeyService_Verify(t *testing.T) {
+	svc, _ := setupAPIKeyService(t)
+	ctx := context.Background()
+
+	active, activeKey, err := svc.Mint(ctx, "active", nil, nil)
+	if err != nil {
+		t.Fatalf("Mint() error = %v", err)
+	}
+	revoked, revokedKey, err := svc.Mint(ctx, "revoked", nil, nil)
+	if err != nil {
+		t.Fatalf("Mint() error = %v", err)
+	}
+	if err := svc.Revoke(ctx, revoked.ID.String()); err != nil {
+		t.Fatalf("Revoke() error = %v", err)
+	}
+	expiresAt := time.Now().Add(time.Minute)
+	_, expiredKey, err := svc.Mint(ctx, "expired", nil, &expiresAt)
+	if err != nil {
+		t.Fatalf("Mint() error = %v", err)
+	}
+
+	other := NewAPIKeyService(svc.repo, []byte("other-secret"))
+	later := time.Now().Add(time.Hour)
+
+	tests := []struct {
+		name     string
+		verifier APIKeyService
+		key      string
+		now      time.Time
+		wantErr  bool
+	}{
+		{
+			name:     "Active",
+			verifier: svc,
+			key:      activeKey,
+		},
+		{
+			name:     "Revoked",
+			verifier: svc,
+			key:      revokedKey,
+			wantErr:  true,
+		},
+		{
+			name:     "Expired",
+			verifier: svc,
+			key:      expiredKey,
+			now:      later,
+			wantErr:  true,
+		},
+		{
+			name:     "Wrong Secret",
+			verifier: other,
+			key:      activeKey,
+			wantErr:  true,
+		},
+		{
+			name:     "Tampered",
+			verifier: svc,
+			key:      active.ID.String() + ".tampered",
+			wantErr:  true,
+		},
+		{
+			name:     "Malformed",
+			verifier: svc,
+			key:      "not-a-key",
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !tt.now.IsZero() {
+				svc.now = func() time.Time { return tt.now }
+				defer func() { svc.now = time.Now }()
+			}
+
+			_, err := tt.verifier.Verify(ctx, tt.key)
+			if tt.wantErr {
+				if !errors.Is(err, auth.ErrInvalidToken) {
+					t.Fatalf("Verify() error = %v, want ErrInvalidToken", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Verify() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestAPIKeyService_LastUsed(t *testing.T) {
+	svc, repo := setupAPIKeyService(t)
+	ctx := context.Background()
+	now := time.Now()
+	svc.now = func() time.Time { return now }
+
+	apiKey, key, err := svc.Mint(ctx, "ci", nil, nil)
+	if err != nil {
+		t.Fatalf("Mint() error = %v", err)
+	}
+	lastUsed := func() *time.Time {
+		t.Helper()
+		stored, err := repo.GetByID(ctx, apiKey.ID)
+		if err != nil {
+			t.Fatalf("GetByID() error = %v", err)
+		}
+		return stored.LastUsedAt
+	}
+	verify := func() {
+		t.Helper()
+		if _, err := svc.Verify(ctx, key); err != nil {
+			t.Fatalf("Verify() error = %v", err)
+		}
+	}
+
+	if lastUsed() != nil {
+		t.Fatal("expected last_used_at to be empty before first use")
+	}
+	verify()
+	first := lastUsed()
+	if first == nil || !first.Equal(now) {
+		t.Fatalf("last_used_at = %v, want %v", first, now)
+	}
+
+	// uses within lastUsedInterval are not written
+	now = now.Add(lastUsedInterval / 2)
+	verify()
+	if got := lastUsed(); !got.Equal(*first) {
+		t.Errorf("last_used_at = %v, want %v", got, first)
+	}
+
+	now = now.Add(lastUsedInterval)
+	verify()
+	if got := lastUsed(); !got.Equal(now) {
+		t.Errorf("last_used_at = %v, want %v", got, now)
+	}
+}
+
+func TestAPIKeyService_Rotate(t *testing.T) {
+	svc, _ := setupAPIKeyService(t)
+	ctx := context.Background()
+	now := time.Now()
+	svc.now = func() time.Time { return now }
+
+	old, oldKey, err := svc.Mint(ctx, "ci", []string{"project:read", "project:write"}, nil)
+	if err != nil {
+		t.Fatalf("Mint() error = %v", err)
+	}
+
+	replacement, newKey, err := svc.Rotate(ctx, old.ID.String(), time.Hour)
+	if err != nil {
+		t.Fatalf("Rotate() error = %v", err)
+	}
+	if replacement.ID == old.ID || replacement.Name != old.Name || replacement.Scopes != old.Scopes || replacement.LineageID != old.ID {
+		t.Errorf("replacement = %+v, want a new key like %+v", replacement, old)
+	}
+
+	// both keys work during the overlap, as the same subject
+	for _, key := range []string{oldKey, newKey} {
+		principal, err := svc.Verify(ctx, key)
+		if err != nil {
+			t.Errorf("Verify() error = %v during overlap", err)
+			continue
+		}
+		if want := APIKeyIssuer + ":" + old.ID.String(); principal.Subject != want {
+			t.Errorf("Subject = %q, want %q", principal.Subject, want)
+		}
+	}
+
+	// only the replacement works after it
+	now = now.Add(time.Hour + time.Second)
+	if _, err := svc.Verify(ctx, oldKey); !errors.Is(err, auth.ErrInvalidToken) {
+		t.Errorf("Verify() error = %v, want ErrInvalidToken for the old key", err)
+	}
+	if _, err := svc.Verify(ctx, newKey); err != nil {
+		t.Errorf("Verify() error = %v for the replacement", err)
+	}
+
+	// retired keys cannot be rotated again
+	if _, _, err := svc.Rotate(ctx, old.ID.String(), time.Hour); !errors.Is(err, ErrAPIKeyNotFound) {
+		t.Errorf("Rotate() error = %v, want ErrAPIKeyNotFound", err)
+	}
+	if _, _, err := svc.Rotate(ctx, "invalid", time.Hour); !errors.Is(err, ErrAPIKeyNotFound) {
+		t.Errorf("Rotate() error = %v, want ErrAPIKeyNotFound", err)
+	}
+}
+
+func TestAPIKeyService_Revoke(t *testing.T) {
+	svc, _ := setupAPIKeyService(t)
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		id      string
+		wantErr error
+	}{
+		{
+			name: "Success",
+		},
+		{
+			name:    "Not Found",
+			id:      "00000000-0000-0000-0000-000000000000",
+			wantErr: ErrAPIKeyNotFound,
+		},
+		{
+			name:    "Invalid ID",
+			id:      "invalid",
+			wantErr: ErrAPIKeyNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id := tt.id
+			if id == "" {
+				apiKey, _, err := svc.Mint(ctx, "ci", nil, nil)
+				if err != nil {
+					t.Fatalf("Mint() error = %v", err)
+				}
+				id = apiKey.ID.String()
+			}
+
+			if err := svc.Revoke(ctx, id); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Revoke() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -1,49 +1,315 @@
 package service
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"strconv"
+	"time"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"{{cookiecutter.module_name}}/internal/auth"
 	"{{cookiecutter.module_name}}/internal/entity"
+	"{{cookiecutter.module_name}}/internal/event"
+	"{{cookiecutter.module_name}}/internal/query"
 	"{{cookiecutter.module_name}}/internal/repository"
+	"{{cookiecutter.module_name}}/internal/validation"
 )
 
 var (
 	Err{{cookiecutter.entity_name}}NotFound = errors.New("{{cookiecutter.entity_name_lower}} not found")
 	ErrInvalidID       = errors.New("invalid {{cookiecutter.entity_name_lower}} ID")
-	ErrNameRequired    = errors.New("name is required")
+	// ErrNameRequired is wrapped by the validation.Errors of a missing name.
+	//
+	// Deprecated: use validation.ErrRequired, the error of every required
+	// field. Missing names are answered as validation-failed problems.
+	ErrNameRequired    = validation.ErrRequired
+	ErrInvalidCursor   = errors.New("invalid cursor")
+	ErrVersionConflict = errors.New("{{cookiecutter.entity_name_lower}} version does not match")
+	ErrInvalidPatch    = errors.New("patch cannot be applied")
+	ErrImportAborted   = errors.New("not imported, another row was rejected")
+	ErrBatchAborted    = errors.New("not applied, another operation failed")
 )
 
+// PatchFunc applies a client's patch, such as a JSON Merge Patch or a JSON
+// Patch, to the JSON document of a {{cookiecutter.entity_name_lower}}'s editable fields.
+type PatchFunc func(doc []byte) ([]byte, error)
+
+// editable{{cookiecutter.entity_name}} is the document a PatchFunc edits. It lists the fields
+// clients may change, anything else in a patched document is rejected.
+type editable{{cookiecutter.entity_name}} struct {
+	Name string `json:"name"`
+}
+
+// Events raised by {{cookiecutter.entity_name}}Service. Each carries before and after snapshots of
+// the {{cookiecutter.entity_name_lower}} as a {{cookiecutter.entity_name}}Snapshot.
+const (
+	{{cookiecutter.entity_name}}AggregateType = "{{cookiecutter.entity_name_lower}}"
+	Event{{cookiecutter.entity_name}}Created  = "{{cookiecutter.entity_name_lower}}.created"
+	Event{{cookiecutter.entity_name}}Updated  = "{{cookiecutter.entity_name_lower}}.updated"
+	Event{{cookiecutter.entity_name}}Deleted  = "{{cookiecutter.entity_name_lower}}.deleted"
+	Event{{cookiecutter.entity_name}}Restored = "{{cookiecutter.entity_name_lower}}.restored"
+)
+
+// {{cookiecutter.entity_name}}Snapshot is the state of a {{cookiecutter.entity_name_lower}} as published in events. It is part of
+// the event contract, changes that break consumers need a new event.SchemaVersion.
+type {{cookiecutter.entity_name}}Snapshot struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Version   int64     `json:"version"`
+	CreatedBy string    `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// WriteMode tells writes of many rows, such as Import and Batch, what to do
+// when some of them fail.
+type WriteMode int
+
+const (
+	// Atomic writes every row or none, a failed row aborts the others.
+	Atomic WriteMode = iota
+	// BestEffort writes the rows that succeed and reports the others.
+	BestEffort
+)
+
+// Import{{cookiecutter.entity_name}} is a row of an import. Err is set for rows the caller could not
+// read, they are rejected with it.
+type Import{{cookiecutter.entity_name}} struct {
+	Name string
+	Err  error
+}
+
+// ImportResult is what became of a row of an import: the {{cookiecutter.entity_name_lower}} created from it,
+// or the reason it was rejected.
+type ImportResult struct {
+	{{cookiecutter.entity_name}} *entity.{{cookiecutter.entity_name}}
+	Err     error
+}
+
+// Operations of a batch.
+const (
+	BatchCreate = "create"
+	BatchUpdate = "update"
+	BatchDelete = "delete"
+)
+
+// BatchOperation is a create, update or delete of a batch. Updates and
+// deletes name the {{cookiecutter.entity_name_lower}} by ID and the version it must still be at, like
+// Update and Delete do.
+type BatchOperation struct {
+	Op      string
+	ID      string
+	Name    string
+	Version int64
+}
+
+// BatchResult is what became of an operation of a batch: the {{cookiecutter.entity_name_lower}} it created
+// or updated, nil for a delete, or the error it failed with.
+type BatchResult struct {
+	{{cookiecutter.entity_name}} *entity.{{cookiecutter.entity_name}}
+	Err     error
+}
+
 type {{cookiecutter.entity_name}}Service interface {
 	Create(ctx context.Context, name string) (*entity.{{cookiecutter.entity_name}}, error)
 	Get(ctx context.Context, id string) (*entity.{{cookiecutter.entity_name}}, error)
-	Update(ctx context.Context, id string, name string) (*entity.{{cookiecutter.entity_name}}, error)
-	Delete(ctx context.Context, id string) error
-	List(ctx context.Context, limit, offset string) ([]entity.{{cookiecutter.entity_name}}, int, error)
+	Update(ctx context.Context, id string, name string, version int64) (*entity.{{cookiecutter.entity_name}}, error)
+	Patch(ctx context.Context, id string, version int64, patch PatchFunc) (*entity.{{cookiecutter.entity_name}}, error)
+	Delete(ctx context.Context, id string, version int64) error
+	Restore(ctx context.Context, id string) (*entity.{{cookiecutter.entity_name}}, error)
+	Purge(ctx context.Context, id string) error
+	List(ctx context.Context, limit, offset string, params query.Params) ([]entity.{{cookiecutter.entity_name}}, int, error)
+	ListByCursor(ctx context.Context, limit, cursor string, params query.Params) (*repository.CursorPage[entity.{{cookiecutter.entity_name}}], error)
+	Export(ctx context.Context, params query.Params, fn func(batch []entity.{{cookiecutter.entity_name}}) error) error
+	Import(ctx context.Context, rows []Import{{cookiecutter.entity_name}}, mode WriteMode) ([]ImportResult, error)
+	Batch(ctx context.Context, ops []BatchOperation, mode WriteMode) ([]BatchResult, error)
 }
 
 type {{cookiecutter.entity_name_lower}}Service struct {
-	repo *repository.EntityRepository[entity.{{cookiecutter.entity_name}}]
+	repo   *repository.EntityRepository[entity.{{cookiecutter.entity_name}}]
+	events event.Publisher
+	policy Policy
 }
 
-func New{{cookiecutter.entity_name}}Service(repo *repository.EntityRepository[entity.{{cookiecutter.entity_name}}]) {{cookiecutter.entity_name}}Service {
-	return &{{cookiecutter.entity_name_lower}}Service{repo: repo}
+// New{{cookiecutter.entity_name}}Service creates a {{cookiecutter.entity_name}}Service. Events are published in the same
+// transaction as the change they describe, so with an outbox publisher they
+// are stored if and only if the change is. policy guards updates, deletes
+// and restores, a nil policy allows them all.
+func New{{cookiecutter.entity_name}}Service(repo *repository.EntityRepository[entity.{{cookiecutter.entity_name}}], events event.Publisher, policy Policy) {{cookiecutter.entity_name}}Service {
+	if policy == nil {
+		policy = AllowAll
+	}
+	return &{{cookiecutter.entity_name_lower}}Service{repo: repo, events: events, policy: policy}
 }
 
 func (s *{{cookiecutter.entity_name_lower}}Service) Create(ctx context.Context, name string) (*entity.{{cookiecutter.entity_name}}, error) {
-	if name == "" {
-		return nil, ErrNameRequired
+	if err := validate(name); err != nil {
+		return nil, err
 	}
 	{{cookiecutter.entity_name_lower}} := entity.New{{cookiecutter.entity_name}}(name)
-	if err := s.repo.Create(ctx, {{cookiecutter.entity_name_lower}}); err != nil {
+	if principal, ok := auth.FromContext(ctx); ok {
+		{{cookiecutter.entity_name_lower}}.CreatedBy = principal.Subject
+	}
+	err := s.repo.WithTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.Create(ctx, {{cookiecutter.entity_name_lower}}); err != nil {
+			return err
+		}
+		return s.publish(ctx, Event{{cookiecutter.entity_name}}Created, {{cookiecutter.entity_name_lower}}.ID, nil, {{cookiecutter.entity_name_lower}})
+	})
+	if err != nil {
 		return nil, err
 	}
 	return {{cookiecutter.entity_name_lower}}, nil
 }
 
+// importBatchSize is the number of {{cookiecutter.entity_name_lower}}s Import inserts per statement.
+const importBatchSize = 100
+
+// Import creates a {{cookiecutter.entity_name_lower}} from every row that passes the validation of Create,
+// in batches, and publishes a created event for each. It returns a result
+// per row, in the order of rows. In Atomic mode a rejected row leaves
+// the other rows with ErrImportAborted and nothing is created. In
+// BestEffort mode every batch is a transaction of its own, when one
+// fails its rows are retried one by one so only the rows at fault are
+// rejected. Errors that stop the whole import, such as a failed transaction
+// in Atomic mode, are returned instead.
+func (s *{{cookiecutter.entity_name_lower}}Service) Import(ctx context.Context, rows []Import{{cookiecutter.entity_name}}, mode WriteMode) ([]ImportResult, error) {
+	var createdBy string
+	if principal, ok := auth.FromContext(ctx); ok {
+		createdBy = principal.Subject
+	}
+
+	results := make([]ImportResult, len(rows))
+	var valid []int
+	for i, row := range rows {
+		err := row.Err
+		if err == nil {
+			err = validate(row.Name)
+		}
+		if err != nil {
+			results[i].Err = err
+			continue
+		}
+		{{cookiecutter.entity_name_lower}} := entity.New{{cookiecutter.entity_name}}(row.Name)
+		{{cookiecutter.entity_name_lower}}.CreatedBy = createdBy
+		results[i].{{cookiecutter.entity_name}} = {{cookiecutter.entity_name_lower}}
+		valid = append(valid, i)
+	}
+
+	if mode == Atomic {
+		if len(valid) < len(rows) {
+			for _, i := range valid {
+				results[i] = ImportResult{Err: ErrImportAborted}
+			}
+			return results, nil
+		}
+		err := s.repo.WithTx(ctx, func(ctx context.Context) error {
+			return s.createBatches(ctx, results, valid)
+		})
+		if err != nil {
+			return nil, err
+		}
+		return results, nil
+	}
+
+	for start := 0; start < len(valid); start += importBatchSize {
+		batch := valid[start:min(start+importBatchSize, len(valid))]
+		err := s.repo.WithTx(ctx, func(ctx context.Context) error {
+			return s.createBatches(ctx, results, batch)
+		})
+		if err == nil {
+			continue
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		for _, i := range batch {
+			err := s.repo.WithTx(ctx, func(ctx context.Context) error {
+				return s.createBatches(ctx, results, []int{i})
+			})
+			if err != nil {
+				results[i] = ImportResult{Err: err}
+			}
+		}
+	}
+	return results, nil
+}
+
+// createBatches inserts the {{cookiecutter.entity_name_lower}}s of results at indexes, importBatchSize at a
+// time, and publishes their created events.
+func (s *{{cookiecutter.entity_name_lower}}Service) createBatches(ctx context.Context, results []ImportResult, indexes []int) error {
+	{{cookiecutter.entity_name_lower}}s := make([]*entity.{{cookiecutter.entity_name}}, len(indexes))
+	for j, i := range indexes {
+		{{cookiecutter.entity_name_lower}}s[j] = results[i].{{cookiecutter.entity_name}}
+	}
+	if err := s.repo.CreateBatch(ctx, {{cookiecutter.entity_name_lower}}s, importBatchSize); err != nil {
+		return err
+	}
+	for _, {{cookiecutter.entity_name_lower}} := range {{cookiecutter.entity_name_lower}}s {
+		if err := s.publish(ctx, Event{{cookiecutter.entity_name}}Created, {{cookiecutter.entity_name_lower}}.ID, nil, {{cookiecutter.entity_name_lower}}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Batch applies ops in order with Create, Update and Delete and returns a
+// result per operation, in the order of ops. In Atomic mode the operations
+// share a transaction: the first that fails rolls back the others, which are
+// left with ErrBatchAborted. In BestEffort mode every operation commits on
+// its own. Errors that stop the whole batch, such as a failed commit in
+// Atomic mode, are returned instead.
+func (s *{{cookiecutter.entity_name_lower}}Service) Batch(ctx context.Context, ops []BatchOperation, mode WriteMode) ([]BatchResult, error) {
+	results := make([]BatchResult, len(ops))
+	if mode == BestEffort {
+		for i, op := range ops {
+			results[i].{{cookiecutter.entity_name}}, results[i].Err = s.apply(ctx, op)
+		}
+		return results, nil
+	}
+
+	failed := -1
+	err := s.repo.WithTx(ctx, func(ctx context.Context) error {
+		for i, op := range ops {
+			results[i].{{cookiecutter.entity_name}}, results[i].Err = s.apply(ctx, op)
+			if results[i].Err != nil {
+				failed = i
+				return results[i].Err
+			}
+		}
+		return nil
+	})
+	if failed < 0 {
+		if err != nil {
+			return nil, err
+		}
+		return results, nil
+	}
+	for i := range results {
+		if i != failed {
+			results[i] = BatchResult{Err: ErrBatchAborted}
+		}
+	}
+	return results, nil
+}
+
+// apply runs an operation of a batch.
+func (s *{{cookiecutter.entity_name_lower}}Service) apply(ctx context.Context, op BatchOperation) (*entity.{{cookiecutter.entity_name}}, error) {
+	switch op.Op {
+	case BatchCreate:
+		return s.Create(ctx, op.Name)
+	case BatchUpdate:
+		return s.Update(ctx, op.ID, op.Name, op.Version)
+	case BatchDelete:
+		return nil, s.Delete(ctx, op.ID, op.Version)
+	}
+	return nil, fmt.Errorf("unknown batch operation %q", op.Op)
+}
+
 func (s *{{cookiecutter.entity_name_lower}}Service) Get(ctx context.Context, id string) (*entity.{{cookiecutter.entity_name}}, error) {
 	uuidID, err := uuid.Parse(id)
 	if err != nil {
@@ -60,9 +326,11 @@ func (s *{{cookiecutter.entity_name_lower}}Service) Get(ctx context.Context, id
 	return {{cookiecutter.entity_name_lower}}, nil
 }
 
-func (s *{{cookiecutter.entity_name_lower}}Service) Update(ctx context.Context, id string, name string) (*entity.{{cookiecutter.entity_name}}, error) {
-	if name == "" {
-		return nil, ErrNameRequired
+// Update renames a {{cookiecutter.entity_name_lower}} when it is still at version, so concurrent
+// edits fail with ErrVersionConflict instead of overwriting each other.
+func (s *{{cookiecutter.entity_name_lower}}Service) Update(ctx context.Context, id string, name string, version int64) (*entity.{{cookiecutter.entity_name}}, error) {
+	if err := validate(name); err != nil {
+		return nil, err
 	}
 
 	uuidID, err := uuid.Parse(id)
@@ -70,38 +338,173 @@ func (s *{{cookiecutter.entity_name_lower}}Service) Update(ctx context.Context,
 		return nil, ErrInvalidID
 	}
 
-	{{cookiecutter.entity_name_lower}}, err := s.repo.GetByID(ctx, uuidID)
-	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, Err{{cookiecutter.entity_name}}NotFound
+	var {{cookiecutter.entity_name_lower}} *entity.{{cookiecutter.entity_name}}
+	err = s.repo.WithTx(ctx, func(ctx context.Context) error {
+		{{cookiecutter.entity_name_lower}}, err = s.getVersion(ctx, uuidID, version, ActionUpdate)
+		if err != nil {
+			return err
 		}
-		return nil, err
+		before := *{{cookiecutter.entity_name_lower}}
+
+		{{cookiecutter.entity_name_lower}}.Name = name
+		if err := s.repo.UpdateIfVersion(ctx, {{cookiecutter.entity_name_lower}}, version); err != nil {
+			return err
+		}
+		return s.publish(ctx, Event{{cookiecutter.entity_name}}Updated, uuidID, &before, {{cookiecutter.entity_name_lower}})
+	})
+	if err != nil {
+		return nil, versionError(err)
 	}
 
-	{{cookiecutter.entity_name_lower}}.Name = name
+	return {{cookiecutter.entity_name_lower}}, nil
+}
 
-	if err := s.repo.Update(ctx, {{cookiecutter.entity_name_lower}}); err != nil {
-		return nil, err
+// Patch applies patch to a {{cookiecutter.entity_name_lower}} when it is still at version. The patched
+// document goes through the same validation as Create and only the columns
+// that actually changed are written.
+func (s *{{cookiecutter.entity_name_lower}}Service) Patch(ctx context.Context, id string, version int64, patch PatchFunc) (*entity.{{cookiecutter.entity_name}}, error) {
+	uuidID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, ErrInvalidID
 	}
 
+	var {{cookiecutter.entity_name_lower}} *entity.{{cookiecutter.entity_name}}
+	err = s.repo.WithTx(ctx, func(ctx context.Context) error {
+		before, err := s.getVersion(ctx, uuidID, version, ActionUpdate)
+		if err != nil {
+			return err
+		}
+
+		edited, err := applyPatch(before, patch)
+		if err != nil {
+			return err
+		}
+
+		columns := map[string]any{}
+		if edited.Name != before.Name {
+			columns["name"] = edited.Name
+		}
+		if len(columns) == 0 {
+			{{cookiecutter.entity_name_lower}} = before
+			return nil
+		}
+
+		if err := s.repo.UpdateColumnsIfVersion(ctx, uuidID, version, columns); err != nil {
+			return err
+		}
+		if {{cookiecutter.entity_name_lower}}, err = s.repo.GetByID(ctx, uuidID); err != nil {
+			return err
+		}
+		return s.publish(ctx, Event{{cookiecutter.entity_name}}Updated, uuidID, before, {{cookiecutter.entity_name_lower}})
+	})
+	if err != nil {
+		return nil, versionError(err)
+	}
 	return {{cookiecutter.entity_name_lower}}, nil
 }
 
-func (s *{{cookiecutter.entity_name_lower}}Service) Delete(ctx context.Context, id string) error {
+// applyPatch returns the editable fields of {{cookiecutter.entity_name_lower}} after patch, validated like Create.
+func applyPatch({{cookiecutter.entity_name_lower}} *entity.{{cookiecutter.entity_name}}, patch PatchFunc) (editable{{cookiecutter.entity_name}}, error) {
+	doc, err := json.Marshal(editable{{cookiecutter.entity_name}}{Name: {{cookiecutter.entity_name_lower}}.Name})
+	if err != nil {
+		return editable{{cookiecutter.entity_name}}{}, err
+	}
+	patched, err := patch(doc)
+	if err != nil {
+		return editable{{cookiecutter.entity_name}}{}, fmt.Errorf("%w: %w", ErrInvalidPatch, err)
+	}
+
+	var edited editable{{cookiecutter.entity_name}}
+	dec := json.NewDecoder(bytes.NewReader(patched))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&edited); err != nil {
+		return editable{{cookiecutter.entity_name}}{}, fmt.Errorf("%w: %w", ErrInvalidPatch, err)
+	}
+	if err := validate(edited.Name); err != nil {
+		return editable{{cookiecutter.entity_name}}{}, err
+	}
+	return edited, nil
+}
+
+// Delete soft deletes a {{cookiecutter.entity_name_lower}} when it is still at version, it can be
+// brought back with Restore.
+func (s *{{cookiecutter.entity_name_lower}}Service) Delete(ctx context.Context, id string, version int64) error {
 	uuidID, err := uuid.Parse(id)
 	if err != nil {
 		return ErrInvalidID
 	}
-	return s.repo.Delete(ctx, uuidID)
+
+	err = s.repo.WithTx(ctx, func(ctx context.Context) error {
+		before, err := s.getVersion(ctx, uuidID, version, ActionDelete)
+		if err != nil {
+			return err
+		}
+		if err := s.repo.DeleteIfVersion(ctx, uuidID, version); err != nil {
+			return err
+		}
+		return s.publish(ctx, Event{{cookiecutter.entity_name}}Deleted, uuidID, before, nil)
+	})
+	if err != nil {
+		return versionError(err)
+	}
+	return nil
 }
 
-func (s *{{cookiecutter.entity_name_lower}}Service) List(ctx context.Context, limitStr, offsetStr string) ([]entity.{{cookiecutter.entity_name}}, int, error) {
-	limit := 10
-	if limitStr != "" {
-		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 {
-			limit = l
+// Restore brings back a soft deleted {{cookiecutter.entity_name_lower}}. The restore and the read of the
+// restored {{cookiecutter.entity_name_lower}} share a transaction so the caller never sees a later change.
+func (s *{{cookiecutter.entity_name_lower}}Service) Restore(ctx context.Context, id string) (*entity.{{cookiecutter.entity_name}}, error) {
+	uuidID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, ErrInvalidID
+	}
+
+	var {{cookiecutter.entity_name_lower}} *entity.{{cookiecutter.entity_name}}
+	err = s.repo.WithTx(ctx, func(ctx context.Context) error {
+		// restoring a {{cookiecutter.entity_name_lower}} that is not deleted changes nothing and raises
+		// no event, so retried restores succeed
+		err := s.repo.Restore(ctx, uuidID)
+		restored := err == nil
+		if err != nil && !errors.Is(err, repository.ErrNotDeleted) {
+			return err
+		}
+		if {{cookiecutter.entity_name_lower}}, err = s.repo.GetByID(ctx, uuidID); err != nil {
+			return err
+		}
+		// a denied restore rolls back
+		if err := s.authorize(ctx, ActionRestore, {{cookiecutter.entity_name_lower}}); err != nil {
+			return err
+		}
+		if !restored {
+			return nil
+		}
+		return s.publish(ctx, Event{{cookiecutter.entity_name}}Restored, uuidID, nil, {{cookiecutter.entity_name_lower}})
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, Err{{cookiecutter.entity_name}}NotFound
+		}
+		return nil, err
+	}
+	return {{cookiecutter.entity_name_lower}}, nil
+}
+
+// Purge permanently removes a {{cookiecutter.entity_name_lower}}, deleted or not.
+func (s *{{cookiecutter.entity_name_lower}}Service) Purge(ctx context.Context, id string) error {
+	uuidID, err := uuid.Parse(id)
+	if err != nil {
+		return ErrInvalidID
+	}
+	if err := s.repo.Purge(ctx, uuidID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return Err{{cookiecutter.entity_name}}NotFound
 		}
+		return err
 	}
+	return nil
+}
+
+func (s *{{cookiecutter.entity_name_lower}}Service) List(ctx context.Context, limitStr, offsetStr string, params query.Params) ([]entity.{{cookiecutter.entity_name}}, int, error) {
+	limit := parseLimit(limitStr)
 
 	offset := 0
 	if offsetStr != "" {
@@ -110,13 +513,105 @@ func (s *{{cookiecutter.entity_name_lower}}Service) List(ctx context.Context, li
 		}
 	}
 
-	{{cookiecutter.entity_name_lower}}s, err := s.repo.List(ctx, limit, offset)
+	{{cookiecutter.entity_name_lower}}s, total, err := s.repo.ListWithCount(ctx, limit, offset, params.Sorts, repository.Where(params.Filters))
 	if err != nil {
 		return nil, 0, err
 	}
 
-	// TODO: Get total count from DB
-	total := len({{cookiecutter.entity_name_lower}}s) // Simplified
+	return {{cookiecutter.entity_name_lower}}s, int(total), nil
+}
+
+// ListByCursor retrieves a page of {{cookiecutter.entity_name_lower}}s with keyset pagination.
+// An empty cursor starts at the first {{cookiecutter.entity_name_lower}}. Keyset pages are always
+// ordered by (created_at, id) so params.Sorts is ignored.
+func (s *{{cookiecutter.entity_name_lower}}Service) ListByCursor(ctx context.Context, limitStr, cursor string, params query.Params) (*repository.CursorPage[entity.{{cookiecutter.entity_name}}], error) {
+	page, err := s.repo.ListByCursor(ctx, parseLimit(limitStr), cursor, repository.Where(params.Filters))
+	if err != nil {
+		if errors.Is(err, repository.ErrInvalidCursor) {
+			return nil, ErrInvalidCursor
+		}
+		return nil, err
+	}
+	return page, nil
+}
+
+// exportBatchSize is the number of {{cookiecutter.entity_name_lower}}s Export reads per query.
+const exportBatchSize = 500
+
+// Export calls fn with every {{cookiecutter.entity_name_lower}} that matches params.Filters, in batches
+// ordered by (created_at, id), so params.Sorts is ignored. Only one batch is
+// held in memory. It stops at the first error of fn and when ctx is done.
+func (s *{{cookiecutter.entity_name_lower}}Service) Export(ctx context.Context, params query.Params, fn func(batch []entity.{{cookiecutter.entity_name}}) error) error {
+	return s.repo.Each(ctx, exportBatchSize, fn, repository.Where(params.Filters))
+}
+
+// getVersion reads a {{cookiecutter.entity_name_lower}} the caller may perform action on and checks
+// that it is still at version.
+func (s *{{cookiecutter.entity_name_lower}}Service) getVersion(ctx context.Context, id uuid.UUID, version int64, action Action) (*entity.{{cookiecutter.entity_name}}, error) {
+	{{cookiecutter.entity_name_lower}}, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.authorize(ctx, action, {{cookiecutter.entity_name_lower}}); err != nil {
+		return nil, err
+	}
+	if {{cookiecutter.entity_name_lower}}.Version != version {
+		return nil, ErrVersionConflict
+	}
+	return {{cookiecutter.entity_name_lower}}, nil
+}
+
+// publish raises eventType for the {{cookiecutter.entity_name_lower}} with the given snapshots, nil
+// before or after are published as null.
+func (s *{{cookiecutter.entity_name_lower}}Service) publish(ctx context.Context, eventType string, id uuid.UUID, before, after *entity.{{cookiecutter.entity_name}}) error {
+	e, err := event.New(ctx, eventType, {{cookiecutter.entity_name}}AggregateType, id.String(), snapshot(before), snapshot(after))
+	if err != nil {
+		return err
+	}
+	return s.events.Publish(ctx, e)
+}
 
-	return {{cookiecutter.entity_name_lower}}s, total, nil
+func snapshot({{cookiecutter.entity_name_lower}} *entity.{{cookiecutter.entity_name}}) *{{cookiecutter.entity_name}}Snapshot {
+	if {{cookiecutter.entity_name_lower}} == nil {
+		return nil
+	}
+	return &{{cookiecutter.entity_name}}Snapshot{
+		ID:        {{cookiecutter.entity_name_lower}}.ID.String(),
+		Name:      {{cookiecutter.entity_name_lower}}.Name,
+		Version:   {{cookiecutter.entity_name_lower}}.Version,
+		CreatedBy: {{cookiecutter.entity_name_lower}}.CreatedBy,
+		CreatedAt: {{cookiecutter.entity_name_lower}}.CreatedAt,
+		UpdatedAt: {{cookiecutter.entity_name_lower}}.UpdatedAt,
+	}
+}
+
+// validate checks the fields clients provide on create, update and patch.
+// Its errors are validation.Errors.
+func validate(name string) error {
+	var v validation.Validator
+	validation.Field(&v, "name", name, entity.{{cookiecutter.entity_name}}NameRules...)
+	return v.Err()
+}
+
+// versionError maps errors of versioned repository writes to service errors.
+func versionError(err error) error {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return Err{{cookiecutter.entity_name}}NotFound
+	case errors.Is(err, repository.ErrVersionConflict):
+		return ErrVersionConflict
+	default:
+		return err
+	}
+}
+
+// parseLimit reads a page size, falling back to 10 when it is missing or invalid.
+func parseLimit(limitStr string) int {
+	limit := 10
+	if limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 {
+			limit = l
+		}
+	}
+	return limit
 }
@@ -0,0 +1,67 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"{{cookiecutter.module_name}}/internal/auth"
+	"{{cookiecutter.module_name}}/internal/entity"
+	"{{cookiecutter.module_name}}/internal/logger"
+)
+
+var ErrForbidden = errors.New("access denied")
+
+// AdminRole is the role that may change any {{cookiecutter.entity_name_lower}} under OwnerPolicy.
+const AdminRole = "admin"
+
+// Action is a change a caller asks {{cookiecutter.entity_name}}Service to make to a {{cookiecutter.entity_name_lower}}.
+type Action string
+
+const (
+	ActionUpdate  Action = "update"
+	ActionDelete  Action = "delete"
+	ActionRestore Action = "restore"
+)
+
+// Policy decides whether the caller in ctx may perform action on {{cookiecutter.entity_name_lower}}.
+// It runs in the transaction of the change, after the {{cookiecutter.entity_name_lower}} is read and
+// before it is written, and denies by returning ErrForbidden.
+type Policy func(ctx context.Context, action Action, {{cookiecutter.entity_name_lower}} *entity.{{cookiecutter.entity_name}}) error
+
+// AllowAll is the Policy that permits everything.
+func AllowAll(ctx context.Context, action Action, {{cookiecutter.entity_name_lower}} *entity.{{cookiecutter.entity_name}}) error {
+	return nil
+}
+
+// OwnerPolicy only lets the creator of a {{cookiecutter.entity_name_lower}} and principals with
+// adminRole change it. Calls without a principal are allowed, they come from
+// background work or from local development without authentication.
+func OwnerPolicy(adminRole string) Policy {
+	return func(ctx context.Context, action Action, {{cookiecutter.entity_name_lower}} *entity.{{cookiecutter.entity_name}}) error {
+		principal, ok := auth.FromContext(ctx)
+		if !ok || principal.HasRole(adminRole) {
+			return nil
+		}
+		if {{cookiecutter.entity_name_lower}}.CreatedBy != "" && {{cookiecutter.entity_name_lower}}.CreatedBy == principal.Subject {
+			return nil
+		}
+		return ErrForbidden
+	}
+}
+
+// authorize asks the policy and logs its decision on the request logger,
+// which carries the correlation ID.
+func (s *{{cookiecutter.entity_name_lower}}Service) authorize(ctx context.Context, action Action, {{cookiecutter.entity_name_lower}} *entity.{{cookiecutter.entity_name}}) error {
+	err := s.policy(ctx, action, {{cookiecutter.entity_name_lower}})
+	log := logger.FromContext(ctx).With(
+		slog.String("action", string(action)),
+		slog.String("{{cookiecutter.entity_name_lower}}_id", {{cookiecutter.entity_name_lower}}.ID.String()),
+	)
+	if err != nil {
+		log.Info("authorization denied", slog.String("error", err.Error()))
+		return err
+	}
+	log.Info("authorization granted")
+	return nil
+}
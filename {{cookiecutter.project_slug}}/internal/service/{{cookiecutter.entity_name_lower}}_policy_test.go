@@ -0,0 +1,159 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"{{cookiecutter.module_name}}/internal/auth"
+	"{{cookiecutter.module_name}}/internal/event"
+)
+
+func Test{{cookiecutter.entity_name}}Service_OwnerPolicy(t *testing.T) {
+	owner := auth.ToContext(context.Background(), &auth.Principal{Subject: "owner"})
+	other := auth.ToContext(context.Background(), &auth.Principal{Subject: "other"})
+	admin := auth.ToContext(context.Background(), &auth.Principal{Subject: "admin", Roles: []string{AdminRole}})
+	anonymous := context.Background()
+
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		wantErr error
+	}{
+		{
+			name: "Owner",
+			ctx:  owner,
+		},
+		{
+			name:    "Other Subject",
+			ctx:     other,
+			wantErr: ErrForbidden,
+		},
+		{
+			name: "Admin",
+			ctx:  admin,
+		},
+		{
+			name: "No Principal",
+			ctx:  anonymous,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := setupTestDB(t)
+			svc := New{{cookiecutter.entity_name}}Service(repo, event.NewBus(), OwnerPolicy(AdminRole))
+
+			created, err := svc.Create(owner, "Owned")
+			if err != nil {
+				t.Fatalf("Create() error = %v", err)
+			}
+			if created.CreatedBy != "owner" {
+				t.Fatalf("CreatedBy = %q, want owner", created.CreatedBy)
+			}
+			id := created.ID.String()
+			version := func() int64 {
+				t.Helper()
+				current, err := svc.Get(owner, id)
+				if err != nil {
+					t.Fatalf("Get() error = %v", err)
+				}
+				return current.Version
+			}
+
+			_, err = svc.Update(tt.ctx, id, "Renamed", version())
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Update() error = %v, want %v", err, tt.wantErr)
+			}
+
+			_, err = svc.Patch(tt.ctx, id, version(), func(doc []byte) ([]byte, error) {
+				return []byte(`{"name":"Patched"}`), nil
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Patch() error = %v, want %v", err, tt.wantErr)
+			}
+
+			// delete as the owner, then try to restore as the caller
+			if err := svc.Delete(owner, id, version()); err != nil {
+				t.Fatalf("Delete() error = %v", err)
+			}
+			_, err = svc.Restore(tt.ctx, id)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Restore() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				// the denied restore was rolled back
+				if _, err := svc.Get(owner, id); !errors.Is(err, Err{{cookiecutter.entity_name}}NotFound) {
+					t.Errorf("Get() after denied restore error = %v, want Err{{cookiecutter.entity_name}}NotFound", err)
+				}
+				if _, err := svc.Restore(owner, id); err != nil {
+					t.Fatalf("Restore() error = %v", err)
+				}
+			}
+
+			err = svc.Delete(tt.ctx, id, version())
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Delete() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test{{cookiecutter.entity_name}}Service_OwnerPolicyWithoutCreator(t *testing.T) {
+	repo := setupTestDB(t)
+	svc := New{{cookiecutter.entity_name}}Service(repo, event.NewBus(), OwnerPolicy(AdminRole))
+
+	// created while authentication was off
+	created, err := svc.Create(context.Background(), "Unowned")
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	ctx := auth.ToContext(context.Background(), &auth.Principal{Subject: "someone"})
+	if _, err := svc.Update(ctx, created.ID.String(), "Renamed", created.Version); !errors.Is(err, ErrForbidden) {
+		t.Errorf("Update() error = %v, want ErrForbidden", err)
+	}
+}
+
+func Test{{cookiecutter.entity_name}}Service_OwnerPolicy_APIKeyRotation(t *testing.T) {
+	keys, _ := setupAPIKeyService(t)
+	svc := New{{cookiecutter.entity_name}}Service(setupTestDB(t), event.NewBus(), OwnerPolicy(AdminRole))
+	ctx := context.Background()
+
+	as := func(key string) context.Context {
+		t.Helper()
+		principal, err := keys.Verify(ctx, key)
+		if err != nil {
+			t.Fatalf("Verify() error = %v", err)
+		}
+		return auth.ToContext(ctx, principal)
+	}
+
+	old, oldKey, err := keys.Mint(ctx, "batch-job", []string{"{{cookiecutter.entity_name_lower}}:write"}, nil)
+	if err != nil {
+		t.Fatalf("Mint() error = %v", err)
+	}
+	created, err := svc.Create(as(oldKey), "Owned By Key")
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	// the replacement is the same caller, it keeps access to what the old key created
+	_, newKey, err := keys.Rotate(ctx, old.ID.String(), time.Hour)
+	if err != nil {
+		t.Fatalf("Rotate() error = %v", err)
+	}
+	if _, err := svc.Update(as(newKey), created.ID.String(), "Renamed", created.Version); err != nil {
+		t.Errorf("Update() after rotation error = %v", err)
+	}
+
+	// another key is not
+	_, otherKey, err := keys.Mint(ctx, "batch-job", []string{"{{cookiecutter.entity_name_lower}}:write"}, nil)
+	if err != nil {
+		t.Fatalf("Mint() error = %v", err)
+	}
+	if _, err := svc.Update(as(otherKey), created.ID.String(), "Taken", created.Version+1); !errors.Is(err, ErrForbidden) {
+		t.Errorf("Update() with another key error = %v, want %v", err, ErrForbidden)
+	}
+}
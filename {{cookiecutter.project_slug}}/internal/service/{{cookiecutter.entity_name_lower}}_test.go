@@ -2,13 +2,19 @@ package service
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"testing"
 
 	"github.com/google/uuid"
 	"{{cookiecutter.module_name}}/internal/db"
 	"{{cookiecutter.module_name}}/internal/entity"
+	"{{cookiecutter.module_name}}/internal/event"
+	"{{cookiecutter.module_name}}/internal/logger"
+	"{{cookiecutter.module_name}}/internal/query"
 	"{{cookiecutter.module_name}}/internal/repository"
+	"{{cookiecutter.module_name}}/internal/validation"
 )
 
 func setupTestDB(t *testing.T) *repository.EntityRepository[entity.{{cookiecutter.entity_name}}] {
@@ -45,7 +51,7 @@ func Test{{cookiecutter.entity_name}}Service_Create(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			repo := setupTestDB(t)
-			svc := New{{cookiecutter.entity_name}}Service(repo)
+			svc := New{{cookiecutter.entity_name}}Service(repo, event.NewBus(), nil)
 
 			got, err := svc.Create(context.Background(), tt.pName)
 			if (err != nil) != tt.wantErr {
@@ -64,7 +70,7 @@ func Test{{cookiecutter.entity_name}}Service_Create(t *testing.T) {
 
 func Test{{cookiecutter.entity_name}}Service_Get(t *testing.T) {
 	repo := setupTestDB(t)
-	svc := New{{cookiecutter.entity_name}}Service(repo)
+	svc := New{{cookiecutter.entity_name}}Service(repo, event.NewBus(), nil)
 	ctx := context.Background()
 
 	created, err := svc.Create(ctx, "Existing")
@@ -106,7 +112,7 @@ func Test{{cookiecutter.entity_name}}Service_Get(t *testing.T) {
 
 func Test{{cookiecutter.entity_name}}Service_Update(t *testing.T) {
 	repo := setupTestDB(t)
-	svc := New{{cookiecutter.entity_name}}Service(repo)
+	svc := New{{cookiecutter.entity_name}}Service(repo, event.NewBus(), nil)
 	ctx := context.Background()
 
 	created, err := svc.Create(ctx, "Original")
@@ -118,37 +124,49 @@ func Test{{cookiecutter.entity_name}}Service_Update(t *testing.T) {
 		name    string
 		id      string
 		newName string
+		version int64
 		wantErr bool
 	}{
 		{
 			name:    "Success",
 			id:      created.ID.String(),
 			newName: "Updated",
+			version: 1,
 			wantErr: false,
 		},
 		{
 			name:    "Not Found",
 			id:      uuid.New().String(),
 			newName: "Updated",
+			version: 1,
 			wantErr: true,
 		},
 		{
 			name:    "Empty Name",
 			id:      created.ID.String(),
 			newName: "",
+			version: 1,
 			wantErr: true,
 		},
 		{
 			name:    "Invalid ID",
 			id:      "invalid-uuid",
 			newName: "Updated",
+			version: 1,
+			wantErr: true,
+		},
+		{
+			name:    "Stale Version",
+			id:      created.ID.String(),
+			newName: "Lost Update",
+			version: 1,
 			wantErr: true,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := svc.Update(ctx, tt.id, tt.newName)
+			got, err := svc.Update(ctx, tt.id, tt.newName, tt.version)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("{{cookiecutter.entity_name}}Service.Update() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -158,6 +176,119 @@ func Test{{cookiecutter.entity_name}}Service_Update(t *testing.T) {
 				if got.Name != tt.newName {
 					t.Errorf("{{cookiecutter.entity_name}}Service.Update() Name = %v, want %v", got.Name, tt.newName)
 				}
+				if got.Version != tt.version+1 {
+					t.Errorf("{{cookiecutter.entity_name}}Service.Update() Version = %v, want %v", got.Version, tt.version+1)
+				}
+			}
+		})
+	}
+}
+
+func Test{{cookiecutter.entity_name}}Service_Patch(t *testing.T) {
+	repo := setupTestDB(t)
+	svc := New{{cookiecutter.entity_name}}Service(repo, event.NewBus(), nil)
+	ctx := context.Background()
+
+	created, err := svc.Create(ctx, "Original")
+	if err != nil {
+		t.Fatalf("failed to create {{cookiecutter.entity_name_lower}}: %v", err)
+	}
+
+	replace := func(doc string) PatchFunc {
+		return func([]byte) ([]byte, error) {
+			return []byte(doc), nil
+		}
+	}
+	unchanged := func(doc []byte) ([]byte, error) {
+		return doc, nil
+	}
+	failing := func([]byte) ([]byte, error) {
+		return nil, errors.New("test operation failed")
+	}
+
+	tests := []struct {
+		name        string
+		id          string
+		version     int64
+		patch       PatchFunc
+		wantErr     error
+		wantName    string
+		wantVersion int64
+	}{
+		{
+			name:        "Success",
+			id:          created.ID.String(),
+			version:     1,
+			patch:       replace(`{"name":"Patched"}`),
+			wantName:    "Patched",
+			wantVersion: 2,
+		},
+		{
+			name:        "No Change",
+			id:          created.ID.String(),
+			version:     2,
+			patch:       unchanged,
+			wantName:    "Patched",
+			wantVersion: 2,
+		},
+		{
+			name:    "Unknown Field",
+			id:      created.ID.String(),
+			version: 2,
+			patch:   replace(`{"name":"Patched","owner":"someone"}`),
+			wantErr: ErrInvalidPatch,
+		},
+		{
+			name:    "Patch Fails",
+			id:      created.ID.String(),
+			version: 2,
+			patch:   failing,
+			wantErr: ErrInvalidPatch,
+		},
+		{
+			name:    "Empty Name",
+			id:      created.ID.String(),
+			version: 2,
+			patch:   replace(`{"name":""}`),
+			wantErr: ErrNameRequired,
+		},
+		{
+			name:    "Stale Version",
+			id:      created.ID.String(),
+			version: 1,
+			patch:   replace(`{"name":"Lost Update"}`),
+			wantErr: ErrVersionConflict,
+		},
+		{
+			name:    "Not Found",
+			id:      uuid.New().String(),
+			version: 1,
+			patch:   unchanged,
+			wantErr: Err{{cookiecutter.entity_name}}NotFound,
+		},
+		{
+			name:    "Invalid ID",
+			id:      "invalid-uuid",
+			version: 1,
+			patch:   unchanged,
+			wantErr: ErrInvalidID,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := svc.Patch(ctx, tt.id, tt.version, tt.patch)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("{{cookiecutter.entity_name}}Service.Patch() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if got.Name != tt.wantName {
+				t.Errorf("{{cookiecutter.entity_name}}Service.Patch() Name = %v, want %v", got.Name, tt.wantName)
+			}
+			if got.Version != tt.wantVersion {
+				t.Errorf("{{cookiecutter.entity_name}}Service.Patch() Version = %v, want %v", got.Version, tt.wantVersion)
 			}
 		})
 	}
@@ -165,39 +296,62 @@ func Test{{cookiecutter.entity_name}}Service_Update(t *testing.T) {
 
 func Test{{cookiecutter.entity_name}}Service_Delete(t *testing.T) {
 	repo := setupTestDB(t)
-	svc := New{{cookiecutter.entity_name}}Service(repo)
+	svc := New{{cookiecutter.entity_name}}Service(repo, event.NewBus(), nil)
 	ctx := context.Background()
 
 	created, err := svc.Create(ctx, "To Delete")
 	if err != nil {
 		t.Fatalf("failed to create {{cookiecutter.entity_name_lower}}: %v", err)
 	}
+	edited, err := svc.Create(ctx, "Edited")
+	if err != nil {
+		t.Fatalf("failed to create {{cookiecutter.entity_name_lower}}: %v", err)
+	}
+	if _, err := svc.Update(ctx, edited.ID.String(), "Edited Again", edited.Version); err != nil {
+		t.Fatalf("failed to update {{cookiecutter.entity_name_lower}}: %v", err)
+	}
 
 	tests := []struct {
 		name    string
 		id      string
+		version int64
 		wantErr bool
 	}{
 		{
 			name:    "Success",
 			id:      created.ID.String(),
+			version: 1,
 			wantErr: false,
 		},
 		{
 			name:    "Random ID",
 			id:      uuid.New().String(),
-			wantErr: false,
+			version: 1,
+			wantErr: true,
+		},
+		{
+			name:    "Already Deleted",
+			id:      created.ID.String(),
+			version: 1,
+			wantErr: true,
 		},
 		{
 			name:    "Invalid ID",
 			id:      "invalid-uuid",
+			version: 1,
+			wantErr: true,
+		},
+		{
+			name:    "Stale Version",
+			id:      edited.ID.String(),
+			version: 1,
 			wantErr: true,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := svc.Delete(ctx, tt.id)
+			err := svc.Delete(ctx, tt.id, tt.version)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("{{cookiecutter.entity_name}}Service.Delete() error = %v, wantErr %v", err, tt.wantErr)
 			}
@@ -207,7 +361,7 @@ func Test{{cookiecutter.entity_name}}Service_Delete(t *testing.T) {
 
 func Test{{cookiecutter.entity_name}}Service_List(t *testing.T) {
 	repo := setupTestDB(t)
-	svc := New{{cookiecutter.entity_name}}Service(repo)
+	svc := New{{cookiecutter.entity_name}}Service(repo, event.NewBus(), nil)
 	ctx := context.Background()
 
 	// Create some {{cookiecutter.entity_name_lower}}
@@ -278,7 +432,7 @@ func Test{{cookiecutter.entity_name}}Service_List(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, total, err := svc.List(ctx, tt.limit, tt.offset)
+			got, total, err := svc.List(ctx, tt.limit, tt.offset, query.Params{})
 			if (err != nil) != tt.wantErr {
 				t.Errorf("{{cookiecutter.entity_name}}Service.List() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -287,11 +441,425 @@ func Test{{cookiecutter.entity_name}}Service_List(t *testing.T) {
 				if len(got) != tt.wantCount {
 					t.Errorf("{{cookiecutter.entity_name}}Service.List() count = %v, want %v", len(got), tt.wantCount)
 				}
-				if total != tt.wantCount {
-					// Note: total in our simple implementation is just len(got)
-					t.Errorf("{{cookiecutter.entity_name}}Service.List() total = %v, want %v", total, tt.wantCount)
+				if total != 15 {
+					t.Errorf("{{cookiecutter.entity_name}}Service.List() total = %v, want %v", total, 15)
+				}
+			}
+		})
+	}
+}
+
+func Test{{cookiecutter.entity_name}}Service_Restore(t *testing.T) {
+	repo := setupTestDB(t)
+	svc := New{{cookiecutter.entity_name}}Service(repo, event.NewBus(), nil)
+	ctx := context.Background()
+
+	created, err := svc.Create(ctx, "To Restore")
+	if err != nil {
+		t.Fatalf("failed to create {{cookiecutter.entity_name_lower}}: %v", err)
+	}
+	if err := svc.Delete(ctx, created.ID.String(), created.Version); err != nil {
+		t.Fatalf("failed to delete {{cookiecutter.entity_name_lower}}: %v", err)
+	}
+	if _, err := svc.Get(ctx, created.ID.String()); !errors.Is(err, Err{{cookiecutter.entity_name}}NotFound) {
+		t.Fatalf("expected deleted {{cookiecutter.entity_name_lower}} to be hidden, got %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		id      string
+		wantErr error
+	}{
+		{
+			name:    "Success",
+			id:      created.ID.String(),
+			wantErr: nil,
+		},
+		{
+			name:    "Not Deleted",
+			id:      created.ID.String(),
+			wantErr: nil,
+		},
+		{
+			name:    "Not Found",
+			id:      uuid.New().String(),
+			wantErr: Err{{cookiecutter.entity_name}}NotFound,
+		},
+		{
+			name:    "Invalid ID",
+			id:      "invalid-uuid",
+			wantErr: ErrInvalidID,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := svc.Restore(ctx, tt.id)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("{{cookiecutter.entity_name}}Service.Restore() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && got.ID != created.ID {
+				t.Errorf("{{cookiecutter.entity_name}}Service.Restore() ID = %v, want %v", got.ID, created.ID)
+			}
+		})
+	}
+}
+
+func Test{{cookiecutter.entity_name}}Service_Purge(t *testing.T) {
+	repo := setupTestDB(t)
+	svc := New{{cookiecutter.entity_name}}Service(repo, event.NewBus(), nil)
+	ctx := context.Background()
+
+	created, err := svc.Create(ctx, "To Purge")
+	if err != nil {
+		t.Fatalf("failed to create {{cookiecutter.entity_name_lower}}: %v", err)
+	}
+	if err := svc.Delete(ctx, created.ID.String(), created.Version); err != nil {
+		t.Fatalf("failed to delete {{cookiecutter.entity_name_lower}}: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		id      string
+		wantErr error
+	}{
+		{
+			name:    "Soft Deleted",
+			id:      created.ID.String(),
+			wantErr: nil,
+		},
+		{
+			name:    "Already Purged",
+			id:      created.ID.String(),
+			wantErr: Err{{cookiecutter.entity_name}}NotFound,
+		},
+		{
+			name:    "Invalid ID",
+			id:      "invalid-uuid",
+			wantErr: ErrInvalidID,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := svc.Purge(ctx, tt.id)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("{{cookiecutter.entity_name}}Service.Purge() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	if _, err := svc.Restore(ctx, created.ID.String()); !errors.Is(err, Err{{cookiecutter.entity_name}}NotFound) {
+		t.Errorf("expected purged {{cookiecutter.entity_name_lower}} to be gone, got %v", err)
+	}
+}
+
+func Test{{cookiecutter.entity_name}}Service_Events(t *testing.T) {
+	repo := setupTestDB(t)
+	bus := event.NewBus()
+	svc := New{{cookiecutter.entity_name}}Service(repo, bus, nil)
+	ctx := logger.CorrelationIDToContext(context.Background(), "test-correlation-id")
+
+	var events []event.Event
+	bus.Subscribe("*", func(ctx context.Context, e event.Event) error {
+		events = append(events, e)
+		return nil
+	})
+
+	created, err := svc.Create(ctx, "Original")
+	if err != nil {
+		t.Fatalf("failed to create {{cookiecutter.entity_name_lower}}: %v", err)
+	}
+	id := created.ID.String()
+	if _, err := svc.Update(ctx, id, "Updated", 1); err != nil {
+		t.Fatalf("failed to update {{cookiecutter.entity_name_lower}}: %v", err)
+	}
+	// no change, no event
+	if _, err := svc.Patch(ctx, id, 2, func(doc []byte) ([]byte, error) { return doc, nil }); err != nil {
+		t.Fatalf("failed to patch {{cookiecutter.entity_name_lower}}: %v", err)
+	}
+	if err := svc.Delete(ctx, id, 2); err != nil {
+		t.Fatalf("failed to delete {{cookiecutter.entity_name_lower}}: %v", err)
+	}
+	if _, err := svc.Restore(ctx, id); err != nil {
+		t.Fatalf("failed to restore {{cookiecutter.entity_name_lower}}: %v", err)
+	}
+	// restoring a live {{cookiecutter.entity_name_lower}} changes nothing, no event
+	if _, err := svc.Restore(ctx, id); err != nil {
+		t.Fatalf("failed to restore live {{cookiecutter.entity_name_lower}}: %v", err)
+	}
+	// failed changes raise nothing
+	if _, err := svc.Update(ctx, id, "Stale", 1); !errors.Is(err, ErrVersionConflict) {
+		t.Fatalf("expected version conflict, got %v", err)
+	}
+
+	want := []struct {
+		eventType  string
+		beforeName string
+		afterName  string
+	}{
+		{eventType: Event{{cookiecutter.entity_name}}Created, afterName: "Original"},
+		{eventType: Event{{cookiecutter.entity_name}}Updated, beforeName: "Original", afterName: "Updated"},
+		{eventType: Event{{cookiecutter.entity_name}}Deleted, beforeName: "Updated"},
+		{eventType: Event{{cookiecutter.entity_name}}Restored, afterName: "Updated"},
+	}
+	if len(events) != len(want) {
+		t.Fatalf("got %d events, want %d", len(events), len(want))
+	}
+
+	name := func(raw json.RawMessage) string {
+		var snapshot *{{cookiecutter.entity_name}}Snapshot
+		if err := json.Unmarshal(raw, &snapshot); err != nil {
+			t.Fatalf("failed to decode snapshot: %v", err)
+		}
+		if snapshot == nil {
+			return ""
+		}
+		return snapshot.Name
+	}
+	for i, w := range want {
+		e := events[i]
+		if e.Type != w.eventType || e.AggregateType != {{cookiecutter.entity_name}}AggregateType || e.AggregateID != id {
+			t.Errorf("event %d = %s for %s/%s, want %s for %s/%s", i, e.Type, e.AggregateType, e.AggregateID, w.eventType, {{cookiecutter.entity_name}}AggregateType, id)
+		}
+		if e.CorrelationID != "test-correlation-id" {
+			t.Errorf("event %d correlation ID = %q, want %q", i, e.CorrelationID, "test-correlation-id")
+		}
+		if got := name(e.Before); got != w.beforeName {
+			t.Errorf("event %d before name = %q, want %q", i, got, w.beforeName)
+		}
+		if got := name(e.After); got != w.afterName {
+			t.Errorf("event %d after name = %q, want %q", i, got, w.afterName)
+		}
+	}
+}
+
+func Test{{cookiecutter.entity_name}}Service_EventFailureRollsBack(t *testing.T) {
+	repo := setupTestDB(t)
+	bus := event.NewBus()
+	svc := New{{cookiecutter.entity_name}}Service(repo, bus, nil)
+	ctx := context.Background()
+
+	errBoom := errors.New("boom")
+	bus.Subscribe(Event{{cookiecutter.entity_name}}Created, func(ctx context.Context, e event.Event) error {
+		return errBoom
+	})
+
+	if _, err := svc.Create(ctx, "Not Saved"); !errors.Is(err, errBoom) {
+		t.Fatalf("Create() error = %v, want %v", err, errBoom)
+	}
+	_, total, err := svc.List(ctx, "", "", query.Params{})
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if total != 0 {
+		t.Errorf("List() total = %d, want the create to be rolled back", total)
+	}
+}
+
+func Test{{cookiecutter.entity_name}}Service_Import(t *testing.T) {
+	errUnreadable := errors.New("unreadable row")
+	errBoom := errors.New("boom")
+
+	tests := []struct {
+		name        string
+		rows        []Import{{cookiecutter.entity_name}}
+		mode        WriteMode
+		wantErrs    []error
+		wantCreated int
+	}{
+		{
+			name: "Atomic",
+			rows: []Import{{cookiecutter.entity_name}}{
+				{Name: "A"},
+				{Name: "B"},
+			},
+			mode:        Atomic,
+			wantErrs:    []error{nil, nil},
+			wantCreated: 2,
+		},
+		{
+			name: "Atomic With Rejected Rows",
+			rows: []Import{{cookiecutter.entity_name}}{
+				{Name: "A"},
+				{Name: ""},
+				{Err: errUnreadable},
+				{Name: "D"},
+			},
+			mode:        Atomic,
+			wantErrs:    []error{ErrImportAborted, validation.ErrRequired, errUnreadable, ErrImportAborted},
+			wantCreated: 0,
+		},
+		{
+			name: "Best Effort",
+			rows: []Import{{cookiecutter.entity_name}}{
+				{Name: "A"},
+				{Name: ""},
+				{Err: errUnreadable},
+				{Name: "D"},
+			},
+			mode:        BestEffort,
+			wantErrs:    []error{nil, validation.ErrRequired, errUnreadable, nil},
+			wantCreated: 2,
+		},
+		{
+			name: "Best Effort Retries A Failed Batch",
+			rows: []Import{{cookiecutter.entity_name}}{
+				{Name: "Fails"},
+				{Name: "B"},
+				{Name: "C"},
+			},
+			mode:        BestEffort,
+			wantErrs:    []error{errBoom, nil, nil},
+			wantCreated: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := setupTestDB(t)
+			bus := event.NewBus()
+			svc := New{{cookiecutter.entity_name}}Service(repo, bus, nil)
+			ctx := context.Background()
+
+			events := 0
+			bus.Subscribe(Event{{cookiecutter.entity_name}}Created, func(ctx context.Context, e event.Event) error {
+				var snapshot {{cookiecutter.entity_name}}Snapshot
+				if err := json.Unmarshal(e.After, &snapshot); err != nil {
+					return err
+				}
+				if snapshot.Name == "Fails" {
+					return errBoom
+				}
+				events++
+				return nil
+			})
+
+			results, err := svc.Import(ctx, tt.rows, tt.mode)
+			if err != nil {
+				t.Fatalf("Import() error = %v", err)
+			}
+			if len(results) != len(tt.rows) {
+				t.Fatalf("got %d results, want %d", len(results), len(tt.rows))
+			}
+			for i, result := range results {
+				if !errors.Is(result.Err, tt.wantErrs[i]) || (tt.wantErrs[i] == nil) != (result.Err == nil) {
+					t.Errorf("row %d error = %v, want %v", i, result.Err, tt.wantErrs[i])
+				}
+				if (result.{{cookiecutter.entity_name}} != nil) != (result.Err == nil) {
+					t.Errorf("row %d {{cookiecutter.entity_name_lower}} = %v with error %v, want exactly one", i, result.{{cookiecutter.entity_name}}, result.Err)
+				}
+			}
+
+			_, total, err := svc.List(ctx, "", "", query.Params{})
+			if err != nil {
+				t.Fatalf("List() error = %v", err)
+			}
+			if total != tt.wantCreated {
+				t.Errorf("List() total = %d, want %d", total, tt.wantCreated)
+			}
+			if events != tt.wantCreated {
+				t.Errorf("got %d created events, want %d", events, tt.wantCreated)
+			}
+		})
+	}
+}
+
+func Test{{cookiecutter.entity_name}}Service_Batch(t *testing.T) {
+	tests := []struct {
+		name     string
+		mode     WriteMode
+		stale    bool
+		wantErrs []error
+		// the state of the seeded {{cookiecutter.entity_name_lower}}s after the batch
+		wantName    string
+		wantDeleted bool
+		wantTotal   int
+	}{
+		{
+			name:        "Atomic",
+			mode:        Atomic,
+			wantErrs:    []error{nil, nil, nil},
+			wantName:    "Renamed",
+			wantDeleted: true,
+			wantTotal:   2,
+		},
+		{
+			name:      "Atomic With Failed Operation",
+			mode:      Atomic,
+			stale:     true,
+			wantErrs:  []error{ErrBatchAborted, ErrBatchAborted, ErrBatchAborted, ErrVersionConflict},
+			wantName:  "Kept",
+			wantTotal: 2,
+		},
+		{
+			name:        "Best Effort With Failed Operation",
+			mode:        BestEffort,
+			stale:       true,
+			wantErrs:    []error{nil, nil, nil, ErrVersionConflict},
+			wantName:    "Renamed",
+			wantDeleted: true,
+			wantTotal:   2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := setupTestDB(t)
+			svc := New{{cookiecutter.entity_name}}Service(repo, event.NewBus(), nil)
+			ctx := context.Background()
+
+			kept, err := svc.Create(ctx, "Kept")
+			if err != nil {
+				t.Fatalf("failed to create {{cookiecutter.entity_name_lower}}: %v", err)
+			}
+			deleted, err := svc.Create(ctx, "Deleted")
+			if err != nil {
+				t.Fatalf("failed to create {{cookiecutter.entity_name_lower}}: %v", err)
+			}
+
+			ops := []BatchOperation{
+				{Op: BatchCreate, Name: "Created"},
+				{Op: BatchUpdate, ID: kept.ID.String(), Name: "Renamed", Version: 1},
+				{Op: BatchDelete, ID: deleted.ID.String(), Version: 1},
+			}
+			if tt.stale {
+				ops = append(ops, BatchOperation{Op: BatchUpdate, ID: kept.ID.String(), Name: "Stale", Version: 1})
+			}
+
+			results, err := svc.Batch(ctx, ops, tt.mode)
+			if err != nil {
+				t.Fatalf("Batch() error = %v", err)
+			}
+			if len(results) != len(tt.wantErrs) {
+				t.Fatalf("got %d results, want %d", len(results), len(tt.wantErrs))
+			}
+			for i, result := range results {
+				if !errors.Is(result.Err, tt.wantErrs[i]) || (tt.wantErrs[i] == nil) != (result.Err == nil) {
+					t.Errorf("operation %d error = %v, want %v", i, result.Err, tt.wantErrs[i])
 				}
 			}
+			if tt.wantErrs[0] == nil && (results[0].{{cookiecutter.entity_name}} == nil || results[0].{{cookiecutter.entity_name}}.Name != "Created") {
+				t.Errorf("create result = %+v, want the created {{cookiecutter.entity_name_lower}}", results[0].{{cookiecutter.entity_name}})
+			}
+
+			got, err := svc.Get(ctx, kept.ID.String())
+			if err != nil {
+				t.Fatalf("Get() error = %v", err)
+			}
+			if got.Name != tt.wantName {
+				t.Errorf("name = %q, want %q", got.Name, tt.wantName)
+			}
+			if _, err := svc.Get(ctx, deleted.ID.String()); errors.Is(err, Err{{cookiecutter.entity_name}}NotFound) != tt.wantDeleted {
+				t.Errorf("Get() of the deleted {{cookiecutter.entity_name_lower}} error = %v, want deleted %v", err, tt.wantDeleted)
+			}
+			_, total, err := svc.List(ctx, "", "", query.Params{})
+			if err != nil {
+				t.Fatalf("List() error = %v", err)
+			}
+			if total != tt.wantTotal {
+				t.Errorf("List() total = %d, want %d", total, tt.wantTotal)
+			}
 		})
 	}
 }
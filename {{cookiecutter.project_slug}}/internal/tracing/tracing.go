@@ -0,0 +1,96 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+
+	"{{cookiecutter.module_name}}/internal/version"
+)
+
+// Exporters spans can be sent to, see Setup.
+const (
+	ExporterNone   = "none"   // spans are recorded for their IDs but not sent
+	ExporterStdout = "stdout" // spans are written to stderr as JSON
+	ExporterOTLP   = "otlp"   // spans are sent to OTEL_EXPORTER_OTLP_ENDPOINT over HTTP
+)
+
+// instrumentationName names the tracer of every span this module starts.
+const instrumentationName = "{{cookiecutter.module_name}}"
+
+// ValidateExporter checks that exporter is one Setup knows.
+func ValidateExporter(exporter string) error {
+	switch exporter {
+	case "", ExporterNone, ExporterStdout, ExporterOTLP:
+		return nil
+	}
+	return fmt.Errorf("TRACE_EXPORTER must be %s, %s or %s, got %q", ExporterOTLP, ExporterStdout, ExporterNone, exporter)
+}
+
+// Setup installs the global tracer provider, which sends spans to exporter,
+// ExporterNone when empty, and the W3C trace context and baggage
+// propagators. Spans are sampled when their parent was, and always when
+// they have none, unless OTEL_TRACES_SAMPLER says otherwise. The returned
+// function flushes the spans not sent yet and must be called on shutdown.
+func Setup(ctx context.Context, exporter string, v version.Version) (func(context.Context) error, error) {
+	if err := ValidateExporter(exporter); err != nil {
+		return nil, err
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(
+		attribute.String("service.name", "{{cookiecutter.project_slug}}"),
+		attribute.String("service.version", v.Build),
+		attribute.String("vcs.ref.head.name", v.Branch),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("create resource: %w", err)
+	}
+	opts := []sdktrace.TracerProviderOption{sdktrace.WithResource(res)}
+
+	switch exporter {
+	case ExporterStdout:
+		exp, err := stdouttrace.New(stdouttrace.WithWriter(os.Stderr))
+		if err != nil {
+			return nil, fmt.Errorf("create stdout exporter: %w", err)
+		}
+		opts = append(opts, sdktrace.WithBatcher(exp))
+	case ExporterOTLP:
+		exp, err := otlptracehttp.New(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("create OTLP exporter: %w", err)
+		}
+		opts = append(opts, sdktrace.WithBatcher(exp))
+	}
+
+	provider := sdktrace.NewTracerProvider(opts...)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	return provider.Shutdown, nil
+}
+
+// Tracer returns the tracer of the global provider. It is looked up on each
+// call, so spans started before Setup are not lost to a stale provider.
+func Tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+// Inject writes the trace context of ctx into carrier, such as the
+// attributes of a message.
+func Inject(ctx context.Context, carrier map[string]string) {
+	otel.GetTextMapPropagator().Inject(ctx, propagation.MapCarrier(carrier))
+}
+
+// Extract returns ctx with the trace context read from carrier, which
+// Inject wrote.
+func Extract(ctx context.Context, carrier map[string]string) context.Context {
+	return otel.GetTextMapPropagator().Extract(ctx, propagation.MapCarrier(carrier))
+}
@@ -0,0 +1,185 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"{{cookiecutter.module_name}}/internal/db"
+	"{{cookiecutter.module_name}}/internal/version"
+)
+
+// recordSpans installs a tracer provider that keeps the spans ended during
+// the test, and restores the global one after it.
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+	provider, propagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	t.Cleanup(func() {
+		otel.SetTracerProvider(provider)
+		otel.SetTextMapPropagator(propagator)
+	})
+
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	return recorder
+}
+
+func attributeValue(span sdktrace.ReadOnlySpan, key attribute.Key) string {
+	for _, kv := range span.Attributes() {
+		if kv.Key == key {
+			return kv.Value.Emit()
+		}
+	}
+	return ""
+}
+
+func TestSetup(t *testing.T) {
+	tests := []struct {
+		exporter string
+		wantErr  bool
+	}{
+		{exporter: ""},
+		{exporter: ExporterNone},
+		{exporter: ExporterStdout},
+		{exporter: ExporterOTLP},
+		{exporter: "jaeger", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.exporter, func(t *testing.T) {
+			recordSpans(t)
+
+			shutdown, err := Setup(context.Background(), tt.exporter, version.Version{Build: "test-build"})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Setup() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			defer shutdown(context.Background())
+
+			// spans get IDs to log and propagate whichever exporter is used
+			ctx, span := Tracer().Start(context.Background(), "test")
+			defer span.End()
+			carrier := map[string]string{}
+			Inject(ctx, carrier)
+			if !strings.Contains(carrier["traceparent"], span.SpanContext().TraceID().String()) {
+				t.Errorf("expected traceparent with the trace ID, got %v", carrier)
+			}
+		})
+	}
+}
+
+func TestInjectExtract(t *testing.T) {
+	recordSpans(t)
+	ctx, span := Tracer().Start(context.Background(), "parent")
+	defer span.End()
+
+	carrier := map[string]string{"event": "test.created"}
+	Inject(ctx, carrier)
+	got := Extract(context.Background(), carrier)
+
+	if _, child := Tracer().Start(got, "child"); child.SpanContext().TraceID() != span.SpanContext().TraceID() {
+		t.Error("expected the extracted context to continue the trace")
+	}
+	if carrier["event"] != "test.created" {
+		t.Error("expected the other attributes to be kept")
+	}
+}
+
+func TestTransport(t *testing.T) {
+	recorder := recordSpans(t)
+	var traceparent string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("traceparent")
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	ctx, parent := Tracer().Start(context.Background(), "parent")
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/keys", nil)
+	resp, err := (&http.Client{Transport: Transport(nil)}).Do(req)
+	if err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	resp.Body.Close()
+	parent.End()
+
+	if !strings.Contains(traceparent, parent.SpanContext().TraceID().String()) {
+		t.Errorf("expected traceparent with the trace ID, got %q", traceparent)
+	}
+	if req.Header.Get("traceparent") != "" {
+		t.Error("expected the request of the caller to be left alone")
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("expected 2 spans, got %d", len(spans))
+	}
+	client := spans[0]
+	if client.Name() != http.MethodGet || client.Parent().SpanID() != parent.SpanContext().SpanID() {
+		t.Errorf("expected a GET span under the parent, got %s", client.Name())
+	}
+	if got := attributeValue(client, "http.response.status_code"); got != "404" {
+		t.Errorf("expected status code 404, got %q", got)
+	}
+}
+
+type record struct {
+	ID   int
+	Name string
+}
+
+func TestGormPlugin(t *testing.T) {
+	recorder := recordSpans(t)
+	conn, err := db.MakeDbSqlite()
+	if err != nil {
+		t.Fatalf("failed to connect database: %v", err)
+	}
+	if err := conn.Use(GormPlugin{}); err != nil {
+		t.Fatalf("Use() error = %v", err)
+	}
+	if err := conn.AutoMigrate(&record{}); err != nil {
+		t.Fatalf("failed to migrate database: %v", err)
+	}
+
+	// outside of a trace nothing is recorded
+	if err := conn.Create(&record{ID: 1, Name: "a"}).Error; err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if n := len(recorder.Ended()); n != 0 {
+		t.Fatalf("expected no spans outside of a trace, got %d", n)
+	}
+
+	ctx, parent := Tracer().Start(context.Background(), "parent")
+	var got record
+	if err := conn.WithContext(ctx).First(&got, 1).Error; err != nil {
+		t.Fatalf("First() error = %v", err)
+	}
+	conn.WithContext(ctx).Table("missing").Find(&[]record{})
+	parent.End()
+
+	spans := recorder.Ended()
+	if len(spans) != 3 {
+		t.Fatalf("expected 3 spans, got %d", len(spans))
+	}
+	query := spans[0]
+	if query.Name() != "select record" || query.Parent().SpanID() != parent.SpanContext().SpanID() {
+		t.Errorf("expected a select record span under the parent, got %s", query.Name())
+	}
+	if text := attributeValue(query, "db.query.text"); !strings.HasPrefix(text, "SELECT * FROM `record`") {
+		t.Errorf("expected the query text, got %q", text)
+	}
+	if failed := spans[1]; failed.Status().Code.String() != "Error" {
+		t.Errorf("expected the query of a missing table to fail, got %s", failed.Status().Code)
+	}
+}
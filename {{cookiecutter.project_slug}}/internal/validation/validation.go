@@ -0,0 +1,166 @@
+package validation
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// ErrRequired is the error of fields that must not be empty.
+var ErrRequired = errors.New("is required")
+
+// Rule checks a value and returns the error of the first problem it finds.
+// Rules other than Required accept the zero value, so optional fields only
+// need Required left out.
+type Rule[T any] func(value T) error
+
+// Required rejects empty strings.
+func Required() Rule[string] {
+	return func(value string) error {
+		if value == "" {
+			return ErrRequired
+		}
+		return nil
+	}
+}
+
+// MinLen rejects strings shorter than n characters.
+func MinLen(n int) Rule[string] {
+	err := fmt.Errorf("must be at least %d characters", n)
+	return func(value string) error {
+		if value != "" && utf8.RuneCountInString(value) < n {
+			return err
+		}
+		return nil
+	}
+}
+
+// MaxLen rejects strings longer than n characters, such as values that do
+// not fit a VARCHAR(n) column.
+func MaxLen(n int) Rule[string] {
+	err := fmt.Errorf("must be at most %d characters", n)
+	return func(value string) error {
+		if utf8.RuneCountInString(value) > n {
+			return err
+		}
+		return nil
+	}
+}
+
+// Pattern rejects strings that re does not match. message says what the
+// pattern requires, the expression itself means little to clients.
+func Pattern(re *regexp.Regexp, message string) Rule[string] {
+	err := errors.New(message)
+	return func(value string) error {
+		if value != "" && !re.MatchString(value) {
+			return err
+		}
+		return nil
+	}
+}
+
+// OneOf rejects values that are not one of values.
+func OneOf[T comparable](values ...T) Rule[T] {
+	names := make([]string, len(values))
+	for i, v := range values {
+		names[i] = fmt.Sprint(v)
+	}
+	err := fmt.Errorf("must be one of %s", strings.Join(names, ", "))
+	return func(value T) error {
+		var zero T
+		if value != zero && !slices.Contains(values, value) {
+			return err
+		}
+		return nil
+	}
+}
+
+// Errors maps fields to what is wrong with them. Errors wrap the errors of
+// their fields, so errors.Is(err, ErrRequired) finds a missing field.
+type Errors map[string]error
+
+func (e Errors) Error() string {
+	fields := e.names()
+	msgs := make([]string, len(fields))
+	for i, field := range fields {
+		msgs[i] = field + " " + e[field].Error()
+	}
+	return "invalid fields: " + strings.Join(msgs, ", ")
+}
+
+// Detail is the message of e, it only holds the messages of the rules.
+func (e Errors) Detail() string {
+	return e.Error()
+}
+
+// Fields returns the message of each field, they are reported as field
+// errors of the problem.
+func (e Errors) Fields() map[string]string {
+	fields := make(map[string]string, len(e))
+	for field, err := range e {
+		fields[field] = err.Error()
+	}
+	return fields
+}
+
+func (e Errors) Unwrap() []error {
+	errs := make([]error, 0, len(e))
+	for _, field := range e.names() {
+		errs = append(errs, e[field])
+	}
+	return errs
+}
+
+func (e Errors) names() []string {
+	names := make([]string, 0, len(e))
+	for field := range e {
+		names = append(names, field)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// Validator collects the errors of every field instead of stopping at the
+// first. The zero value is ready to use.
+type Validator struct {
+	errs Errors
+}
+
+// Field checks value against rules in order and records the first error.
+// A field keeps the first error recorded for it.
+func Field[T any](v *Validator, field string, value T, rules ...Rule[T]) {
+	for _, rule := range rules {
+		if err := rule(value); err != nil {
+			v.add(field, err)
+			return
+		}
+	}
+}
+
+// Check records message for field unless ok, for rules that span fields
+// such as a range whose start must come before its end.
+func (v *Validator) Check(ok bool, field, message string) {
+	if !ok {
+		v.add(field, errors.New(message))
+	}
+}
+
+func (v *Validator) add(field string, err error) {
+	if v.errs == nil {
+		v.errs = Errors{}
+	}
+	if _, ok := v.errs[field]; !ok {
+		v.errs[field] = err
+	}
+}
+
+// Err returns the collected errors, nil when every field is valid.
+func (v *Validator) Err() error {
+	if len(v.errs) == 0 {
+		return nil
+	}
+	return v.errs
+}
@@ -0,0 +1,81 @@
+package validation
+
+import (
+	"errors"
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+var slug = regexp.MustCompile(`^[a-z0-9-]+$`)
+
+func TestRules(t *testing.T) {
+	tests := []struct {
+		name    string
+		rule    Rule[string]
+		value   string
+		wantErr string
+	}{
+		{name: "Required", rule: Required(), value: "a"},
+		{name: "Required Empty", rule: Required(), value: "", wantErr: "is required"},
+		{name: "MinLen", rule: MinLen(3), value: "abc"},
+		{name: "MinLen Too Short", rule: MinLen(3), value: "ab", wantErr: "must be at least 3 characters"},
+		{name: "MinLen Empty", rule: MinLen(3), value: ""},
+		{name: "MaxLen", rule: MaxLen(3), value: "äöü"},
+		{name: "MaxLen Too Long", rule: MaxLen(3), value: "abcd", wantErr: "must be at most 3 characters"},
+		{name: "Pattern", rule: Pattern(slug, "must be a slug"), value: "my-slug"},
+		{name: "Pattern Mismatch", rule: Pattern(slug, "must be a slug"), value: "My Slug", wantErr: "must be a slug"},
+		{name: "Pattern Empty", rule: Pattern(slug, "must be a slug"), value: ""},
+		{name: "OneOf", rule: OneOf("asc", "desc"), value: "desc"},
+		{name: "OneOf Other", rule: OneOf("asc", "desc"), value: "up", wantErr: "must be one of asc, desc"},
+		{name: "OneOf Empty", rule: OneOf("asc", "desc"), value: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.rule(tt.value)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("rule(%q) error = %v, want nil", tt.value, err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("rule(%q) error = %v, want %q", tt.value, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidator(t *testing.T) {
+	var v Validator
+	if err := v.Err(); err != nil {
+		t.Fatalf("Err() of an unused validator = %v, want nil", err)
+	}
+
+	Field(&v, "name", "", Required(), MaxLen(3))
+	Field(&v, "slug", "No Slug", Pattern(slug, "must be a slug"))
+	Field(&v, "order", "asc", OneOf("asc", "desc"))
+	v.Check(false, "name", "must differ from slug")
+	v.Check(false, "end", "must be after start")
+
+	err := v.Err()
+	var errs Errors
+	if !errors.As(err, &errs) {
+		t.Fatalf("Err() = %v, want Errors", err)
+	}
+	want := map[string]string{
+		"name": "is required",
+		"slug": "must be a slug",
+		"end":  "must be after start",
+	}
+	if got := errs.Fields(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Fields() = %v, want %v", got, want)
+	}
+	if got := err.Error(); got != "invalid fields: end must be after start, name is required, slug must be a slug" {
+		t.Errorf("Error() = %q", got)
+	}
+	if !errors.Is(err, ErrRequired) {
+		t.Error("expected Errors to wrap ErrRequired")
+	}
+}
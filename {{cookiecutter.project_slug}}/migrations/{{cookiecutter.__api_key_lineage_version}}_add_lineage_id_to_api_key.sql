@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE api_key ADD COLUMN lineage_id UUID NULL;
+
+-- keys minted so far keep their ID as subject
+UPDATE api_key SET lineage_id = id;
+
+ALTER TABLE api_key ALTER COLUMN lineage_id SET NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE api_key DROP COLUMN IF EXISTS lineage_id;
+-- +goose StatementEnd
@@ -0,0 +1,9 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE INDEX idx_{{cookiecutter.entity_name_lower}}_created_at_id ON {{cookiecutter.entity_name_lower}}(created_at, id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_{{cookiecutter.entity_name_lower}}_created_at_id;
+-- +goose StatementEnd
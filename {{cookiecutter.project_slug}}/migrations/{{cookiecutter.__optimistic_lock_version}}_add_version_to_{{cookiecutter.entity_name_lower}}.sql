@@ -0,0 +1,9 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE {{cookiecutter.entity_name_lower}} ADD COLUMN version BIGINT NOT NULL DEFAULT 1;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE {{cookiecutter.entity_name_lower}} DROP COLUMN IF EXISTS version;
+-- +goose StatementEnd
@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+-- serves the due messages the relay claims, in id order
+CREATE INDEX idx_outbox_message_status_next_attempt_at_id ON outbox_message(status, next_attempt_at, id);
+-- serves the lookup of earlier pending messages of an aggregate
+CREATE INDEX idx_outbox_message_pending_aggregate_id_id ON outbox_message(aggregate_id, id) WHERE status = 'pending';
+-- covered by idx_outbox_message_status_next_attempt_at_id
+DROP INDEX IF EXISTS idx_outbox_message_status;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+CREATE INDEX idx_outbox_message_status ON outbox_message(status);
+DROP INDEX IF EXISTS idx_outbox_message_pending_aggregate_id_id;
+DROP INDEX IF EXISTS idx_outbox_message_status_next_attempt_at_id;
+-- +goose StatementEnd
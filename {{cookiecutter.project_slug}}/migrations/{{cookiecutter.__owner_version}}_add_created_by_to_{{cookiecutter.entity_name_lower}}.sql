@@ -0,0 +1,9 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE {{cookiecutter.entity_name_lower}} ADD COLUMN created_by TEXT NOT NULL DEFAULT '';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE {{cookiecutter.entity_name_lower}} DROP COLUMN IF EXISTS created_by;
+-- +goose StatementEnd
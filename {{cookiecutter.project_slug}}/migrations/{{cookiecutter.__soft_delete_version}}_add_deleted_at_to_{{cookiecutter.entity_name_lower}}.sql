@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE {{cookiecutter.entity_name_lower}} ADD COLUMN deleted_at TIMESTAMP NULL;
+
+CREATE INDEX idx_{{cookiecutter.entity_name_lower}}_deleted_at ON {{cookiecutter.entity_name_lower}}(deleted_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_{{cookiecutter.entity_name_lower}}_deleted_at;
+
+ALTER TABLE {{cookiecutter.entity_name_lower}} DROP COLUMN IF EXISTS deleted_at;
+-- +goose StatementEnd
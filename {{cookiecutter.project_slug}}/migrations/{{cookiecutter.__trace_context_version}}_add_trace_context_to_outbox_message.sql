@@ -0,0 +1,9 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE outbox_message ADD COLUMN trace_context BYTEA NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE outbox_message DROP COLUMN IF EXISTS trace_context;
+-- +goose StatementEnd